@@ -0,0 +1,225 @@
+package canon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Canonicalize parses the JSON document in r and returns its canonical form
+// as defined by RFC 8785 (JSON Canonicalization Scheme)
+//
+// two documents that represent the same data canonicalize to the same bytes,
+// regardless of whitespace, object member order, number spelling or string escaping
+// nothing but whitespace may follow the value of the document, and as RFC 8785
+// requires I-JSON, escape sequences of surrogates must form pairs
+func Canonicalize(r io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	l := lex.New(bytes.NewReader(data))
+	v, err := parse.ParseValue(l, l.ReadToken())
+	if err != nil {
+		return nil, err
+	}
+	if t := l.ReadToken(); t.TokenType != tok.EOF {
+		return nil, fmt.Errorf("Expected end of document, found: %q", t.Literal)
+	}
+	if err := checkSurrogates(data); err != nil {
+		return nil, err
+	}
+	return Marshal(v)
+}
+
+// reports the first escape sequence of a surrogate that is not part of a pair,
+// which the lexer would have replaced with U+FFFD, in data, a valid document
+func checkSurrogates(data []byte) error {
+	for i := 0; i < len(data); i++ {
+		n := lex.EscapeLen(data[i:])
+		if n == 0 {
+			continue
+		}
+		if n == 2 {
+			i++
+			continue
+		}
+		if r := escapedRune(data[i:]); utf16.IsSurrogate(r) {
+			if lex.EscapeLen(data[i+6:]) != 6 || utf16.DecodeRune(r, escapedRune(data[i+6:])) == utf8.RuneError {
+				return fmt.Errorf("Unpaired surrogate %s at offset %d", data[i:i+6], i)
+			}
+			n += 6
+		}
+		i += n - 1
+	}
+	return nil
+}
+
+// the rune of the \u escape sequence str begins with
+func escapedRune(str []byte) rune {
+	r, _ := strconv.ParseUint(string(str[2:6]), 16, 16)
+	return rune(r)
+}
+
+// Hash returns the SHA-256 digest of the canonical form of the JSON document in r
+// it is stable across any reformatting of the document
+func Hash(r io.Reader) ([sha256.Size]byte, error) {
+	canonical, err := Canonicalize(r)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(canonical), nil
+}
+
+// Marshal returns the canonical form of v
+// v is expected to be made up of the values the parser produces
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeValue(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeValue(buf *bytes.Buffer, v interface{}) error {
+	switch actual := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(actual))
+	case string:
+		return writeString(buf, actual)
+	case int:
+		// all JSON numbers are IEEE 754 doubles as far as JCS is concerned
+		return writeNumber(buf, float64(actual))
+	case float64:
+		return writeNumber(buf, actual)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range actual {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeValue(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for i, k := range sortedKeys(actual) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeString(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeValue(buf, actual[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("Unsupported value type: %T", v)
+	}
+	return nil
+}
+
+// strings are written as is, except for the characters JSON requires to be escaped
+func writeString(buf *bytes.Buffer, str string) error {
+	if !utf8.ValidString(str) {
+		return fmt.Errorf("Invalid UTF-8 in string: %q", str)
+	}
+	encode.WriteString(buf, str)
+	return nil
+}
+
+// object keys are sorted by their UTF-16 code units rather than by
+// their UTF-8 bytes, the two orders differ for characters above U+FFFF
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	units := make(map[string][]uint16, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+		units[k] = utf16.Encode([]rune(k))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := units[keys[i]], units[keys[j]]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return keys
+}
+
+// writeNumber writes f the way ECMAScript's Number.prototype.toString does,
+// the shortest digits that round trip, switching to exponent notation
+// for values of 1e21 and above or below 1e-6
+func writeNumber(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("Unsupported number: %v", f)
+	}
+	if f == 0 {
+		// also covers negative zero
+		buf.WriteByte('0')
+		return nil
+	}
+	if f < 0 {
+		buf.WriteByte('-')
+		f = -f
+	}
+
+	// shortest representation d.ddde±x gives the digits and the exponent
+	repr := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp := repr, 0
+	if i := strings.IndexByte(repr, 'e'); i >= 0 {
+		mantissa = repr[:i]
+		exp, _ = strconv.Atoi(repr[i+1:])
+	}
+	digits := strings.Replace(mantissa, ".", "", 1)
+	// the value is 0.digits * 10^n
+	k, n := len(digits), exp+1
+
+	switch {
+	case k <= n && n <= 21:
+		buf.WriteString(digits)
+		buf.WriteString(strings.Repeat("0", n-k))
+	case 0 < n && n <= 21:
+		buf.WriteString(digits[:n])
+		buf.WriteByte('.')
+		buf.WriteString(digits[n:])
+	case -6 < n && n <= 0:
+		buf.WriteString("0.")
+		buf.WriteString(strings.Repeat("0", -n))
+		buf.WriteString(digits)
+	default:
+		buf.WriteByte(digits[0])
+		if k > 1 {
+			buf.WriteByte('.')
+			buf.WriteString(digits[1:])
+		}
+		buf.WriteByte('e')
+		if n-1 >= 0 {
+			buf.WriteByte('+')
+		}
+		buf.WriteString(strconv.Itoa(n - 1))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package canon
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// the IEEE 754 number serialization samples from Appendix B of RFC 8785
+func TestWriteNumber(t *testing.T) {
+	tests := []struct {
+		bits    string
+		want    string
+		wantErr bool
+	}{
+		{bits: "0000000000000000", want: "0"},
+		{bits: "8000000000000000", want: "0"},
+		{bits: "0000000000000001", want: "5e-324"},
+		{bits: "8000000000000001", want: "-5e-324"},
+		{bits: "7fefffffffffffff", want: "1.7976931348623157e+308"},
+		{bits: "ffefffffffffffff", want: "-1.7976931348623157e+308"},
+		{bits: "4340000000000000", want: "9007199254740992"},
+		{bits: "c340000000000000", want: "-9007199254740992"},
+		{bits: "4430000000000000", want: "295147905179352830000"},
+		{bits: "7fffffffffffffff", wantErr: true},
+		{bits: "7ff0000000000000", wantErr: true},
+		{bits: "44b52d02c7e14af5", want: "9.999999999999997e+22"},
+		{bits: "44b52d02c7e14af6", want: "1e+23"},
+		{bits: "44b52d02c7e14af7", want: "1.0000000000000001e+23"},
+		{bits: "444b1ae4d6e2ef4e", want: "999999999999999700000"},
+		{bits: "444b1ae4d6e2ef4f", want: "999999999999999900000"},
+		{bits: "444b1ae4d6e2ef50", want: "1e+21"},
+		{bits: "3eb0c6f7a0b5ed8c", want: "9.999999999999997e-7"},
+		{bits: "3eb0c6f7a0b5ed8d", want: "0.000001"},
+		{bits: "41b3de4355555553", want: "333333333.3333332"},
+		{bits: "41b3de4355555554", want: "333333333.33333325"},
+		{bits: "41b3de4355555555", want: "333333333.3333333"},
+		{bits: "41b3de4355555556", want: "333333333.3333334"},
+		{bits: "41b3de4355555557", want: "333333333.33333343"},
+		{bits: "becbf647612f3696", want: "-0.0000033333333333333333"},
+		{bits: "43143ff3c1cb0959", want: "1424953923781206.2"},
+	}
+
+	for _, test := range tests {
+		bits, err := strconv.ParseUint(test.bits, 16, 64)
+		if err != nil {
+			t.Fatalf("ParseUint(%q): %v", test.bits, err)
+		}
+		var buf bytes.Buffer
+
+		err = writeNumber(&buf, math.Float64frombits(bits))
+
+		gotErr := err != nil
+		if gotErr != test.wantErr || buf.String() != test.want {
+			t.Errorf("bits: %s, got: %q, want: %q, err: %v, wantErr: %v",
+				test.bits, buf.String(), test.want, err, test.wantErr)
+		}
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		// section 3.2.2 of RFC 8785
+		{
+			doc: `{
+  "numbers": [333333333.33333329, 1E30, 4.50,
+              2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`,
+			want: "{\"literals\":[null,true,false],\"numbers\":[333333333.3333333,1e+30,4.5,0.002,1e-27]," +
+				"\"string\":\"\u20ac$\\u000f\\nA'B\\\"\\\\\\\\\\\"/\"}",
+		},
+		// section 3.2.3 of RFC 8785, keys sorted by UTF-16 code units
+		{
+			doc: `{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`,
+			want: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\"," +
+				"\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\"," +
+				"\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{doc: `  [ 10, -0.0, 1e2, "a" ]  `, want: `[10,0,100,"a"]`},
+		{doc: `{"b": {"d": 1, "c": 2}, "a": []}`, want: `{"a":[],"b":{"c":2,"d":1}}`},
+		{doc: `["\ufffd", "\\ud800", "\ud83d\ude00\u00e9"]`, want: "[\"\ufffd\",\"\\\\ud800\",\"\U0001F600\u00e9\"]"},
+		// integers beyond int64 are numbers all the same
+		{doc: `[18446744073709551616, -9223372036854775809]`, want: `[18446744073709552000,-9223372036854776000]`},
+	}
+
+	for _, test := range tests {
+		got, err := Canonicalize(strings.NewReader(test.doc))
+
+		if err != nil || string(got) != test.want {
+			t.Errorf("doc: %s, got: %s, want: %s, err: %v", test.doc, got, test.want, err)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	h1, err := Hash(strings.NewReader(`{"a": 1.0, "b": ["x", "\u0079"]}`))
+	if err != nil {
+		t.Fatalf("Hash(): %v", err)
+	}
+	h2, err := Hash(strings.NewReader(`{ "b" : [ "x", "y" ], "a" : 1 }`))
+	if err != nil {
+		t.Fatalf("Hash(): %v", err)
+	}
+	h3, err := Hash(strings.NewReader(`{"a": 2, "b": ["x", "y"]}`))
+	if err != nil {
+		t.Fatalf("Hash(): %v", err)
+	}
+
+	if h1 != h2 {
+		t.Errorf("equivalent documents hashed differently: %x, %x", h1, h2)
+	}
+	if h1 == h3 {
+		t.Errorf("different documents hashed the same: %x", h1)
+	}
+
+	if _, err := Hash(strings.NewReader(`{"a": `)); err == nil {
+		t.Errorf("expected error hashing invalid document")
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	tests := []string{
+		`{"a": 1} {"evil": 2}`,
+		`[1] 2`,
+		`1 1`,
+		`{"a": 1}}`,
+		`{"a": 1, "a": 2}`,
+		``,
+		`["\ud800"]`,
+		`["\udfff"]`,
+		`["\ud83d\u0041"]`,
+		`{"\ude00\ud83d": 1}`,
+		`["\ud83d\\ude00"]`,
+	}
+
+	for _, test := range tests {
+		if got, err := Canonicalize(strings.NewReader(test)); err == nil {
+			t.Errorf("doc: %s, got: %s, want an error", test, got)
+		}
+		if _, err := Hash(strings.NewReader(test)); err == nil {
+			t.Errorf("doc: %s, Hash() did not fail", test)
+		}
+	}
+}
+
+func TestMarshalInvalidString(t *testing.T) {
+	if got, err := Marshal("\xff"); err == nil {
+		t.Errorf("expected error marshaling invalid UTF-8, got: %s", got)
+	}
+}
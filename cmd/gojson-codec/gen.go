@@ -0,0 +1,540 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vyevs/gojson/encode"
+)
+
+// the packages the generated code may refer to, by the name it uses
+var importPaths = map[string]string{
+	"bytes":   "bytes",
+	"sort":    "sort",
+	"strconv": "strconv",
+	"gojson":  "github.com/vyevs/gojson",
+	"encode":  "github.com/vyevs/gojson/encode",
+	"lex":     "github.com/vyevs/gojson/lex",
+	"tok":     "github.com/vyevs/gojson/tok",
+}
+
+// the predeclared types decoded and encoded without reflection
+var (
+	intBits   = map[string]int{"int": 0, "int8": 8, "int16": 16, "int32": 32, "int64": 64, "rune": 32}
+	uintBits  = map[string]int{"uint": 0, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64, "byte": 8, "uintptr": 0}
+	floatBits = map[string]int{"float32": 32, "float64": 64}
+)
+
+type structField struct {
+	goName    string
+	jsonName  string
+	omitEmpty bool
+	typ       ast.Expr
+}
+
+// the methods gojson.Unmarshal and Marshal call in place of decoding
+// and encoding a value by its type
+var hookMethods = map[string]bool{
+	"DecodeJSON":    true,
+	"UnmarshalJSON": true,
+	"UnmarshalText": true,
+	"EncodeJSON":    true,
+	"MarshalJSON":   true,
+	"MarshalText":   true,
+}
+
+type generator struct {
+	specs   map[string]ast.Expr // the types declared in the package
+	listed  map[string]bool     // the types methods are generated for
+	hooked  map[string]bool     // the types declared with any of hookMethods
+	imports map[string]bool
+	buf     bytes.Buffer
+}
+
+// generate returns the formatted source of a file in package pkgName declaring
+// DecodeJSON and EncodeJSON methods for each of the named struct types of pkg
+func generate(pkg *ast.Package, pkgName string, typeNames []string) ([]byte, error) {
+	g := generator{
+		specs:   map[string]ast.Expr{},
+		listed:  map[string]bool{},
+		hooked:  map[string]bool{},
+		imports: map[string]bool{"bytes": true, "gojson": true, "lex": true, "tok": true},
+	}
+	for _, f := range pkg.Files {
+		for _, d := range f.Decls {
+			if fd, ok := d.(*ast.FuncDecl); ok {
+				if name := receiverName(fd); name != "" && hookMethods[fd.Name.Name] {
+					g.hooked[name] = true
+				}
+				continue
+			}
+			gd, ok := d.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, s := range gd.Specs {
+				if ts, ok := s.(*ast.TypeSpec); ok {
+					g.specs[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+	}
+	for _, name := range typeNames {
+		g.listed[name] = true
+	}
+
+	for _, name := range typeNames {
+		spec, ok := g.specs[name]
+		if !ok {
+			return nil, fmt.Errorf("Type %s not found in package %s", name, pkgName)
+		}
+		st, ok := spec.(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("Type %s is not a struct", name)
+		}
+		fields, err := structFields(name, st)
+		if err != nil {
+			return nil, err
+		}
+		g.writeDecoder(name, fields)
+		g.writeEncoder(name, fields)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by gojson-codec; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", pkgName)
+	var std, module []string
+	for name := range g.imports {
+		if p := importPaths[name]; strings.Contains(p, ".") {
+			module = append(module, p)
+		} else {
+			std = append(std, p)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(module)
+	for _, p := range std {
+		fmt.Fprintf(&out, "\t%q\n", p)
+	}
+	out.WriteString("\n")
+	for _, p := range module {
+		fmt.Fprintf(&out, "\t%q\n", p)
+	}
+	out.WriteString(")\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Generated invalid code: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// the name of the type fd is a method of, "" if it is a function
+func receiverName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return ""
+	}
+	typ := fd.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// the fields Unmarshal and Marshal would use,
+// embedded fields are not supported as their promotion rules need reflection
+func structFields(typeName string, st *ast.StructType) ([]structField, error) {
+	var fields []structField
+	seen := map[string]bool{}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			return nil, fmt.Errorf("Type %s: embedded field %s is not supported", typeName, types.ExprString(f.Type))
+		}
+		var tag string
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("Type %s: invalid tag %s", typeName, f.Tag.Value)
+			}
+			tag = reflect.StructTag(raw).Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			sf := structField{goName: n.Name, jsonName: name, omitEmpty: strings.Contains(opts, "omitempty"), typ: f.Type}
+			if sf.jsonName == "" {
+				sf.jsonName = n.Name
+			}
+			if seen[sf.jsonName] {
+				return nil, fmt.Errorf("Type %s: more than one field is named %q", typeName, sf.jsonName)
+			}
+			seen[sf.jsonName] = true
+			fields = append(fields, sf)
+		}
+	}
+	return fields, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) writeDecoder(name string, fields []structField) {
+	g.printf("\n// DecodeJSON decodes the object that begins with first into x\n")
+	g.printf("func (x *%s) DecodeJSON(l lex.Lexer, first tok.Token) error {\n", name)
+	g.printf("return gojson.DecodeObject(l, first, func(key string, t tok.Token) error {\n")
+	g.printf("switch key {\n")
+	for _, f := range fields {
+		g.printf("case %q:\n", f.jsonName)
+		g.decode("x."+f.goName, f.typ, "t", 0, false)
+	}
+	g.printf("default:\nreturn gojson.SkipValue(l, t)\n}\nreturn nil\n})\n}\n")
+}
+
+// decode writes statements decoding the value that begins with token t into dst,
+// a null leaves dst alone unless it is a pointer, slice or map,
+// nonNull is set once t is known not to be null
+func (g *generator) decode(dst string, typ ast.Expr, t string, depth int, nonNull bool) {
+	switch e := typ.(type) {
+	case *ast.Ident:
+		if g.decodeBasic(dst, e.Name, t, nonNull) {
+			return
+		}
+		if g.listed[e.Name] {
+			g.printf("if err := %s.DecodeJSON(l, %s); err != nil {\nreturn err\n}\n", operand(dst), t)
+			return
+		}
+	case *ast.StarExpr:
+		if !nonNull {
+			g.printf("if %s.TokenType == tok.Null {\n%s = nil\n} else {\n", t, dst)
+		}
+		g.printf("if %s == nil {\n%s = new(%s)\n}\n", dst, dst, types.ExprString(e.X))
+		if id, ok := e.X.(*ast.Ident); ok && g.listed[id.Name] {
+			// the method is called through the pointer
+			g.printf("if err := %s.DecodeJSON(l, %s); err != nil {\nreturn err\n}\n", operand(dst), t)
+		} else {
+			g.decode("*"+dst, e.X, t, depth, true)
+		}
+		if !nonNull {
+			g.printf("}\n")
+		}
+		return
+	case *ast.ArrayType:
+		if e.Len != nil {
+			break
+		}
+		elem := types.ExprString(e.Elt)
+		te, v := "t"+strconv.Itoa(depth+1), "v"+strconv.Itoa(depth+1)
+		if !nonNull {
+			g.printf("if %s.TokenType == tok.Null {\n%s = nil\n} else {\n", t, dst)
+		}
+		g.printf("%s = %s[:0]\n", dst, operand(dst))
+		g.printf("if err := gojson.DecodeArray(l, %s, func(%s tok.Token) error {\n", t, te)
+		g.printf("var %s %s\n", v, elem)
+		g.decode(v, e.Elt, te, depth+1, false)
+		g.printf("%s = append(%s, %s)\nreturn nil\n}); err != nil {\nreturn err\n}\n", dst, dst, v)
+		g.printf("if %s == nil {\n%s = []%s{}\n}\n", dst, dst, elem)
+		if !nonNull {
+			g.printf("}\n")
+		}
+		return
+	case *ast.MapType:
+		if k, ok := e.Key.(*ast.Ident); !ok || k.Name != "string" {
+			break
+		}
+		elem := types.ExprString(e.Value)
+		te, k, v := "t"+strconv.Itoa(depth+1), "k"+strconv.Itoa(depth+1), "v"+strconv.Itoa(depth+1)
+		if !nonNull {
+			g.printf("if %s.TokenType == tok.Null {\n%s = nil\n} else {\n", t, dst)
+		}
+		g.printf("if %s == nil {\n%s = map[string]%s{}\n}\n", dst, dst, elem)
+		g.printf("if err := gojson.DecodeObject(l, %s, func(%s string, %s tok.Token) error {\n", t, k, te)
+		g.printf("var %s %s\n", v, elem)
+		g.decode(v, e.Value, te, depth+1, false)
+		g.printf("%s[%s] = %s\nreturn nil\n}); err != nil {\nreturn err\n}\n", operand(dst), k, v)
+		if !nonNull {
+			g.printf("}\n")
+		}
+		return
+	}
+
+	// everything else goes through reflection
+	g.printf("if err := gojson.DecodeValue(l, %s, &%s); err != nil {\nreturn err\n}\n", t, operand(dst))
+}
+
+// decodeBasic writes statements decoding a value of a predeclared type, or of a
+// type declared in the package whose underlying type is one, reporting false
+// for any other type
+func (g *generator) decodeBasic(dst, typeName, t string, nonNull bool) bool {
+	var fn, result string
+	switch basic := g.basic(typeName); {
+	case basic == "string":
+		fn, result = "gojson.DecodeString("+t+")", "string"
+	case basic == "bool":
+		fn, result = "gojson.DecodeBool("+t+")", "bool"
+	case hasKey(intBits, basic):
+		fn, result = fmt.Sprintf("gojson.DecodeInt(%s, %d)", t, intBits[basic]), "int64"
+	case hasKey(uintBits, basic):
+		fn, result = fmt.Sprintf("gojson.DecodeUint(%s, %d)", t, uintBits[basic]), "uint64"
+	case hasKey(floatBits, basic):
+		fn, result = fmt.Sprintf("gojson.DecodeFloat(%s, %d)", t, floatBits[basic]), "float64"
+	default:
+		return false
+	}
+	if !nonNull {
+		g.printf("if %s.TokenType != tok.Null {\n", t)
+	}
+	g.printf("v, err := %s\nif err != nil {\nreturn err\n}\n", fn)
+	g.printf("%s = %s\n", dst, convert(typeName, result, "v"))
+	if !nonNull {
+		g.printf("}\n")
+	}
+	return true
+}
+
+// basic returns the predeclared type that typeName is, or that it is declared
+// in the package as, directly or through other declared types,
+// "" if it is neither, or if it has methods that gojson calls instead
+func (g *generator) basic(typeName string) string {
+	if g.hooked[typeName] {
+		return ""
+	}
+	for seen := map[string]bool{}; !seen[typeName]; {
+		switch {
+		case typeName == "string", typeName == "bool",
+			hasKey(intBits, typeName), hasKey(uintBits, typeName), hasKey(floatBits, typeName):
+			return typeName
+		}
+		seen[typeName] = true
+		id, ok := g.specs[typeName].(*ast.Ident)
+		if !ok {
+			return ""
+		}
+		typeName = id.Name
+	}
+	return ""
+}
+
+// operand parenthesizes a dereference so it can be indexed,
+// sliced or have a method called on it
+func operand(expr string) string {
+	if strings.HasPrefix(expr, "*") {
+		return "(" + expr + ")"
+	}
+	return expr
+}
+
+// the separator written before each field is known statically
+// until a field tagged omitempty has been reached, from then on
+// up to the next field that is always written it is tracked at run time
+const (
+	noneWritten = iota
+	someWritten
+	maybeWritten
+)
+
+func (g *generator) writeEncoder(name string, fields []structField) {
+	conds := make([]string, len(fields))
+	states := make([]int, len(fields)+1)
+	for i, f := range fields {
+		if f.omitEmpty {
+			conds[i] = g.nonEmpty("x."+f.goName, f.typ)
+		}
+		switch {
+		case conds[i] == "":
+			states[i+1] = someWritten
+		case states[i] == someWritten:
+			states[i+1] = someWritten
+		default:
+			states[i+1] = maybeWritten
+		}
+	}
+	// whether the comma variable is read after the field
+	readLater := make([]bool, len(fields)+1)
+	for i := len(fields) - 1; i >= 0; i-- {
+		readLater[i] = states[i] == maybeWritten || (readLater[i+1] && states[i+1] != someWritten)
+	}
+
+	g.printf("\n// EncodeJSON writes the JSON encoding of x to buf\n")
+	g.printf("func (x *%s) EncodeJSON(buf *bytes.Buffer) error {\n", name)
+	g.printf("buf.WriteByte('{')\n")
+	if readLater[0] {
+		g.printf("comma := false\n")
+	}
+	for i, f := range fields {
+		var key bytes.Buffer
+		encode.WriteString(&key, f.jsonName)
+		key.WriteByte(':')
+		lit := key.String()
+
+		if conds[i] != "" {
+			g.printf("if %s {\n", conds[i])
+		}
+		switch states[i] {
+		case someWritten:
+			lit = "," + lit
+		case maybeWritten:
+			g.printf("if comma {\nbuf.WriteByte(',')\n}\n")
+		}
+		g.printf("buf.WriteString(%s)\n", strconv.Quote(lit))
+		g.encode("x."+f.goName, f.typ, 0, conds[i] != "" && (strings.HasSuffix(conds[i], "!= nil") || strings.HasPrefix(conds[i], "len(")))
+		if states[i+1] == maybeWritten && readLater[i+1] {
+			g.printf("comma = true\n")
+		}
+		if conds[i] != "" {
+			g.printf("}\n")
+		}
+	}
+	g.printf("buf.WriteByte('}')\nreturn nil\n}\n")
+}
+
+// encode writes statements writing the encoding of src to buf,
+// nonNil is set once a pointer, slice or map src is known not to be nil
+func (g *generator) encode(src string, typ ast.Expr, depth int, nonNil bool) {
+	switch e := typ.(type) {
+	case *ast.Ident:
+		// types declared in the package as predeclared ones are converted to them
+		switch basic := g.basic(e.Name); {
+		case basic == "string":
+			g.imports["encode"] = true
+			g.printf("encode.WriteString(buf, %s)\n", convert("string", e.Name, src))
+			return
+		case basic == "bool":
+			g.imports["strconv"] = true
+			g.printf("buf.WriteString(strconv.FormatBool(%s))\n", convert("bool", e.Name, src))
+			return
+		case hasKey(intBits, basic):
+			g.imports["strconv"] = true
+			g.printf("buf.WriteString(strconv.FormatInt(%s, 10))\n", convert("int64", e.Name, src))
+			return
+		case hasKey(uintBits, basic):
+			g.imports["strconv"] = true
+			g.printf("buf.WriteString(strconv.FormatUint(%s, 10))\n", convert("uint64", e.Name, src))
+			return
+		case hasKey(floatBits, basic):
+			g.imports["encode"] = true
+			g.printf("if err := encode.WriteFloat(buf, %s, %d); err != nil {\nreturn err\n}\n", convert("float64", e.Name, src), floatBits[basic])
+			return
+		case g.listed[e.Name]:
+			// the method is called through a pointer rather than a copy
+			g.printf("if err := %s.EncodeJSON(buf); err != nil {\nreturn err\n}\n", operand(strings.TrimPrefix(src, "*")))
+			return
+		}
+	case *ast.StarExpr:
+		if nonNil {
+			g.encode("*"+src, e.X, depth, false)
+			return
+		}
+		g.printf("if %s == nil {\nbuf.WriteString(\"null\")\n} else {\n", src)
+		g.encode("*"+src, e.X, depth, false)
+		g.printf("}\n")
+		return
+	case *ast.ArrayType:
+		if e.Len != nil {
+			break
+		}
+		i := "i" + strconv.Itoa(depth+1)
+		if !nonNil {
+			g.printf("if %s == nil {\nbuf.WriteString(\"null\")\n} else {\n", src)
+		}
+		g.printf("buf.WriteByte('[')\nfor %s := range %s {\n", i, src)
+		g.printf("if %s > 0 {\nbuf.WriteByte(',')\n}\n", i)
+		g.encode(operand(src)+"["+i+"]", e.Elt, depth+1, false)
+		g.printf("}\nbuf.WriteByte(']')\n")
+		if !nonNil {
+			g.printf("}\n")
+		}
+		return
+	case *ast.MapType:
+		if k, ok := e.Key.(*ast.Ident); !ok || k.Name != "string" {
+			break
+		}
+		g.imports["sort"] = true
+		g.imports["encode"] = true
+		d := strconv.Itoa(depth + 1)
+		if !nonNil {
+			g.printf("if %s == nil {\nbuf.WriteString(\"null\")\n} else {\n", src)
+		}
+		g.printf("keys%s := make([]string, 0, len(%s))\nfor k := range %s {\nkeys%s = append(keys%s, k)\n}\n", d, src, src, d, d)
+		g.printf("sort.Strings(keys%s)\nbuf.WriteByte('{')\n", d)
+		g.printf("for i%s, k%s := range keys%s {\nif i%s > 0 {\nbuf.WriteByte(',')\n}\n", d, d, d, d)
+		g.printf("encode.WriteString(buf, k%s)\nbuf.WriteByte(':')\n", d)
+		v := operand(src) + "[k" + d + "]"
+		if id, ok := e.Value.(*ast.Ident); ok && g.listed[id.Name] {
+			// map values are copied out, as the methods have pointer receivers
+			g.printf("v%s := %s\n", d, v)
+			v = "v" + d
+		}
+		g.encode(v, e.Value, depth+1, false)
+		g.printf("}\nbuf.WriteByte('}')\n")
+		if !nonNil {
+			g.printf("}\n")
+		}
+		return
+	}
+
+	// everything else goes through reflection
+	g.printf("if err := gojson.EncodeValue(buf, %s); err != nil {\nreturn err\n}\n", src)
+}
+
+// nonEmpty returns the condition under which a field tagged omitempty
+// is written, types declared in the package are followed to their
+// underlying type, and types declared elsewhere are checked at run time
+func (g *generator) nonEmpty(src string, typ ast.Expr) string {
+	switch e := typ.(type) {
+	case *ast.Ident:
+		switch {
+		case e.Name == "string":
+			return src + ` != ""`
+		case e.Name == "bool":
+			return src
+		case hasKey(intBits, e.Name), hasKey(uintBits, e.Name), hasKey(floatBits, e.Name):
+			return src + " != 0"
+		}
+		if spec, ok := g.specs[e.Name]; ok {
+			if _, ok := spec.(*ast.StructType); ok {
+				return ""
+			}
+			return g.nonEmpty(src, spec)
+		}
+	case *ast.StarExpr, *ast.InterfaceType, *ast.FuncType, *ast.ChanType:
+		return src + " != nil"
+	case *ast.ArrayType, *ast.MapType:
+		return "len(" + src + ") != 0"
+	case *ast.StructType:
+		return ""
+	}
+	return "!gojson.IsEmptyValue(" + src + ")"
+}
+
+// converts expr of type from to type to, if they differ
+func convert(to, from, expr string) string {
+	if to == from {
+		return expr
+	}
+	return to + "(" + expr + ")"
+}
+
+func hasKey(m map[string]int, k string) bool {
+	_, ok := m[k]
+	return ok
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// copies the testdata file into a directory of its own, as a package
+func packageDir(t *testing.T, file string) string {
+	dir, err := ioutil.TempDir("", "gojson-codec")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	src, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", file, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, file), src, 0644); err != nil {
+		t.Fatalf("WriteFile(%s): %v", file, err)
+	}
+	return dir
+}
+
+func TestGenerateGolden(t *testing.T) {
+	dir := packageDir(t, "types.go")
+	defer os.RemoveAll(dir)
+
+	if err := run(dir, "Order,Line", "", "types.go", "example"); err != nil {
+		t.Fatalf("run(): %v", err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(dir, "types_gojson.go"))
+	if err != nil {
+		t.Fatalf("ReadFile(): %v", err)
+	}
+
+	golden := filepath.Join("testdata", "types_gojson.golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatalf("WriteFile(%s): %v", golden, err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", golden, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		types   string
+		wantErr string
+	}{
+		{src: "package p\ntype A struct{ B }\ntype B struct{}\n", types: "A", wantErr: "embedded field B"},
+		{src: "package p\ntype A []int\n", types: "A", wantErr: "not a struct"},
+		{src: "package p\ntype A struct{}\n", types: "A,C", wantErr: "C not found"},
+		{src: "package p\ntype A struct{ X int `json:\"x\"`; Y int `json:\"x\"` }\n", types: "A", wantErr: `named "x"`},
+		{src: "package p\ntype A struct{}\n", types: "", wantErr: "-type is required"},
+	}
+
+	for _, test := range tests {
+		dir, err := ioutil.TempDir("", "gojson-codec")
+		if err != nil {
+			t.Fatalf("TempDir(): %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(test.src), 0644); err != nil {
+			t.Fatalf("WriteFile(): %v", err)
+		}
+
+		err = run(dir, test.types, "", "p.go", "p")
+
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("src: %q, err: %v, want error containing: %q", test.src, err, test.wantErr)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	tests := []struct {
+		goFile, want string
+	}{
+		{goFile: "types.go", want: "types_gojson.go"},
+		{goFile: "types_test.go", want: "types_gojson_test.go"},
+	}
+
+	for _, test := range tests {
+		if got := outputName(test.goFile); got != test.want {
+			t.Errorf("goFile: %s, got: %s, want: %s", test.goFile, got, test.want)
+		}
+	}
+}
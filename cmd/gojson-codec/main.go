@@ -0,0 +1,105 @@
+// Command gojson-codec generates DecodeJSON and EncodeJSON methods for structs
+// that work on the gojson lexer directly, without reflection
+//
+// Usage, in a file of the package declaring the types:
+//
+//	//go:generate gojson-codec -type T1,T2
+//
+// the methods are written to a file named after the one holding the directive,
+// types.go gets types_gojson.go and types_test.go gets types_gojson_test.go,
+// unless -o is given
+//
+// both methods have pointer receivers, pointers to the types implement
+// gojson.ValueDecoder and gojson.ValueEncoder, so gojson.Unmarshal and
+// gojson.Marshal use the methods wherever the types appear addressably
+// fields are decoded and encoded the same way as by gojson.Unmarshal and
+// gojson.Marshal, fields of the predeclared types, of types declared in the
+// package as one of them, of pointers, slices and maps with string keys of
+// them, and of the other types listed are handled by the generated code,
+// fields of any other type go through reflection, as do those of types
+// declared in the package with methods gojson calls instead, such as MarshalText,
+// reflection uses their Unmarshaler and Marshaler methods
+// embedded fields are not supported
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeList := flag.String("type", "", "comma separated names of the struct types to generate methods for")
+	out := flag.String("o", "", "file to write the methods to")
+	dir := flag.String("dir", ".", "directory of the package declaring the types")
+	flag.Parse()
+
+	if err := run(*dir, *typeList, *out, os.Getenv("GOFILE"), os.Getenv("GOPACKAGE")); err != nil {
+		fmt.Fprintf(os.Stderr, "gojson-codec: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, typeList, out, goFile, goPackage string) error {
+	if typeList == "" {
+		return fmt.Errorf("-type is required")
+	}
+	typeNames := strings.Split(typeList, ",")
+
+	if out == "" {
+		if goFile == "" {
+			return fmt.Errorf("-o is required outside of go generate")
+		}
+		out = outputName(goFile)
+	}
+	out = filepath.Join(dir, filepath.Base(out))
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return fi.Name() != filepath.Base(out)
+	}, 0)
+	if err != nil {
+		return err
+	}
+
+	pkg, name, err := findPackage(pkgs, goPackage, typeNames[0])
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkg, name, typeNames)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(out, src, 0644)
+}
+
+// types.go becomes types_gojson.go, types_test.go becomes types_gojson_test.go
+func outputName(goFile string) string {
+	base := strings.TrimSuffix(goFile, ".go")
+	if strings.HasSuffix(base, "_test") {
+		return strings.TrimSuffix(base, "_test") + "_gojson_test.go"
+	}
+	return base + "_gojson.go"
+}
+
+// the package named by go generate, or else the one declaring typeName,
+// as a directory may hold a package and its external test package
+func findPackage(pkgs map[string]*ast.Package, name, typeName string) (*ast.Package, string, error) {
+	if pkg, ok := pkgs[name]; ok {
+		return pkg, name, nil
+	}
+	for n, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			if f.Scope.Lookup(typeName) != nil {
+				return pkg, n, nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("No package declaring %s found", typeName)
+}
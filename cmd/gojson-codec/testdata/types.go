@@ -0,0 +1,58 @@
+package example
+
+import (
+	"fmt"
+	"time"
+)
+
+//go:generate gojson-codec -type Order,Line
+
+type Currency string
+
+// Status is encoded as text rather than as its number
+type Status int
+
+const (
+	Off Status = iota
+	On
+)
+
+func (s Status) MarshalText() ([]byte, error) {
+	if s == On {
+		return []byte("on"), nil
+	}
+	return []byte("off"), nil
+}
+
+func (s *Status) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "on":
+		*s = On
+	case "off":
+		*s = Off
+	default:
+		return fmt.Errorf("unknown status %q", text)
+	}
+	return nil
+}
+
+type Order struct {
+	ID       int64             `json:"id"`
+	Customer *string           `json:"customer,omitempty"`
+	Lines    []Line            `json:"lines"`
+	Totals   map[string]uint32 `json:"totals,omitempty"`
+	Currency Currency          `json:"currency,omitempty"`
+	Status   Status            `json:"status"`
+	Placed   time.Time         `json:"placed"`
+	Notes    []*string         `json:"notes,omitempty"`
+	Internal string            `json:"-"`
+}
+
+type Line struct {
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"qty,omitempty"`
+	Price    float64 `json:"price,omitempty"`
+	Weight   float32 `json:"weight"`
+	Gift     bool
+	Parent   *Line `json:"parent,omitempty"`
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vyevs/gojson/infer"
+)
+
+// words written in all caps when they make up a whole part of a name
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true,
+	"UI": true, "UID": true, "URI": true, "URL": true, "UTF8": true, "UUID": true,
+	"XML": true,
+}
+
+// a struct type still to be written
+type structType struct {
+	name  string
+	shape *infer.Shape
+}
+
+type generator struct {
+	types   map[string]bool // type names already taken
+	pending []structType
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+// generate returns the formatted source of a Go file in package pkg
+// declaring a type named name, and the types nested in it,
+// that the samples merged into shape decode into
+func generate(shape *infer.Shape, samples int, pkg, name string) ([]byte, error) {
+	g := generator{types: map[string]bool{}, imports: map[string]bool{}}
+	name = g.typeName(exportedName(name), "")
+
+	if shape.Objects > 0 && len(kinds(shape)) == 1 && len(shape.Properties) > 0 {
+		g.pending = append(g.pending, structType{name: name, shape: shape})
+	} else {
+		expr, comment := g.typeExpr(shape, name, name)
+		fmt.Fprintf(&g.body, "type %s %s%s\n", name, expr, lineComment(comment))
+	}
+	for len(g.pending) > 0 {
+		st := g.pending[0]
+		g.pending = g.pending[1:]
+		g.writeStruct(st)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gojson-gen from %d %s; DO NOT EDIT.\n\n", samples, plural(samples, "sample"))
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		var paths []string
+		for p := range g.imports {
+			paths = append(paths, strconv.Quote(p))
+		}
+		sort.Strings(paths)
+		fmt.Fprintf(&out, "import (\n%s\n)\n\n", strings.Join(paths, "\n"))
+	}
+	out.Write(g.body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+func (g *generator) writeStruct(st structType) {
+	s := st.shape
+	if s.Objects > 1 && len(s.Required()) == 0 {
+		fmt.Fprintf(&g.body, "\n// %s is merged from %d objects that have no field in common\n", st.name, s.Objects)
+	} else {
+		g.body.WriteString("\n")
+	}
+	fmt.Fprintf(&g.body, "type %s struct {\n", st.name)
+
+	keys := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := map[string]bool{}
+	for _, k := range keys {
+		ps := s.Properties[k]
+		field := uniqueName(exportedName(k), fields)
+		fields[field] = true
+
+		expr, comment := g.typeExpr(ps, field, st.name+field)
+
+		optional := ps.Count < s.Objects
+		if (optional || ps.Nulls > 0) && pointable(expr) {
+			expr = "*" + expr
+		}
+		tag := k
+		if optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&g.body, "\t%s %s `json:%s`%s\n", field, expr, strconv.Quote(tag), lineComment(comment))
+	}
+	g.body.WriteString("}\n")
+}
+
+// typeExpr returns the Go type the values of s decode into,
+// nested structs are named name, or qualified if name is taken
+// the comment describes values that could not be given a precise type
+func (g *generator) typeExpr(s *infer.Shape, name, qualified string) (string, string) {
+	ks := kinds(s)
+	switch {
+	case len(ks) == 0:
+		return "interface{}", ""
+	case len(ks) > 1:
+		return "interface{}", "heterogeneous: " + strings.Join(g.variants(s, ks, name, qualified), ", ")
+	}
+
+	switch ks[0] {
+	case "boolean":
+		return "bool", ""
+	case "integer":
+		return "int", ""
+	case "number":
+		return "float64", ""
+	case "string":
+		if s.Format() == "date-time" {
+			g.imports["time"] = true
+			return "time.Time", ""
+		}
+		if s.NumericStrings == s.Strings {
+			return "string", "numeric string"
+		}
+		return "string", ""
+	case "object":
+		if len(s.Properties) == 0 {
+			return "map[string]interface{}", ""
+		}
+		tn := g.typeName(name, qualified)
+		g.pending = append(g.pending, structType{name: tn, shape: s})
+		return tn, ""
+	}
+
+	// arrays
+	if s.Items == nil {
+		return "[]interface{}", ""
+	}
+	expr, comment := g.typeExpr(s.Items, singular(name), singular(qualified))
+	if s.Items.Nulls > 0 && pointable(expr) {
+		expr = "*" + expr
+	}
+	if comment != "" {
+		comment = "elements are " + comment
+	}
+	return "[]" + expr, comment
+}
+
+// variants describes each kind of value in ks, objects and arrays
+// are given their own types so their values can be decoded separately
+func (g *generator) variants(s *infer.Shape, ks []string, name, qualified string) []string {
+	out := make([]string, len(ks))
+	for i, k := range ks {
+		out[i] = k
+		var v *infer.Shape
+		switch k {
+		case "object":
+			v = &infer.Shape{Count: s.Objects, Objects: s.Objects, Properties: s.Properties}
+			if expr, _ := g.typeExpr(v, name+"Object", qualified+"Object"); expr != "" {
+				out[i] += " (" + expr + ")"
+			}
+		case "array":
+			v = &infer.Shape{Count: s.Arrays, Arrays: s.Arrays, Items: s.Items, MinItems: s.MinItems, MaxItems: s.MaxItems}
+			if expr, _ := g.typeExpr(v, name+"Elements", qualified+"Elements"); expr != "" {
+				out[i] += " (" + expr + ")"
+			}
+		}
+	}
+	return out
+}
+
+// typeName reserves a name for a new type, trying name first,
+// then qualified, then either followed by a number
+func (g *generator) typeName(name, qualified string) string {
+	for _, n := range []string{name, qualified} {
+		if n != "" && !g.types[n] {
+			g.types[n] = true
+			return n
+		}
+	}
+	n := uniqueName(name, g.types)
+	g.types[n] = true
+	return n
+}
+
+// the kinds of non-null values observed
+func kinds(s *infer.Shape) []string {
+	var ks []string
+	for _, t := range s.Types() {
+		if t != "null" {
+			ks = append(ks, t)
+		}
+	}
+	return ks
+}
+
+// whether a field of the type can be made a pointer to tell absent or null
+// values apart, slices, maps and interfaces can already hold nil
+func pointable(expr string) bool {
+	return !strings.HasPrefix(expr, "[]") && !strings.HasPrefix(expr, "map[") && expr != "interface{}"
+}
+
+func uniqueName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		n := name + strconv.Itoa(i)
+		if !taken[n] {
+			return n
+		}
+	}
+}
+
+// exportedName turns a JSON key such as "per_page" or "userId"
+// into an exported Go identifier such as "PerPage" or "UserID"
+func exportedName(key string) string {
+	var parts []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			parts = append(parts, string(cur))
+			cur = nil
+		}
+	}
+	rs := []rune(key)
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(cur) > 0:
+			// a new word starts at an upper case letter following a lower case one,
+			// or at the last upper case letter of a run followed by a lower case one
+			prev := cur[len(cur)-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				flush()
+			}
+		}
+		cur = append(cur, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, p := range parts {
+		if up := strings.ToUpper(p); initialisms[up] {
+			b.WriteString(up)
+			continue
+		}
+		rs := []rune(strings.ToLower(p))
+		rs[0] = unicode.ToUpper(rs[0])
+		b.WriteString(string(rs))
+	}
+
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if r := []rune(name)[0]; !unicode.IsLetter(r) {
+		name = "X" + name
+	}
+	return name
+}
+
+// names the elements of an array from the name of the array
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name + "Item"
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func lineComment(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return " // " + comment
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/infer"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		files    []string
+		typeName string
+		golden   string
+	}{
+		{files: []string{"colors1.json"}, typeName: "Palette", golden: "colors1.golden"},
+		{files: []string{"colors2.json", "colors3.json"}, typeName: "Colors", golden: "colors_merged.golden"},
+		{files: []string{"gdp.json"}, typeName: "GDPResponse", golden: "gdp.golden"},
+		{files: []string{"meteorites.json"}, typeName: "Meteorites", golden: "meteorites.golden"},
+	}
+
+	for _, test := range tests {
+		var files []string
+		for _, f := range test.files {
+			files = append(files, filepath.Join("..", "..", "parse", "testdata", f))
+		}
+
+		got, err := run(files, test.typeName, "samples", false)
+		if err != nil {
+			t.Errorf("files: %v, err: %v", test.files, err)
+			continue
+		}
+
+		golden := filepath.Join("testdata", test.golden)
+		if *update {
+			if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+				t.Fatalf("WriteFile(%s): %v", golden, err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", golden, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("files: %v, got:\n%s\nwant:\n%s", test.files, got, want)
+		}
+	}
+}
+
+func TestGenerateNDJSON(t *testing.T) {
+	tests := []struct {
+		ndjson string
+		want   []string
+	}{
+		{
+			ndjson: `{"id": 1, "createdAt": "2019-05-07T10:00:00Z", "tags": ["a"]}
+{"id": 2, "createdAt": "2019-05-08T10:00:00Z", "owner": {"name": "x"}, "tags": null}`,
+			want: []string{
+				`import (`,
+				`"time"`,
+				"CreatedAt time.Time `json:\"createdAt\"`",
+				"ID        int       `json:\"id\"`",
+				"Owner     *Owner    `json:\"owner,omitempty\"`",
+				"Tags      []string  `json:\"tags\"`",
+				"type Owner struct {",
+			},
+		},
+		{
+			ndjson: `{"items": [{"kind": "a", "x": 1}, {"type": "b", "y": true}]}`,
+			want: []string{
+				"Items []Item `json:\"items\"`",
+				"// Item is merged from 2 objects that have no field in common",
+				"Kind *string `json:\"kind,omitempty\"`",
+				"Y    *bool   `json:\"y,omitempty\"`",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		inf := newInferencer(t, test.ndjson)
+
+		got, err := generate(inf.Shape(), inf.Samples(), "samples", "Event")
+		if err != nil {
+			t.Errorf("ndjson: %s, err: %v", test.ndjson, err)
+			continue
+		}
+		for _, w := range test.want {
+			if !strings.Contains(string(got), w) {
+				t.Errorf("ndjson: %s, got:\n%s\nwant it to contain: %s", test.ndjson, got, w)
+			}
+		}
+	}
+}
+
+func newInferencer(t *testing.T, ndjson string) *infer.Inferencer {
+	inf := infer.New()
+	if err := inf.AddNDJSON(strings.NewReader(ndjson)); err != nil {
+		t.Fatalf("AddNDJSON(%q): %v", ndjson, err)
+	}
+	return inf
+}
+
+func TestExportedName(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{key: "name", want: "Name"},
+		{key: "per_page", want: "PerPage"},
+		{key: "userId", want: "UserID"},
+		{key: "HTTPServer", want: "HTTPServer"},
+		{key: "api-url", want: "APIURL"},
+		{key: ":@computed_region_nnqa_25f4", want: "ComputedRegionNnqa25f4"},
+		{key: "2fa", want: "X2fa"},
+		{key: "", want: "Field"},
+		{key: "ünïcode key", want: "ÜnïcodeKey"},
+	}
+
+	for _, test := range tests {
+		if got := exportedName(test.key); got != test.want {
+			t.Errorf("key: %q, got: %q, want: %q", test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Command gojson-gen generates Go struct definitions from sample JSON documents
+//
+// Usage:
+//
+//	gojson-gen [-type name] [-package name] [-ndjson] [-o file] [file ...]
+//
+// every file is a sample of the same kind of document, standard input is read
+// when no files are given, the samples are merged so fields missing from some
+// of them become optional (a pointer with omitempty) and fields that are null
+// in some of them become pointers
+//
+// nested objects are given types named after the field holding them,
+// fields and arrays whose values differ in kind are typed interface{}
+// and commented with the kinds observed
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/vyevs/gojson"
+	"github.com/vyevs/gojson/infer"
+)
+
+func main() {
+	typeName := flag.String("type", "Root", "name of the generated top level type")
+	pkg := flag.String("package", "main", "package clause of the generated file")
+	ndjson := flag.Bool("ndjson", false, "treat every line of the input as a separate sample")
+	out := flag.String("o", "", "file to write to instead of standard output")
+	flag.Parse()
+
+	src, err := run(flag.Args(), *typeName, *pkg, *ndjson)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gojson-gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(*out, src, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gojson-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run reads the samples in files, or standard input if there are none,
+// and returns the generated source
+func run(files []string, typeName, pkg string, ndjson bool) ([]byte, error) {
+	inf := infer.New()
+	if len(files) == 0 {
+		if err := addSamples(inf, os.Stdin, ndjson); err != nil {
+			return nil, fmt.Errorf("stdin: %v", err)
+		}
+	}
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		err = addSamples(inf, f, ndjson)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	if inf.Samples() == 0 {
+		return nil, fmt.Errorf("no samples found")
+	}
+	return generate(inf.Shape(), inf.Samples(), pkg, typeName)
+}
+
+func addSamples(inf *infer.Inferencer, r io.Reader, ndjson bool) error {
+	if ndjson {
+		return inf.AddNDJSON(r)
+	}
+	doc, err := gojson.Parse(r)
+	if err != nil {
+		return err
+	}
+	return inf.Add(doc)
+}
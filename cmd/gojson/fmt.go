@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/vyevs/gojson/format"
+)
+
+// format formats the files named by args, compactly if minify is set,
+// to standard output, or in place with -w
+func (c *command) format(args []string, minify bool) int {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	write := fs.Bool("w", false, "write the result to the file instead of standard output")
+	check := fs.Bool("check", false, "list the files whose formatting differs, and exit with status 1 if there are any")
+	ndjson := fs.Bool("ndjson", false, "treat every line of the input as a separate document")
+	var opts format.Options
+	if !minify {
+		fs.StringVar(&opts.Indent, "indent", "  ", "indentation of every level of nesting")
+		fs.IntVar(&opts.MaxInlineWidth, "width", 0, "keep objects and arrays at most this many bytes wide on one line")
+		fs.BoolVar(&opts.SortKeys, "sort", false, "sort the members of objects by key")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if minify {
+		opts.Indent = ""
+	}
+	names, err := expand(fs.Args())
+	if err != nil {
+		c.errorf("gojson %s: %v", c.name, err)
+		return 2
+	}
+
+	for _, name := range names {
+		if name == "-" && *write {
+			c.errorf("gojson %s: cannot use -w with standard input", c.name)
+			return 2
+		}
+		data, err := c.read(name)
+		if err != nil {
+			c.errorf("gojson %s: %v", c.name, err)
+			continue
+		}
+		out, ok := c.formatFile(name, data, opts, isNDJSON(name, *ndjson))
+		if !ok {
+			continue
+		}
+
+		if *check && !bytes.Equal(out, data) {
+			fmt.Fprintln(c.stdout, displayName(name))
+			c.status = 1
+		}
+		switch {
+		case *write:
+			if !bytes.Equal(out, data) {
+				if err := writeFile(name, out); err != nil {
+					c.errorf("gojson %s: %v", c.name, err)
+				}
+			}
+		case !*check:
+			c.stdout.Write(out)
+		}
+	}
+	return c.status
+}
+
+// returns the documents of the file name formatted as set in opts, every one of
+// them followed by a newline, NDJSON documents are formatted compactly
+// reports false after reporting the syntax errors of documents that are not valid
+func (c *command) formatFile(name string, data []byte, opts format.Options, ndjson bool) ([]byte, bool) {
+	if ndjson {
+		opts.Indent = ""
+	}
+	var out bytes.Buffer
+	ok := true
+	for _, doc := range documents(data, ndjson) {
+		err := format.Format(&out, bytes.NewReader(doc.data), opts)
+		if err == nil {
+			out.WriteByte('\n')
+			continue
+		}
+		ok = false
+		errs := diagnose(name, doc)
+		if len(errs) == 0 {
+			errs = []string{fmt.Sprintf("%s:%d: %v", displayName(name), doc.line+1, err)}
+		}
+		for _, e := range errs {
+			c.errorf("%s", e)
+		}
+	}
+	return out.Bytes(), ok
+}
+
+// writes data to the file name, keeping its permissions
+func writeFile(name string, data []byte) error {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, fi.Mode().Perm())
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+// expands the globs among args, which are kept in order,
+// "-" stands for standard input, which is read if there are no args
+func expand(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"-"}, nil
+	}
+	var names []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			names = append(names, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No files match %q", arg)
+		}
+		names = append(names, matches...)
+	}
+	return names, nil
+}
+
+// reads the file name, or standard input for "-"
+func (c *command) read(name string) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(c.stdin)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// opens the file name, or standard input for "-"
+func (c *command) open(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return ioutil.NopCloser(c.stdin), nil
+	}
+	return os.Open(name)
+}
+
+// the name of the file to report errors in
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
+	}
+	return name
+}
+
+// reports whether the file name holds a document per line
+func isNDJSON(name string, ndjson bool) bool {
+	ext := filepath.Ext(name)
+	return ndjson || ext == ".ndjson" || ext == ".jsonl"
+}
+
+// a document of a file, which begins on line
+type document struct {
+	line int // 0-based
+	data []byte
+}
+
+// splits data into its documents, every line that is not blank for NDJSON
+func documents(data []byte, ndjson bool) []document {
+	if !ndjson {
+		return []document{{data: data}}
+	}
+	var docs []document
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(bytes.TrimSpace(line)) > 0 {
+			docs = append(docs, document{line: i, data: line})
+		}
+	}
+	return docs
+}
+
+// returns the syntax errors of doc, of the file name, as file:line:col: message
+func diagnose(name string, doc document) []string {
+	_, diags, _ := parse.ParseRecover(bytes.NewReader(doc.data))
+	var errs []string
+	for _, d := range diags {
+		errs = append(errs, fmt.Sprintf("%s:%d:%d: %s", displayName(name), doc.line+d.Line, d.Column, d.Message))
+	}
+	return errs
+}
@@ -0,0 +1,78 @@
+// Command gojson validates, formats, minifies and queries JSON documents
+//
+// Usage:
+//
+//	gojson validate [-ndjson] [file or glob ...]
+//	gojson fmt [-w] [-check] [-indent s] [-width n] [-sort] [-ndjson] [file or glob ...]
+//	gojson min [-w] [-ndjson] [file or glob ...]
+//	gojson query [-r] [-c] filter [file or glob ...]
+//
+// standard input is read when no files are given, and for "-"
+// files ending in .ndjson or .jsonl, and all input with -ndjson, hold a
+// document per line, each of which is validated on its own, and formatted
+// compactly on a line of its own
+//
+// query runs a filter in a subset of the jq language, see package query,
+// on every document of a stream of them, e.g. NDJSON, writing its outputs
+// indented, or compactly with -c, and strings without quotes with -r
+//
+// syntax errors are reported as file:line:col: message, the exit status is 1
+// if any document is not valid, or for fmt -check if any file is not formatted,
+// and 2 if the command line is not
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage:
+	gojson validate [-ndjson] [file or glob ...]
+	gojson fmt [-w] [-check] [-indent s] [-width n] [-sort] [-ndjson] [file or glob ...]
+	gojson min [-w] [-ndjson] [file or glob ...]
+	gojson query [-r] [-c] filter [file or glob ...]
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command line args and returns the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	c := &command{name: args[0], stdin: stdin, stdout: stdout, stderr: stderr}
+	switch args[0] {
+	case "validate":
+		return c.validate(args[1:])
+	case "fmt":
+		return c.format(args[1:], false)
+	case "min":
+		return c.format(args[1:], true)
+	case "query":
+		return c.query(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	fmt.Fprintf(stderr, "gojson: unknown command %q\n%s", args[0], usage)
+	return 2
+}
+
+// a subcommand being run, with the streams it uses
+type command struct {
+	name   string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	status int
+}
+
+// reports err, which makes the exit status 1
+func (c *command) errorf(format string, args ...interface{}) {
+	fmt.Fprintf(c.stderr, format+"\n", args...)
+	c.status = 1
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writes files to a new directory, whose path is returned
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "gojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func runCommand(dir string, stdin string, args ...string) (status int, stdout, stderr string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "$dir/") {
+			args[i] = filepath.Join(dir, strings.TrimPrefix(arg, "$dir/"))
+		}
+	}
+	var out, errOut bytes.Buffer
+	status = run(args, strings.NewReader(stdin), &out, &errOut)
+	return status, out.String(), strings.Replace(errOut.String(), dir+string(filepath.Separator), "", -1)
+}
+
+func TestValidate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.json":      `{"a": [1, 2]}`,
+		"b.json":      "{\n  \"a\": 1\n  \"b\": tru\n}",
+		"c.ndjson":    "{\"a\": 1}\n\n[1,]\n\"x\"\n",
+		"notes.txt":   "not json",
+		"dup.json":    `{"a": 1, "a": 2}`,
+		"empty.json":  "",
+		"valid2.json": "[]",
+		"big.json":    `{"id": 18446744073709551616}`,
+		"crlf.json":   "{\r\n  \"a\": [1, 2],\r\n  \"b\": true\r\n}\r\n",
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		args       []string
+		stdin      string
+		wantStatus int
+		wantStderr string
+	}{
+		{args: []string{"validate", "$dir/a.json", "$dir/valid2.json"}, wantStatus: 0},
+		{args: []string{"validate", "$dir/big.json", "$dir/crlf.json"}, wantStatus: 0},
+		{
+			args:       []string{"validate", "$dir/*.json"},
+			wantStatus: 1,
+			wantStderr: "b.json:3:3: Expected comma, found: \"\\\"b\\\"\"\n" +
+				"b.json:3:8: Invalid token: \"tru\"\n" +
+				"dup.json:1:10: Found duplicate key \"a\"\n" +
+				"empty.json:1:1: Expected value, found: end of document\n",
+		},
+		{
+			args:       []string{"validate", "$dir/c.ndjson"},
+			wantStatus: 1,
+			wantStderr: "c.ndjson:3:3: Found trailing comma\n",
+		},
+		{args: []string{"validate"}, stdin: `{"a": 1}`, wantStatus: 0},
+		{
+			args:       []string{"validate", "-ndjson", "-"},
+			stdin:      "1\n2 3\n",
+			wantStatus: 1,
+			wantStderr: "<stdin>:2:3: Expected end of document, found: \"3\"\n",
+		},
+		{
+			args:       []string{"validate", "$dir/*.yaml"},
+			wantStatus: 2,
+			wantStderr: "gojson validate: No files match \"*.yaml\"\n",
+		},
+	}
+
+	for _, test := range tests {
+		status, _, stderr := runCommand(dir, test.stdin, test.args...)
+		if status != test.wantStatus || stderr != test.wantStderr {
+			t.Errorf("args: %v, got: %d %q, want: %d %q", test.args, status, stderr, test.wantStatus, test.wantStderr)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		args       []string
+		stdin      string
+		wantStatus int
+		wantStdout string
+	}{
+		{
+			args:       []string{"fmt"},
+			stdin:      `{"b": [1, 2], "a": {}}`,
+			wantStdout: "{\n  \"b\": [\n    1,\n    2\n  ],\n  \"a\": {}\n}\n",
+		},
+		{
+			args:       []string{"fmt", "-indent", "\t", "-width", "20", "-sort"},
+			stdin:      `{"b": [1, 2], "a": {}}`,
+			wantStdout: "{\n\t\"a\": {},\n\t\"b\": [1, 2]\n}\n",
+		},
+		{
+			args:       []string{"min"},
+			stdin:      "{\n  \"b\": [1, 2]\n}\n",
+			wantStdout: "{\"b\":[1,2]}\n",
+		},
+		{
+			args:       []string{"fmt", "-ndjson"},
+			stdin:      "{ \"a\" : 1 }\n\n[ 1, 2 ]\n",
+			wantStdout: "{\"a\":1}\n[1,2]\n",
+		},
+		{
+			args:       []string{"min"},
+			stdin:      "{\r\n  \"id\": 18446744073709551616\r\n}\r\n",
+			wantStdout: "{\"id\":18446744073709551616}\n",
+		},
+		{
+			args:       []string{"fmt", "-check"},
+			stdin:      "{\"a\": 1}",
+			wantStatus: 1,
+			wantStdout: "<stdin>\n",
+		},
+		{
+			args:  []string{"fmt", "-check"},
+			stdin: "{\n  \"a\": 1\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		status, stdout, stderr := runCommand("", test.stdin, test.args...)
+		if status != test.wantStatus || stdout != test.wantStdout || stderr != "" {
+			t.Errorf("args: %q, got: %d %q %q, want: %d %q", test.args, status, stdout, stderr, test.wantStatus, test.wantStdout)
+		}
+	}
+}
+
+func TestFormatFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"formatted.json": "[\n  1\n]\n",
+		"ugly.json":      "[1]",
+		"bad.json":       "{\"a\": }",
+		"log.jsonl":      "{ \"a\": 1 }\r\n{\"a\": 2}\n",
+	})
+	defer os.RemoveAll(dir)
+
+	status, stdout, stderr := runCommand(dir, "", "fmt", "-check", "$dir/*.json")
+	if status != 1 || stdout != filepath.Join(dir, "ugly.json")+"\n" || stderr != "bad.json:1:7: Expected value, found: \"}\"\n" {
+		t.Errorf("-check: got: %d %q %q", status, stdout, stderr)
+	}
+
+	status, stdout, stderr = runCommand(dir, "", "fmt", "-w", "$dir/ugly.json", "$dir/formatted.json", "$dir/log.jsonl")
+	if status != 0 || stdout != "" || stderr != "" {
+		t.Errorf("-w: got: %d %q %q", status, stdout, stderr)
+	}
+	want := map[string]string{
+		"formatted.json": "[\n  1\n]\n",
+		"ugly.json":      "[\n  1\n]\n",
+		"bad.json":       "{\"a\": }",
+		"log.jsonl":      "{\"a\":1}\n{\"a\":2}\n",
+	}
+	for name, content := range want {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s: got: %q, want: %q", name, got, content)
+		}
+	}
+
+	if status, _, stderr := runCommand(dir, "", "min", "-w"); status != 2 || stderr != "gojson min: cannot use -w with standard input\n" {
+		t.Errorf("-w with stdin: got: %d %q", status, stderr)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if status, _, stderr := runCommand("", "", "lint"); status != 2 || !strings.HasPrefix(stderr, "gojson: unknown command \"lint\"\n") {
+		t.Errorf("got: %d %q", status, stderr)
+	}
+	if status, _, _ := runCommand("", ""); status != 2 {
+		t.Errorf("no args: got: %d, want: 2", status)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		args       []string
+		stdin      string
+		wantStatus int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			args:       []string{"query", ".a"},
+			stdin:      `{"a": {"b": [1, 2]}}`,
+			wantStdout: "{\n  \"b\": [\n    1,\n    2\n  ]\n}\n",
+		},
+		{
+			args:       []string{"query", "-c", "-r", "select(.level == \"error\") | .msg, .tags"},
+			stdin:      "{\"level\": \"info\", \"msg\": \"started\"}\n{\"level\": \"error\", \"msg\": \"disk full\", \"tags\": [\"io\"]}\n",
+			wantStdout: "disk full\n[\"io\"]\n",
+		},
+		{
+			args:       []string{"query", "-c", ".[] | . * 2"},
+			stdin:      "[1, 2] [3]\n[\"x\"]\n[4]",
+			wantStatus: 1,
+			wantStdout: "2\n4\n6\n8\n",
+			wantStderr: "<stdin>:2:1: string (\"x\") and number (2) cannot be multiplied\n",
+		},
+		{
+			args:       []string{"query", "-c", ".a"},
+			stdin:      "{\"a\":1}\r\n{\"a\":2}\r\n",
+			wantStdout: "1\n2\n",
+		},
+		{
+			args:       []string{"query", "."},
+			stdin:      "1\n{\"a\" 2}\n3",
+			wantStatus: 1,
+			wantStdout: "1\n",
+			wantStderr: "<stdin>:2:6: Expected colon (\":\"): got: \"2\"\n",
+		},
+		{
+			args:       []string{"query", ".a |"},
+			wantStatus: 2,
+			wantStderr: "gojson query: Unexpected end of filter, expected value\n",
+		},
+		{
+			args:       []string{"query"},
+			wantStatus: 2,
+			wantStderr: "gojson query: a filter is required\n",
+		},
+	}
+
+	for _, test := range tests {
+		status, stdout, stderr := runCommand("", test.stdin, test.args...)
+		if status != test.wantStatus || stdout != test.wantStdout || stderr != test.wantStderr {
+			t.Errorf("args: %q, got: %d %q %q, want: %d %q %q", test.args, status, stdout, stderr, test.wantStatus, test.wantStdout, test.wantStderr)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/query"
+	"github.com/vyevs/gojson/tok"
+)
+
+// query runs the filter in args on every document of the files named after it,
+// which are read as a stream of whitespace separated documents, so NDJSON
+// is read a line at a time
+func (c *command) query(args []string) int {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	raw := fs.Bool("r", false, "write strings without quotes")
+	compact := fs.Bool("c", false, "write every output compactly on one line")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		c.errorf("gojson query: a filter is required")
+		return 2
+	}
+	q, err := query.Parse(fs.Arg(0))
+	if err != nil {
+		c.errorf("gojson query: %v", err)
+		return 2
+	}
+	names, err := expand(fs.Args()[1:])
+	if err != nil {
+		c.errorf("gojson query: %v", err)
+		return 2
+	}
+
+	out := bufio.NewWriter(c.stdout)
+	defer out.Flush()
+	for _, name := range names {
+		r, err := c.open(name)
+		if err != nil {
+			c.errorf("gojson query: %v", err)
+			continue
+		}
+		c.queryStream(q, name, r, out, *raw, *compact)
+		r.Close()
+	}
+	return c.status
+}
+
+// runs q on every document in r, writing the outputs to out as they are produced
+func (c *command) queryStream(q *query.Query, name string, r io.Reader, out *bufio.Writer, raw, compact bool) {
+	l := lex.New(r)
+	l.TrackLines()
+	position := func() string {
+		line, col := l.Position(l.TokenOffset())
+		return fmt.Sprintf("%s:%d:%d", displayName(name), line, col)
+	}
+
+	for {
+		t := l.ReadToken()
+		if t.TokenType == tok.EOF {
+			return
+		}
+		start := position()
+		doc, err := parse.ParseValue(l, t)
+		if err != nil {
+			// the rest of the input cannot be told apart into documents
+			c.errorf("%s: %v", position(), err)
+			return
+		}
+
+		results, err := q.Run(doc)
+		for _, v := range results {
+			if err := writeResult(out, v, raw, compact); err != nil {
+				c.errorf("%s: %v", start, err)
+			}
+		}
+		if err != nil {
+			c.errorf("%s: %v", start, err)
+		}
+		out.Flush()
+	}
+}
+
+func writeResult(out *bufio.Writer, v interface{}, raw, compact bool) error {
+	if s, ok := v.(string); ok && raw {
+		out.WriteString(s)
+		return out.WriteByte('\n')
+	}
+	var b []byte
+	var err error
+	if compact {
+		b, err = encode.Marshal(v)
+	} else {
+		b, err = encode.MarshalIndent(v, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	out.Write(b)
+	return out.WriteByte('\n')
+}
@@ -0,0 +1,32 @@
+package main
+
+import "flag"
+
+// validate reports every syntax error in the files named by args
+func (c *command) validate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	ndjson := fs.Bool("ndjson", false, "treat every line of the input as a separate document")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	names, err := expand(fs.Args())
+	if err != nil {
+		c.errorf("gojson validate: %v", err)
+		return 2
+	}
+
+	for _, name := range names {
+		data, err := c.read(name)
+		if err != nil {
+			c.errorf("gojson validate: %v", err)
+			continue
+		}
+		for _, doc := range documents(data, isNDJSON(name, *ndjson)) {
+			for _, e := range diagnose(name, doc) {
+				c.errorf("%s", e)
+			}
+		}
+	}
+	return c.status
+}
@@ -0,0 +1,169 @@
+package gojson
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// ValueDecoder is implemented by types that decode themselves
+// straight from the lexer, such as those gojson-codec generates methods for
+//
+// first is the first token of the value and has already been read,
+// DecodeJSON must read the rest of the value and nothing more
+// Unmarshal and Decode call DecodeJSON instead of using reflection
+type ValueDecoder interface {
+	DecodeJSON(l lex.Lexer, first tok.Token) error
+}
+
+// ValueEncoder is implemented by types that write their own JSON encoding,
+// such as those gojson-codec generates methods for
+// Marshal calls EncodeJSON instead of using reflection
+type ValueEncoder interface {
+	EncodeJSON(buf *bytes.Buffer) error
+}
+
+// DecodeObject decodes the object that begins with first, calling fn with each
+// key and the first token of its value, fn must decode or skip the value
+// a null is accepted as an object without keys
+func DecodeObject(l lex.Lexer, first tok.Token, fn func(key string, t tok.Token) error) error {
+	if first.TokenType == tok.Null {
+		return nil
+	}
+	if first.TokenType != tok.OpeningCurlyBrace {
+		return unexpected("object", first)
+	}
+	var seenValue bool
+	for t := l.ReadToken(); t.TokenType != tok.ClosingCurlyBrace; t = l.ReadToken() {
+		if seenValue {
+			if t.TokenType != tok.Comma {
+				return fmt.Errorf("Expected comma(%q) got %q", ",", t.Literal)
+			}
+			t = l.ReadToken()
+		}
+		if t.TokenType != tok.String {
+			return fmt.Errorf("Expected key, got: %q", t.Literal)
+		}
+		key := t.Literal
+
+		t = l.ReadToken()
+		if t.TokenType != tok.Colon {
+			return fmt.Errorf("Expected colon (%q): got: %q", ":", t.Literal)
+		}
+		if err := fn(key, l.ReadToken()); err != nil {
+			return err
+		}
+		seenValue = true
+	}
+	return nil
+}
+
+// DecodeArray decodes the array that begins with first, calling fn with
+// the first token of each element, fn must decode or skip the element
+// a null is accepted as an array without elements
+func DecodeArray(l lex.Lexer, first tok.Token, fn func(t tok.Token) error) error {
+	if first.TokenType == tok.Null {
+		return nil
+	}
+	if first.TokenType != tok.OpeningSquareBracket {
+		return unexpected("array", first)
+	}
+	var seenValue bool
+	for t := l.ReadToken(); t.TokenType != tok.ClosingSquareBracket; t = l.ReadToken() {
+		if seenValue {
+			if t.TokenType != tok.Comma {
+				return fmt.Errorf("expected comma(%q), found: %q", ",", t.Literal)
+			}
+			t = l.ReadToken()
+		}
+		seenValue = true
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SkipValue reads past the value that begins with first
+func SkipValue(l lex.Lexer, first tok.Token) error {
+	switch first.TokenType {
+	case tok.OpeningCurlyBrace:
+		return DecodeObject(l, first, func(_ string, t tok.Token) error {
+			return SkipValue(l, t)
+		})
+	case tok.OpeningSquareBracket:
+		return DecodeArray(l, first, func(t tok.Token) error {
+			return SkipValue(l, t)
+		})
+	case tok.String, tok.Integer, tok.FloatingPoint, tok.Boolean, tok.Null:
+		return nil
+	}
+	return unexpected("value", first)
+}
+
+// DecodeString returns the value of a string token
+func DecodeString(t tok.Token) (string, error) {
+	if t.TokenType != tok.String {
+		return "", unexpected("string", t)
+	}
+	return t.Literal, nil
+}
+
+// DecodeBool returns the value of a boolean token
+func DecodeBool(t tok.Token) (bool, error) {
+	if t.TokenType != tok.Boolean {
+		return false, unexpected("boolean", t)
+	}
+	return t.Literal == "true", nil
+}
+
+// DecodeInt returns the value of an integer token that fits in bitSize bits
+func DecodeInt(t tok.Token, bitSize int) (int64, error) {
+	if t.TokenType != tok.Integer {
+		return 0, unexpected("integer", t)
+	}
+	v, err := strconv.ParseInt(t.Literal, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("Integer %s out of range for %d bits", t.Literal, bitSize)
+	}
+	return v, nil
+}
+
+// DecodeUint returns the value of a non-negative integer token
+// that fits in bitSize bits
+func DecodeUint(t tok.Token, bitSize int) (uint64, error) {
+	if t.TokenType != tok.Integer {
+		return 0, unexpected("integer", t)
+	}
+	v, err := strconv.ParseUint(t.Literal, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("Integer %s out of range for unsigned %d bits", t.Literal, bitSize)
+	}
+	return v, nil
+}
+
+// DecodeFloat returns the value of a numeric token
+// rounded to the nearest float of bitSize bits
+func DecodeFloat(t tok.Token, bitSize int) (float64, error) {
+	if t.TokenType != tok.Integer && t.TokenType != tok.FloatingPoint {
+		return 0, unexpected("number", t)
+	}
+	v, err := strconv.ParseFloat(t.Literal, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("Number %s out of range for %d bit floats", t.Literal, bitSize)
+	}
+	return v, nil
+}
+
+func unexpected(want string, t tok.Token) error {
+	switch t.TokenType {
+	case tok.EOF:
+		return fmt.Errorf("Expected %s, found end of document", want)
+	case tok.Invalid:
+		return fmt.Errorf("Found invalid token: %s", t.Literal)
+	}
+	return fmt.Errorf("Expected %s, found: %q", want, t.Literal)
+}
@@ -0,0 +1,783 @@
+// Code generated by gojson-codec; DO NOT EDIT.
+
+package gojson_test
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/vyevs/gojson"
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// DecodeJSON decodes the object that begins with first into x
+func (x *Meteorite) DecodeJSON(l lex.Lexer, first tok.Token) error {
+	return gojson.DecodeObject(l, first, func(key string, t tok.Token) error {
+		switch key {
+		case ":@computed_region_cbhk_fwbd":
+			if t.TokenType == tok.Null {
+				x.ComputedRegionCbhkFwbd = nil
+			} else {
+				if x.ComputedRegionCbhkFwbd == nil {
+					x.ComputedRegionCbhkFwbd = new(string)
+				}
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				*x.ComputedRegionCbhkFwbd = v
+			}
+		case ":@computed_region_nnqa_25f4":
+			if t.TokenType == tok.Null {
+				x.ComputedRegionNnqa25f4 = nil
+			} else {
+				if x.ComputedRegionNnqa25f4 == nil {
+					x.ComputedRegionNnqa25f4 = new(string)
+				}
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				*x.ComputedRegionNnqa25f4 = v
+			}
+		case "fall":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.Fall = v
+			}
+		case "geolocation":
+			if t.TokenType == tok.Null {
+				x.Geolocation = nil
+			} else {
+				if x.Geolocation == nil {
+					x.Geolocation = new(Geolocation)
+				}
+				if err := x.Geolocation.DecodeJSON(l, t); err != nil {
+					return err
+				}
+			}
+		case "id":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.ID = v
+			}
+		case "mass":
+			if t.TokenType == tok.Null {
+				x.Mass = nil
+			} else {
+				if x.Mass == nil {
+					x.Mass = new(string)
+				}
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				*x.Mass = v
+			}
+		case "name":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.Name = v
+			}
+		case "nametype":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.Nametype = v
+			}
+		case "recclass":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.Recclass = v
+			}
+		case "reclat":
+			if t.TokenType == tok.Null {
+				x.Reclat = nil
+			} else {
+				if x.Reclat == nil {
+					x.Reclat = new(string)
+				}
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				*x.Reclat = v
+			}
+		case "reclong":
+			if t.TokenType == tok.Null {
+				x.Reclong = nil
+			} else {
+				if x.Reclong == nil {
+					x.Reclong = new(string)
+				}
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				*x.Reclong = v
+			}
+		case "year":
+			if t.TokenType == tok.Null {
+				x.Year = nil
+			} else {
+				if x.Year == nil {
+					x.Year = new(string)
+				}
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				*x.Year = v
+			}
+		default:
+			return gojson.SkipValue(l, t)
+		}
+		return nil
+	})
+}
+
+// EncodeJSON writes the JSON encoding of x to buf
+func (x *Meteorite) EncodeJSON(buf *bytes.Buffer) error {
+	buf.WriteByte('{')
+	comma := false
+	if x.ComputedRegionCbhkFwbd != nil {
+		buf.WriteString("\":@computed_region_cbhk_fwbd\":")
+		encode.WriteString(buf, *x.ComputedRegionCbhkFwbd)
+		comma = true
+	}
+	if x.ComputedRegionNnqa25f4 != nil {
+		if comma {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\":@computed_region_nnqa_25f4\":")
+		encode.WriteString(buf, *x.ComputedRegionNnqa25f4)
+		comma = true
+	}
+	if comma {
+		buf.WriteByte(',')
+	}
+	buf.WriteString("\"fall\":")
+	encode.WriteString(buf, x.Fall)
+	if x.Geolocation != nil {
+		buf.WriteString(",\"geolocation\":")
+		if err := x.Geolocation.EncodeJSON(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteString(",\"id\":")
+	encode.WriteString(buf, x.ID)
+	if x.Mass != nil {
+		buf.WriteString(",\"mass\":")
+		encode.WriteString(buf, *x.Mass)
+	}
+	buf.WriteString(",\"name\":")
+	encode.WriteString(buf, x.Name)
+	buf.WriteString(",\"nametype\":")
+	encode.WriteString(buf, x.Nametype)
+	buf.WriteString(",\"recclass\":")
+	encode.WriteString(buf, x.Recclass)
+	if x.Reclat != nil {
+		buf.WriteString(",\"reclat\":")
+		encode.WriteString(buf, *x.Reclat)
+	}
+	if x.Reclong != nil {
+		buf.WriteString(",\"reclong\":")
+		encode.WriteString(buf, *x.Reclong)
+	}
+	if x.Year != nil {
+		buf.WriteString(",\"year\":")
+		encode.WriteString(buf, *x.Year)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// DecodeJSON decodes the object that begins with first into x
+func (x *Geolocation) DecodeJSON(l lex.Lexer, first tok.Token) error {
+	return gojson.DecodeObject(l, first, func(key string, t tok.Token) error {
+		switch key {
+		case "coordinates":
+			if t.TokenType == tok.Null {
+				x.Coordinates = nil
+			} else {
+				x.Coordinates = x.Coordinates[:0]
+				if err := gojson.DecodeArray(l, t, func(t1 tok.Token) error {
+					var v1 float64
+					if t1.TokenType != tok.Null {
+						v, err := gojson.DecodeFloat(t1, 64)
+						if err != nil {
+							return err
+						}
+						v1 = v
+					}
+					x.Coordinates = append(x.Coordinates, v1)
+					return nil
+				}); err != nil {
+					return err
+				}
+				if x.Coordinates == nil {
+					x.Coordinates = []float64{}
+				}
+			}
+		case "type":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.Type = v
+			}
+		default:
+			return gojson.SkipValue(l, t)
+		}
+		return nil
+	})
+}
+
+// EncodeJSON writes the JSON encoding of x to buf
+func (x *Geolocation) EncodeJSON(buf *bytes.Buffer) error {
+	buf.WriteByte('{')
+	buf.WriteString("\"coordinates\":")
+	if x.Coordinates == nil {
+		buf.WriteString("null")
+	} else {
+		buf.WriteByte('[')
+		for i1 := range x.Coordinates {
+			if i1 > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encode.WriteFloat(buf, x.Coordinates[i1], 64); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteString(",\"type\":")
+	encode.WriteString(buf, x.Type)
+	buf.WriteByte('}')
+	return nil
+}
+
+// DecodeJSON decodes the object that begins with first into x
+func (x *Sample) DecodeJSON(l lex.Lexer, first tok.Token) error {
+	return gojson.DecodeObject(l, first, func(key string, t tok.Token) error {
+		switch key {
+		case "name":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.Name = v
+			}
+		case "count":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeInt(t, 0)
+				if err != nil {
+					return err
+				}
+				x.Count = int(v)
+			}
+		case "small":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeInt(t, 8)
+				if err != nil {
+					return err
+				}
+				x.Small = int8(v)
+			}
+		case "big":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeUint(t, 64)
+				if err != nil {
+					return err
+				}
+				x.Big = v
+			}
+		case "ratio":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeFloat(t, 32)
+				if err != nil {
+					return err
+				}
+				x.Ratio = float32(v)
+			}
+		case "ok":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeBool(t)
+				if err != nil {
+					return err
+				}
+				x.OK = v
+			}
+		case "ptr":
+			if t.TokenType == tok.Null {
+				x.Ptr = nil
+			} else {
+				if x.Ptr == nil {
+					x.Ptr = new(int)
+				}
+				v, err := gojson.DecodeInt(t, 0)
+				if err != nil {
+					return err
+				}
+				*x.Ptr = int(v)
+			}
+		case "ptrptr":
+			if t.TokenType == tok.Null {
+				x.PtrPtr = nil
+			} else {
+				if x.PtrPtr == nil {
+					x.PtrPtr = new(*string)
+				}
+				if *x.PtrPtr == nil {
+					*x.PtrPtr = new(string)
+				}
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				**x.PtrPtr = v
+			}
+		case "tags":
+			if t.TokenType == tok.Null {
+				x.Tags = nil
+			} else {
+				x.Tags = x.Tags[:0]
+				if err := gojson.DecodeArray(l, t, func(t1 tok.Token) error {
+					var v1 string
+					if t1.TokenType != tok.Null {
+						v, err := gojson.DecodeString(t1)
+						if err != nil {
+							return err
+						}
+						v1 = v
+					}
+					x.Tags = append(x.Tags, v1)
+					return nil
+				}); err != nil {
+					return err
+				}
+				if x.Tags == nil {
+					x.Tags = []string{}
+				}
+			}
+		case "matrix":
+			if t.TokenType == tok.Null {
+				x.Matrix = nil
+			} else {
+				x.Matrix = x.Matrix[:0]
+				if err := gojson.DecodeArray(l, t, func(t1 tok.Token) error {
+					var v1 []int
+					if t1.TokenType == tok.Null {
+						v1 = nil
+					} else {
+						v1 = v1[:0]
+						if err := gojson.DecodeArray(l, t1, func(t2 tok.Token) error {
+							var v2 int
+							if t2.TokenType != tok.Null {
+								v, err := gojson.DecodeInt(t2, 0)
+								if err != nil {
+									return err
+								}
+								v2 = int(v)
+							}
+							v1 = append(v1, v2)
+							return nil
+						}); err != nil {
+							return err
+						}
+						if v1 == nil {
+							v1 = []int{}
+						}
+					}
+					x.Matrix = append(x.Matrix, v1)
+					return nil
+				}); err != nil {
+					return err
+				}
+				if x.Matrix == nil {
+					x.Matrix = [][]int{}
+				}
+			}
+		case "attrs":
+			if t.TokenType == tok.Null {
+				x.Attrs = nil
+			} else {
+				if x.Attrs == nil {
+					x.Attrs = map[string]float64{}
+				}
+				if err := gojson.DecodeObject(l, t, func(k1 string, t1 tok.Token) error {
+					var v1 float64
+					if t1.TokenType != tok.Null {
+						v, err := gojson.DecodeFloat(t1, 64)
+						if err != nil {
+							return err
+						}
+						v1 = v
+					}
+					x.Attrs[k1] = v1
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+		case "nested":
+			if t.TokenType == tok.Null {
+				x.Nested = nil
+			} else {
+				if x.Nested == nil {
+					x.Nested = map[string][]Inner{}
+				}
+				if err := gojson.DecodeObject(l, t, func(k1 string, t1 tok.Token) error {
+					var v1 []Inner
+					if t1.TokenType == tok.Null {
+						v1 = nil
+					} else {
+						v1 = v1[:0]
+						if err := gojson.DecodeArray(l, t1, func(t2 tok.Token) error {
+							var v2 Inner
+							if err := v2.DecodeJSON(l, t2); err != nil {
+								return err
+							}
+							v1 = append(v1, v2)
+							return nil
+						}); err != nil {
+							return err
+						}
+						if v1 == nil {
+							v1 = []Inner{}
+						}
+					}
+					x.Nested[k1] = v1
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+		case "by_name":
+			if t.TokenType == tok.Null {
+				x.ByName = nil
+			} else {
+				if x.ByName == nil {
+					x.ByName = map[string]Inner{}
+				}
+				if err := gojson.DecodeObject(l, t, func(k1 string, t1 tok.Token) error {
+					var v1 Inner
+					if err := v1.DecodeJSON(l, t1); err != nil {
+						return err
+					}
+					x.ByName[k1] = v1
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+		case "inner":
+			if err := x.Inner.DecodeJSON(l, t); err != nil {
+				return err
+			}
+		case "inners":
+			if t.TokenType == tok.Null {
+				x.Inners = nil
+			} else {
+				x.Inners = x.Inners[:0]
+				if err := gojson.DecodeArray(l, t, func(t1 tok.Token) error {
+					var v1 *Inner
+					if t1.TokenType == tok.Null {
+						v1 = nil
+					} else {
+						if v1 == nil {
+							v1 = new(Inner)
+						}
+						if err := v1.DecodeJSON(l, t1); err != nil {
+							return err
+						}
+					}
+					x.Inners = append(x.Inners, v1)
+					return nil
+				}); err != nil {
+					return err
+				}
+				if x.Inners == nil {
+					x.Inners = []*Inner{}
+				}
+			}
+		case "status":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.Status = Status(v)
+			}
+		case "grade":
+			if err := gojson.DecodeValue(l, t, &x.Grade); err != nil {
+				return err
+			}
+		case "any":
+			if err := gojson.DecodeValue(l, t, &x.Any); err != nil {
+				return err
+			}
+		case "pair":
+			if err := gojson.DecodeValue(l, t, &x.Pair); err != nil {
+				return err
+			}
+		case "NoTag":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.NoTag = v
+			}
+		default:
+			return gojson.SkipValue(l, t)
+		}
+		return nil
+	})
+}
+
+// EncodeJSON writes the JSON encoding of x to buf
+func (x *Sample) EncodeJSON(buf *bytes.Buffer) error {
+	buf.WriteByte('{')
+	buf.WriteString("\"name\":")
+	encode.WriteString(buf, x.Name)
+	if x.Count != 0 {
+		buf.WriteString(",\"count\":")
+		buf.WriteString(strconv.FormatInt(int64(x.Count), 10))
+	}
+	buf.WriteString(",\"small\":")
+	buf.WriteString(strconv.FormatInt(int64(x.Small), 10))
+	buf.WriteString(",\"big\":")
+	buf.WriteString(strconv.FormatUint(x.Big, 10))
+	buf.WriteString(",\"ratio\":")
+	if err := encode.WriteFloat(buf, float64(x.Ratio), 32); err != nil {
+		return err
+	}
+	buf.WriteString(",\"ok\":")
+	buf.WriteString(strconv.FormatBool(x.OK))
+	buf.WriteString(",\"ptr\":")
+	if x.Ptr == nil {
+		buf.WriteString("null")
+	} else {
+		buf.WriteString(strconv.FormatInt(int64(*x.Ptr), 10))
+	}
+	if x.PtrPtr != nil {
+		buf.WriteString(",\"ptrptr\":")
+		if *x.PtrPtr == nil {
+			buf.WriteString("null")
+		} else {
+			encode.WriteString(buf, **x.PtrPtr)
+		}
+	}
+	buf.WriteString(",\"tags\":")
+	if x.Tags == nil {
+		buf.WriteString("null")
+	} else {
+		buf.WriteByte('[')
+		for i1 := range x.Tags {
+			if i1 > 0 {
+				buf.WriteByte(',')
+			}
+			encode.WriteString(buf, x.Tags[i1])
+		}
+		buf.WriteByte(']')
+	}
+	if len(x.Matrix) != 0 {
+		buf.WriteString(",\"matrix\":")
+		buf.WriteByte('[')
+		for i1 := range x.Matrix {
+			if i1 > 0 {
+				buf.WriteByte(',')
+			}
+			if x.Matrix[i1] == nil {
+				buf.WriteString("null")
+			} else {
+				buf.WriteByte('[')
+				for i2 := range x.Matrix[i1] {
+					if i2 > 0 {
+						buf.WriteByte(',')
+					}
+					buf.WriteString(strconv.FormatInt(int64(x.Matrix[i1][i2]), 10))
+				}
+				buf.WriteByte(']')
+			}
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteString(",\"attrs\":")
+	if x.Attrs == nil {
+		buf.WriteString("null")
+	} else {
+		keys1 := make([]string, 0, len(x.Attrs))
+		for k := range x.Attrs {
+			keys1 = append(keys1, k)
+		}
+		sort.Strings(keys1)
+		buf.WriteByte('{')
+		for i1, k1 := range keys1 {
+			if i1 > 0 {
+				buf.WriteByte(',')
+			}
+			encode.WriteString(buf, k1)
+			buf.WriteByte(':')
+			if err := encode.WriteFloat(buf, x.Attrs[k1], 64); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	if len(x.Nested) != 0 {
+		buf.WriteString(",\"nested\":")
+		keys1 := make([]string, 0, len(x.Nested))
+		for k := range x.Nested {
+			keys1 = append(keys1, k)
+		}
+		sort.Strings(keys1)
+		buf.WriteByte('{')
+		for i1, k1 := range keys1 {
+			if i1 > 0 {
+				buf.WriteByte(',')
+			}
+			encode.WriteString(buf, k1)
+			buf.WriteByte(':')
+			if x.Nested[k1] == nil {
+				buf.WriteString("null")
+			} else {
+				buf.WriteByte('[')
+				for i2 := range x.Nested[k1] {
+					if i2 > 0 {
+						buf.WriteByte(',')
+					}
+					if err := x.Nested[k1][i2].EncodeJSON(buf); err != nil {
+						return err
+					}
+				}
+				buf.WriteByte(']')
+			}
+		}
+		buf.WriteByte('}')
+	}
+	if len(x.ByName) != 0 {
+		buf.WriteString(",\"by_name\":")
+		keys1 := make([]string, 0, len(x.ByName))
+		for k := range x.ByName {
+			keys1 = append(keys1, k)
+		}
+		sort.Strings(keys1)
+		buf.WriteByte('{')
+		for i1, k1 := range keys1 {
+			if i1 > 0 {
+				buf.WriteByte(',')
+			}
+			encode.WriteString(buf, k1)
+			buf.WriteByte(':')
+			v1 := x.ByName[k1]
+			if err := v1.EncodeJSON(buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteString(",\"inner\":")
+	if err := x.Inner.EncodeJSON(buf); err != nil {
+		return err
+	}
+	buf.WriteString(",\"inners\":")
+	if x.Inners == nil {
+		buf.WriteString("null")
+	} else {
+		buf.WriteByte('[')
+		for i1 := range x.Inners {
+			if i1 > 0 {
+				buf.WriteByte(',')
+			}
+			if x.Inners[i1] == nil {
+				buf.WriteString("null")
+			} else {
+				if err := x.Inners[i1].EncodeJSON(buf); err != nil {
+					return err
+				}
+			}
+		}
+		buf.WriteByte(']')
+	}
+	if x.Status != "" {
+		buf.WriteString(",\"status\":")
+		encode.WriteString(buf, string(x.Status))
+	}
+	buf.WriteString(",\"grade\":")
+	if err := gojson.EncodeValue(buf, x.Grade); err != nil {
+		return err
+	}
+	buf.WriteString(",\"any\":")
+	if err := gojson.EncodeValue(buf, x.Any); err != nil {
+		return err
+	}
+	buf.WriteString(",\"pair\":")
+	if err := gojson.EncodeValue(buf, x.Pair); err != nil {
+		return err
+	}
+	buf.WriteString(",\"NoTag\":")
+	encode.WriteString(buf, x.NoTag)
+	buf.WriteByte('}')
+	return nil
+}
+
+// DecodeJSON decodes the object that begins with first into x
+func (x *Inner) DecodeJSON(l lex.Lexer, first tok.Token) error {
+	return gojson.DecodeObject(l, first, func(key string, t tok.Token) error {
+		switch key {
+		case "a":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeInt(t, 0)
+				if err != nil {
+					return err
+				}
+				x.A = int(v)
+			}
+		case "b":
+			if t.TokenType != tok.Null {
+				v, err := gojson.DecodeString(t)
+				if err != nil {
+					return err
+				}
+				x.B = v
+			}
+		default:
+			return gojson.SkipValue(l, t)
+		}
+		return nil
+	})
+}
+
+// EncodeJSON writes the JSON encoding of x to buf
+func (x *Inner) EncodeJSON(buf *bytes.Buffer) error {
+	buf.WriteByte('{')
+	buf.WriteString("\"a\":")
+	buf.WriteString(strconv.FormatInt(int64(x.A), 10))
+	if x.B != "" {
+		buf.WriteString(",\"b\":")
+		encode.WriteString(buf, x.B)
+	}
+	buf.WriteByte('}')
+	return nil
+}
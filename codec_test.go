@@ -0,0 +1,326 @@
+package gojson_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/vyevs/gojson"
+)
+
+//go:generate go run ./cmd/gojson-codec -type Meteorite,Geolocation,Sample,Inner
+
+// Meteorite and Geolocation get generated methods,
+// reflectMeteorite and reflectGeolocation are the same types without them
+type Meteorite struct {
+	ComputedRegionCbhkFwbd *string      `json:":@computed_region_cbhk_fwbd,omitempty"`
+	ComputedRegionNnqa25f4 *string      `json:":@computed_region_nnqa_25f4,omitempty"`
+	Fall                   string       `json:"fall"`
+	Geolocation            *Geolocation `json:"geolocation,omitempty"`
+	ID                     string       `json:"id"`
+	Mass                   *string      `json:"mass,omitempty"`
+	Name                   string       `json:"name"`
+	Nametype               string       `json:"nametype"`
+	Recclass               string       `json:"recclass"`
+	Reclat                 *string      `json:"reclat,omitempty"`
+	Reclong                *string      `json:"reclong,omitempty"`
+	Year                   *string      `json:"year,omitempty"`
+}
+
+type Geolocation struct {
+	Coordinates []float64 `json:"coordinates"`
+	Type        string    `json:"type"`
+}
+
+type reflectMeteorite struct {
+	ComputedRegionCbhkFwbd *string             `json:":@computed_region_cbhk_fwbd,omitempty"`
+	ComputedRegionNnqa25f4 *string             `json:":@computed_region_nnqa_25f4,omitempty"`
+	Fall                   string              `json:"fall"`
+	Geolocation            *reflectGeolocation `json:"geolocation,omitempty"`
+	ID                     string              `json:"id"`
+	Mass                   *string             `json:"mass,omitempty"`
+	Name                   string              `json:"name"`
+	Nametype               string              `json:"nametype"`
+	Recclass               string              `json:"recclass"`
+	Reclat                 *string             `json:"reclat,omitempty"`
+	Reclong                *string             `json:"reclong,omitempty"`
+	Year                   *string             `json:"year,omitempty"`
+}
+
+type reflectGeolocation struct {
+	Coordinates []float64 `json:"coordinates"`
+	Type        string    `json:"type"`
+}
+
+type Status string
+
+// Grade is encoded as text, which the generated code must not bypass
+type Grade int
+
+func (g Grade) MarshalText() ([]byte, error) {
+	if g > 0 {
+		return []byte("high"), nil
+	}
+	return []byte("low"), nil
+}
+
+func (g *Grade) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "high":
+		*g = 1
+	case "low":
+		*g = 0
+	default:
+		return fmt.Errorf("unknown grade %q", text)
+	}
+	return nil
+}
+
+// Sample covers every kind of field the generator handles,
+// reflectSample is the same type without generated methods
+type Sample struct {
+	Name    string             `json:"name"`
+	Count   int                `json:"count,omitempty"`
+	Small   int8               `json:"small"`
+	Big     uint64             `json:"big"`
+	Ratio   float32            `json:"ratio"`
+	OK      bool               `json:"ok"`
+	Ptr     *int               `json:"ptr"`
+	PtrPtr  **string           `json:"ptrptr,omitempty"`
+	Tags    []string           `json:"tags"`
+	Matrix  [][]int            `json:"matrix,omitempty"`
+	Attrs   map[string]float64 `json:"attrs"`
+	Nested  map[string][]Inner `json:"nested,omitempty"`
+	ByName  map[string]Inner   `json:"by_name,omitempty"`
+	Inner   Inner              `json:"inner"`
+	Inners  []*Inner           `json:"inners"`
+	Status  Status             `json:"status,omitempty"`
+	Grade   Grade              `json:"grade"`
+	Any     interface{}        `json:"any"`
+	Pair    [2]int             `json:"pair"`
+	Skipped string             `json:"-"`
+	NoTag   string
+	private string
+}
+
+type Inner struct {
+	A int    `json:"a"`
+	B string `json:"b,omitempty"`
+}
+
+type reflectSample struct {
+	Name    string                    `json:"name"`
+	Count   int                       `json:"count,omitempty"`
+	Small   int8                      `json:"small"`
+	Big     uint64                    `json:"big"`
+	Ratio   float32                   `json:"ratio"`
+	OK      bool                      `json:"ok"`
+	Ptr     *int                      `json:"ptr"`
+	PtrPtr  **string                  `json:"ptrptr,omitempty"`
+	Tags    []string                  `json:"tags"`
+	Matrix  [][]int                   `json:"matrix,omitempty"`
+	Attrs   map[string]float64        `json:"attrs"`
+	Nested  map[string][]reflectInner `json:"nested,omitempty"`
+	ByName  map[string]reflectInner   `json:"by_name,omitempty"`
+	Inner   reflectInner              `json:"inner"`
+	Inners  []*reflectInner           `json:"inners"`
+	Status  Status                    `json:"status,omitempty"`
+	Grade   Grade                     `json:"grade"`
+	Any     interface{}               `json:"any"`
+	Pair    [2]int                    `json:"pair"`
+	Skipped string                    `json:"-"`
+	NoTag   string
+	private string
+}
+
+type reflectInner struct {
+	A int    `json:"a"`
+	B string `json:"b,omitempty"`
+}
+
+var (
+	_ gojson.ValueDecoder = (*Sample)(nil)
+	_ gojson.ValueEncoder = (*Sample)(nil)
+)
+
+func TestGeneratedMatchesReflection(t *testing.T) {
+	tests := []string{
+		`{}`,
+		`null`,
+		`{"name": "x", "count": 3, "small": -128, "big": 18446744073709551615, "ratio": 0.1, "ok": true,
+		  "ptr": 7, "ptrptr": "pp", "tags": ["a", "b"], "matrix": [[1], [], [2, 3]],
+		  "attrs": {"z": 1, "a": 2.5}, "nested": {"k": [{"a": 1}, {"a": 2, "b": "y"}]}, "by_name": {"b": {"a": 6}, "a": {"b": "z"}},
+		  "inner": {"a": 4, "unknown": [1, {"x": null}]}, "inners": [null, {"a": 5}],
+		  "status": "active", "grade": "high", "any": {"deep": [1, "two", null]}, "pair": [8, 9, 10],
+		  "NoTag": "n", "Skipped": "s", "private": "p", "extra": {"ignored": true}}`,
+		`{"ptr": null, "tags": null, "attrs": null, "inners": [], "tags": [], "any": null, "inner": null}`,
+		`{"count": 0, "status": "", "matrix": [], "nested": {}}`,
+	}
+
+	for _, test := range tests {
+		var gen Sample
+		genErr := gojson.Unmarshal([]byte(test), &gen)
+		var refl reflectSample
+		reflErr := gojson.Unmarshal([]byte(test), &refl)
+		if genErr != nil || reflErr != nil {
+			t.Errorf("doc: %s, generated err: %v, reflection err: %v", test, genErr, reflErr)
+			continue
+		}
+
+		genOut, genErr := gojson.Marshal(gen)
+		reflOut, reflErr := gojson.Marshal(refl)
+		if genErr != nil || reflErr != nil || string(genOut) != string(reflOut) {
+			t.Errorf("doc: %s, generated: %s, reflection: %s, generated err: %v, reflection err: %v",
+				test, genOut, reflOut, genErr, reflErr)
+		}
+	}
+}
+
+// float32 fields are written with the digits that read back as the same float32
+func TestFloat32Field(t *testing.T) {
+	gen, genErr := gojson.Marshal(&Sample{Ratio: 0.1})
+	refl, reflErr := gojson.Marshal(&reflectSample{Ratio: 0.1})
+	for _, out := range [][]byte{gen, refl} {
+		if genErr != nil || reflErr != nil || !bytes.Contains(out, []byte(`"ratio":0.1,`)) {
+			t.Errorf("got: %s, want a ratio of 0.1, errs: %v, %v", out, genErr, reflErr)
+		}
+	}
+}
+
+func TestGeneratedErrors(t *testing.T) {
+	tests := []string{
+		`{"name": 1}`,
+		`{"small": 128}`,
+		`{"big": -1}`,
+		`{"tags": [1]}`,
+		`{"inner": []}`,
+		`{"inners": [{"a": "x"}]}`,
+		`{"attrs": {"a": true}}`,
+		`{"grade": 1}`,
+		`{"name": "x",}`,
+		`{"name" "x"}`,
+		`[1]`,
+	}
+
+	for _, test := range tests {
+		var gen Sample
+		genErr := gojson.Unmarshal([]byte(test), &gen)
+		var refl reflectSample
+		reflErr := gojson.Unmarshal([]byte(test), &refl)
+		if genErr == nil || reflErr == nil {
+			t.Errorf("doc: %s, generated err: %v, reflection err: %v, want both to fail", test, genErr, reflErr)
+		}
+	}
+}
+
+func TestGeneratedMeteorites(t *testing.T) {
+	data, err := ioutil.ReadFile("parse/testdata/meteorites.json")
+	if err != nil {
+		t.Fatalf("ReadFile(): %v", err)
+	}
+	var gen []Meteorite
+	if err := gojson.Unmarshal(data, &gen); err != nil {
+		t.Fatalf("Unmarshal(): %v", err)
+	}
+	var refl []reflectMeteorite
+	if err := gojson.Unmarshal(data, &refl); err != nil {
+		t.Fatalf("Unmarshal(): %v", err)
+	}
+	var std []reflectMeteorite
+	if err := json.Unmarshal(data, &std); err != nil {
+		t.Fatalf("json.Unmarshal(): %v", err)
+	}
+
+	genOut, err := gojson.Marshal(gen)
+	if err != nil {
+		t.Fatalf("Marshal(): %v", err)
+	}
+	reflOut, err := gojson.Marshal(refl)
+	if err != nil {
+		t.Fatalf("Marshal(): %v", err)
+	}
+	stdOut, err := gojson.Marshal(std)
+	if err != nil {
+		t.Fatalf("Marshal(): %v", err)
+	}
+	if string(genOut) != string(reflOut) || string(reflOut) != string(stdOut) {
+		t.Errorf("generated, reflection and encoding/json decoding differ")
+	}
+}
+
+func readMeteorites(b *testing.B) []byte {
+	data, err := ioutil.ReadFile("parse/testdata/meteorites.json")
+	if err != nil {
+		b.Fatalf("ReadFile(): %v", err)
+	}
+	return data
+}
+
+func BenchmarkDecodeMeteorites(b *testing.B) {
+	data := readMeteorites(b)
+
+	b.Run("generated", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var ms []Meteorite
+			if err := gojson.Unmarshal(data, &ms); err != nil {
+				b.Fatalf("Unmarshal(): %v", err)
+			}
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var ms []reflectMeteorite
+			if err := gojson.Unmarshal(data, &ms); err != nil {
+				b.Fatalf("Unmarshal(): %v", err)
+			}
+		}
+	})
+	b.Run("STDLIB", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var ms []reflectMeteorite
+			if err := json.Unmarshal(data, &ms); err != nil {
+				b.Fatalf("Unmarshal(): %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncodeMeteorites(b *testing.B) {
+	data := readMeteorites(b)
+	var gen []Meteorite
+	var refl []reflectMeteorite
+	if err := gojson.Unmarshal(data, &gen); err != nil {
+		b.Fatalf("Unmarshal(): %v", err)
+	}
+	if err := gojson.Unmarshal(data, &refl); err != nil {
+		b.Fatalf("Unmarshal(): %v", err)
+	}
+
+	b.Run("generated", func(b *testing.B) {
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			if err := gojson.EncodeValue(&buf, gen); err != nil {
+				b.Fatalf("EncodeValue(): %v", err)
+			}
+		}
+	})
+	b.Run("reflection", func(b *testing.B) {
+		var buf bytes.Buffer
+		for i := 0; i < b.N; i++ {
+			buf.Reset()
+			if err := gojson.EncodeValue(&buf, refl); err != nil {
+				b.Fatalf("EncodeValue(): %v", err)
+			}
+		}
+	})
+	b.Run("STDLIB", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(refl); err != nil {
+				b.Fatalf("Marshal(): %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,473 @@
+// Package cst parses JSON documents into concrete syntax trees, which keep
+// the whitespace, comments and spelling of the literals of a document, so that
+// it can be edited without changing the bytes the edits do not touch
+//
+// trivia, the whitespace and comments between tokens, is attached to the
+// member, element or document it precedes or follows, and a document is
+// written back byte for byte as it was parsed
+package cst
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Kind is the kind of value a Node holds
+type Kind uint8
+
+// the Kinds of Nodes
+const (
+	Object Kind = iota
+	Array
+	String
+	Number
+	Bool
+	Null
+)
+
+var kindNames = map[Kind]string{
+	Object: "object",
+	Array:  "array",
+	String: "string",
+	Number: "number",
+	Bool:   "boolean",
+	Null:   "null",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Document is a parsed JSON document
+type Document struct {
+	Leading  []byte // the trivia before the value
+	Root     *Node
+	Trailing []byte // the trivia after the value
+}
+
+// Node is a value in a Document
+type Node struct {
+	Kind Kind
+
+	// Literal is the source of a string, number, true, false or null
+	// as it was spelled, e.g.: "é", 1.0E2
+	Literal []byte
+
+	Members []*Member // of an object
+	Elems   []*Elem   // of an array
+
+	// End is the trivia before the closing bracket of an object or array that
+	// follows its last comma, or that is all there is between empty brackets
+	// it begins with the trailing comma of JSONC documents that have one
+	End []byte
+}
+
+// Member is a member of an object
+type Member struct {
+	Leading     []byte // the trivia before the key, after the opening bracket or comma
+	Key         []byte // the string literal of the key
+	Name        string // the key, unescaped
+	BeforeColon []byte
+	AfterColon  []byte
+	Value       *Node
+	Trailing    []byte // the trivia after the value, before the comma or closing bracket
+}
+
+// Elem is an element of an array
+type Elem struct {
+	Leading  []byte // the trivia before the value, after the opening bracket or comma
+	Value    *Node
+	Trailing []byte // the trivia after the value, before the comma or closing bracket
+}
+
+// Options configures ParseWithOptions
+type Options struct {
+	// JSONC accepts the // line and /* block */ comments of JSONC documents,
+	// which are kept in the trivia, and their trailing commas, which are kept in End
+	JSONC bool
+}
+
+// Parse reads the JSON document in r into a Document
+func Parse(r io.Reader) (*Document, error) {
+	return ParseWithOptions(r, Options{})
+}
+
+// ParseWithOptions is like Parse but accepts the extensions to JSON set in opts
+func ParseWithOptions(r io.Reader, opts Options) (*Document, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{data: data, jsonc: opts.JSONC}
+	return p.document()
+}
+
+// Bytes returns the Document as JSON, which is the input it was parsed from
+// if it has not been edited
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(d.Leading)
+	d.Root.write(&buf)
+	buf.Write(d.Trailing)
+	return buf.Bytes()
+}
+
+// WriteTo writes the Document to w as Bytes returns it
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(d.Bytes())
+	return int64(n), err
+}
+
+// Bytes returns the Node as JSON, without the trivia around it
+func (n *Node) Bytes() []byte {
+	var buf bytes.Buffer
+	n.write(&buf)
+	return buf.Bytes()
+}
+
+func (n *Node) write(buf *bytes.Buffer) {
+	switch n.Kind {
+	case Object:
+		buf.WriteByte('{')
+		for i, m := range n.Members {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(m.Leading)
+			buf.Write(m.Key)
+			buf.Write(m.BeforeColon)
+			buf.WriteByte(':')
+			buf.Write(m.AfterColon)
+			m.Value.write(buf)
+			buf.Write(m.Trailing)
+		}
+		buf.Write(n.End)
+		buf.WriteByte('}')
+	case Array:
+		buf.WriteByte('[')
+		for i, e := range n.Elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(e.Leading)
+			e.Value.write(buf)
+			buf.Write(e.Trailing)
+		}
+		buf.Write(n.End)
+		buf.WriteByte(']')
+	default:
+		buf.Write(n.Literal)
+	}
+}
+
+// Value returns the value the Node holds, made up of the values Parse in
+// package parse produces: map[string]interface{}, []interface{}, string,
+// int, float64, bool or nil
+func (n *Node) Value() (interface{}, error) {
+	switch n.Kind {
+	case Object:
+		out := make(map[string]interface{}, len(n.Members))
+		for _, m := range n.Members {
+			v, err := m.Value.Value()
+			if err != nil {
+				return nil, err
+			}
+			out[m.Name] = v
+		}
+		return out, nil
+	case Array:
+		out := make([]interface{}, 0, len(n.Elems))
+		for _, e := range n.Elems {
+			v, err := e.Value.Value()
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case String:
+		str, _ := lex.AppendUnescaped(nil, n.Literal[1:len(n.Literal)-1])
+		return string(str), nil
+	case Number:
+		literal := string(n.Literal)
+		if lex.NumericTokenType(literal) == tok.Integer {
+			if v, err := strconv.Atoi(literal); err == nil {
+				return v, nil
+			}
+			return nil, fmt.Errorf("Invalid value found: %q", literal)
+		}
+		v, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value found: %q", literal)
+		}
+		return v, nil
+	case Bool:
+		return string(n.Literal) == "true", nil
+	}
+	return nil, nil
+}
+
+// parser is a recursive descent parser over a whole document
+type parser struct {
+	data  []byte
+	i     int
+	jsonc bool
+}
+
+func (p *parser) expected(what string) error {
+	if p.i >= len(p.data) {
+		return fmt.Errorf("Unexpected end of document at offset %d, expected %s", p.i, what)
+	}
+	return fmt.Errorf("Expected %s at offset %d, found: %q", what, p.i, p.data[p.i])
+}
+
+func (p *parser) at(c byte) bool {
+	return p.i < len(p.data) && p.data[p.i] == c
+}
+
+// the source from start to p.i, which is capped so that appending to it
+// while editing never writes over the source that follows it
+func (p *parser) since(start int) []byte {
+	return p.data[start:p.i:p.i]
+}
+
+func (p *parser) document() (*Document, error) {
+	d := &Document{}
+	var err error
+	if d.Leading, err = p.trivia(); err != nil {
+		return nil, err
+	}
+	if d.Root, err = p.value(); err != nil {
+		return nil, err
+	}
+	if d.Trailing, err = p.trivia(); err != nil {
+		return nil, err
+	}
+	if p.i < len(p.data) {
+		return nil, p.expected("end of document")
+	}
+	return d, nil
+}
+
+// reads the whitespace, and in JSONC documents the comments, at p.i
+func (p *parser) trivia() ([]byte, error) {
+	start := p.i
+	for p.i < len(p.data) {
+		switch c := p.data[p.i]; {
+		case c == ' ' || c == '\n' || c == '\t' || c == '\r':
+			p.i++
+		case c == '/' && p.jsonc:
+			if err := p.comment(); err != nil {
+				return nil, err
+			}
+		default:
+			return p.since(start), nil
+		}
+	}
+	return p.since(start), nil
+}
+
+// reads the comment at p.i, which begins with a slash
+func (p *parser) comment() error {
+	start := p.i
+	switch {
+	case bytes.HasPrefix(p.data[p.i:], []byte("//")):
+		end := bytes.IndexByte(p.data[p.i:], '\n')
+		if end < 0 {
+			end = len(p.data) - p.i
+		}
+		p.i += end
+	case bytes.HasPrefix(p.data[p.i:], []byte("/*")):
+		end := bytes.Index(p.data[p.i+2:], []byte("*/"))
+		if end < 0 {
+			return fmt.Errorf("Unterminated comment beginning at offset %d", start)
+		}
+		p.i += 2 + end + 2
+	default:
+		return fmt.Errorf("Invalid comment at offset %d", start)
+	}
+	return nil
+}
+
+func (p *parser) value() (*Node, error) {
+	if p.i >= len(p.data) {
+		return nil, p.expected("value")
+	}
+	switch c := p.data[p.i]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		literal, _, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: String, Literal: literal}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.i
+		for p.i < len(p.data) && isNumberByte(p.data[p.i]) {
+			p.i++
+		}
+		literal := p.since(start)
+		if lex.NumericTokenType(string(literal)) == tok.Invalid {
+			return nil, fmt.Errorf("Invalid number at offset %d: %q", start, literal)
+		}
+		return &Node{Kind: Number, Literal: literal}, nil
+	case c >= 'a' && c <= 'z':
+		start := p.i
+		for p.i < len(p.data) && p.data[p.i] >= 'a' && p.data[p.i] <= 'z' {
+			p.i++
+		}
+		literal := p.since(start)
+		switch string(literal) {
+		case "true", "false":
+			return &Node{Kind: Bool, Literal: literal}, nil
+		case "null":
+			return &Node{Kind: Null, Literal: literal}, nil
+		}
+		return nil, fmt.Errorf("Invalid literal at offset %d: %q", start, literal)
+	}
+	return nil, p.expected("value")
+}
+
+// reads the string at p.i, returning its literal and its unescaped value
+func (p *parser) str() ([]byte, string, error) {
+	start := p.i
+	for p.i++; p.i < len(p.data); p.i++ {
+		switch c := p.data[p.i]; {
+		case c == '\\':
+			p.i++
+		case c == '"':
+			p.i++
+			literal := p.since(start)
+			str, ok := lex.AppendUnescaped(nil, literal[1:len(literal)-1])
+			if !ok {
+				return nil, "", fmt.Errorf("Invalid escape sequence in string beginning at offset %d", start)
+			}
+			return literal, string(str), nil
+		case c < 0x20:
+			return nil, "", fmt.Errorf("Invalid control character %U in string beginning at offset %d", c, start)
+		}
+	}
+	return nil, "", fmt.Errorf("Unterminated string beginning at offset %d", start)
+}
+
+func (p *parser) object() (*Node, error) {
+	n := &Node{Kind: Object}
+	p.i++
+	leading, err := p.trivia()
+	if err != nil {
+		return nil, err
+	}
+	if p.at('}') {
+		n.End = leading
+		p.i++
+		return n, nil
+	}
+
+	seen := map[string]bool{}
+	for {
+		m := &Member{Leading: leading}
+		if !p.at('"') {
+			return nil, p.expected("key")
+		}
+		keyStart := p.i
+		if m.Key, m.Name, err = p.str(); err != nil {
+			return nil, err
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("Found duplicate key %q at offset %d", m.Name, keyStart)
+		}
+		seen[m.Name] = true
+		if m.BeforeColon, err = p.trivia(); err != nil {
+			return nil, err
+		}
+		if !p.at(':') {
+			return nil, p.expected("colon")
+		}
+		p.i++
+		if m.AfterColon, err = p.trivia(); err != nil {
+			return nil, err
+		}
+		if m.Value, err = p.value(); err != nil {
+			return nil, err
+		}
+		if m.Trailing, err = p.trivia(); err != nil {
+			return nil, err
+		}
+		n.Members = append(n.Members, m)
+
+		if p.at('}') {
+			p.i++
+			return n, nil
+		}
+		if !p.at(',') {
+			return nil, p.expected("comma or closing curly brace")
+		}
+		comma := p.i
+		p.i++
+		if leading, err = p.trivia(); err != nil {
+			return nil, err
+		}
+		if p.jsonc && p.at('}') {
+			n.End = p.since(comma)
+			p.i++
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) array() (*Node, error) {
+	n := &Node{Kind: Array}
+	p.i++
+	leading, err := p.trivia()
+	if err != nil {
+		return nil, err
+	}
+	if p.at(']') {
+		n.End = leading
+		p.i++
+		return n, nil
+	}
+
+	for {
+		e := &Elem{Leading: leading}
+		if e.Value, err = p.value(); err != nil {
+			return nil, err
+		}
+		if e.Trailing, err = p.trivia(); err != nil {
+			return nil, err
+		}
+		n.Elems = append(n.Elems, e)
+
+		if p.at(']') {
+			p.i++
+			return n, nil
+		}
+		if !p.at(',') {
+			return nil, p.expected("comma or closing square bracket")
+		}
+		comma := p.i
+		p.i++
+		if leading, err = p.trivia(); err != nil {
+			return nil, err
+		}
+		if p.jsonc && p.at(']') {
+			n.End = p.since(comma)
+			p.i++
+			return n, nil
+		}
+	}
+}
+
+// the bytes a number may hold, whether in a valid order is checked at its end
+func isNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
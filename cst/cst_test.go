@@ -0,0 +1,251 @@
+package cst
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// documents are written back byte for byte, and hold the values parse.Parse returns
+func TestRoundTrip(t *testing.T) {
+	docs := []string{
+		`{}`,
+		" \n[ ] \n",
+		`{"a":1,"b" : [ 1.0E2, -0, "é\n" ,true,false,null ] }`,
+		"{\n\t\"nested\": {\"x\": [[], {}, [{}]]}\n}\n",
+	}
+	for _, f := range []string{"colors1.json", "colors2.json", "colors3.json", "gdp.json", "meteorites.json"} {
+		data, err := ioutil.ReadFile("../parse/testdata/" + f)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", f, err)
+		}
+		docs = append(docs, string(data))
+	}
+
+	for _, doc := range docs {
+		d, err := Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Errorf("doc: %.40q, Parse(): %v", doc, err)
+			continue
+		}
+		if got := string(d.Bytes()); got != doc {
+			t.Errorf("doc: %.40q, got: %.40q", doc, got)
+		}
+
+		got, err := d.Root.Value()
+		want, _ := parse.Parse(strings.NewReader(doc))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("doc: %.40q, got value: %.40v, want: %.40v, err: %v", doc, got, want, err)
+		}
+	}
+}
+
+func TestParseJSONC(t *testing.T) {
+	doc := "// config\n{\n  \"a\": 1, /* one */\n  \"b\": [2,],\n}\n"
+
+	d, err := ParseWithOptions(strings.NewReader(doc), Options{JSONC: true})
+	if err != nil {
+		t.Fatalf("ParseWithOptions(): %v", err)
+	}
+	if got := string(d.Bytes()); got != doc {
+		t.Errorf("got: %q, want: %q", got, doc)
+	}
+	if _, err := Parse(strings.NewReader(doc)); err == nil {
+		t.Errorf("Parse() of a JSONC document did not fail")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		doc     string
+		wantErr string
+	}{
+		{doc: ``, wantErr: "Unexpected end of document at offset 0, expected value"},
+		{doc: `{"a" 1}`, wantErr: "Expected colon at offset 5, found: '1'"},
+		{doc: `[1 2]`, wantErr: "Expected comma or closing square bracket at offset 3, found: '2'"},
+		{doc: `{"a": 1,}`, wantErr: "Expected key at offset 8, found: '}'"},
+		{doc: `{"a": 1, "a": 2}`, wantErr: `Found duplicate key "a" at offset 9`},
+		{doc: `[01]`, wantErr: `Invalid number at offset 1: "01"`},
+		{doc: `[nul]`, wantErr: `Invalid literal at offset 1: "nul"`},
+		{doc: `"\x"`, wantErr: "Invalid escape sequence in string beginning at offset 0"},
+		{doc: "\"a\nb\"", wantErr: "Invalid control character U+000A in string beginning at offset 0"},
+		{doc: `["a`, wantErr: "Unterminated string beginning at offset 1"},
+		{doc: `1 2`, wantErr: "Expected end of document at offset 2, found: '2'"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.doc))
+
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("doc: %q, got err: %v, want: %s", test.doc, err, test.wantErr)
+		}
+	}
+}
+
+const config = `{
+  // the version is bumped by the release script
+  "version": "1.2.3",
+  "deps": [
+    "a", // first
+    "b"
+  ],
+  "empty": {},
+  "inline": [1, 2]
+}
+`
+
+func TestEdits(t *testing.T) {
+	type edit struct {
+		op  string // set, insert or delete
+		ptr string
+		v   interface{}
+	}
+	tests := []struct {
+		edits []edit
+		want  string
+	}{
+		{
+			edits: []edit{{op: "set", ptr: "/version", v: "1.2.4"}},
+			want:  strings.Replace(config, `"1.2.3"`, `"1.2.4"`, 1),
+		},
+		{
+			edits: []edit{{op: "set", ptr: "/license", v: "MIT"}},
+			want:  strings.Replace(config, "\"inline\": [1, 2]\n", "\"inline\": [1, 2],\n  \"license\": \"MIT\"\n", 1),
+		},
+		{
+			edits: []edit{{op: "insert", ptr: "/deps/-", v: "c"}},
+			want:  strings.Replace(config, "\"b\"\n", "\"b\",\n    \"c\"\n", 1),
+		},
+		{
+			edits: []edit{{op: "insert", ptr: "/deps/0", v: "z"}},
+			want:  strings.Replace(config, "\"a\", // first", "\"z\",\n    \"a\", // first", 1),
+		},
+		{
+			edits: []edit{{op: "insert", ptr: "/deps/1", v: "z"}},
+			want:  strings.Replace(config, "\"a\", // first\n    \"b\"", "\"a\", // first\n    \"z\",\n    \"b\"", 1),
+		},
+		{
+			edits: []edit{{op: "delete", ptr: "/deps/0"}},
+			want:  strings.Replace(config, "    \"a\", // first\n", "", 1),
+		},
+		{
+			edits: []edit{{op: "delete", ptr: "/deps/1"}},
+			want:  strings.Replace(config, "\"a\", // first\n    \"b\"\n", "\"a\" // first\n", 1),
+		},
+		{
+			edits: []edit{{op: "delete", ptr: "/inline"}},
+			want:  strings.Replace(config, "  \"empty\": {},\n  \"inline\": [1, 2]\n", "  \"empty\": {}\n", 1),
+		},
+		{
+			edits: []edit{{op: "delete", ptr: "/version"}},
+			want:  strings.Replace(config, "  // the version is bumped by the release script\n  \"version\": \"1.2.3\",\n", "", 1),
+		},
+		{
+			edits: []edit{
+				{op: "insert", ptr: "/inline/0", v: 0},
+				{op: "insert", ptr: "/inline/-", v: 3},
+				{op: "delete", ptr: "/inline/2"},
+			},
+			want: strings.Replace(config, "[1, 2]", "[0, 1, 3]", 1),
+		},
+		{
+			edits: []edit{
+				{op: "set", ptr: "/empty/k", v: []interface{}{true, nil}},
+				{op: "insert", ptr: "/empty/j", v: map[string]interface{}{"x": 1.5}},
+			},
+			want: strings.Replace(config, "{}", `{"k": [true,null], "j": {"x":1.5}}`, 1),
+		},
+		{
+			edits: []edit{{op: "delete", ptr: "/deps/0"}, {op: "delete", ptr: "/deps/0"}},
+			want:  strings.Replace(config, "[\n    \"a\", // first\n    \"b\"\n  ]", "[]", 1),
+		},
+	}
+
+	for i, test := range tests {
+		d, err := ParseWithOptions(strings.NewReader(config), Options{JSONC: true})
+		if err != nil {
+			t.Fatalf("ParseWithOptions(): %v", err)
+		}
+		for _, e := range test.edits {
+			ptr := pointer.MustParse(e.ptr)
+			switch e.op {
+			case "set":
+				err = d.Set(ptr, e.v)
+			case "insert":
+				err = d.Insert(ptr, e.v)
+			case "delete":
+				err = d.Delete(ptr)
+			}
+			if err != nil {
+				t.Fatalf("test %d: %s %s: %v", i, e.op, e.ptr, err)
+			}
+		}
+
+		if got := string(d.Bytes()); got != test.want {
+			t.Errorf("test %d: got:\n%s\nwant:\n%s", i, got, test.want)
+		}
+		if _, err := ParseWithOptions(bytes.NewReader(d.Bytes()), Options{JSONC: true}); err != nil {
+			t.Errorf("test %d: the edited document does not parse: %v", i, err)
+		}
+	}
+}
+
+func TestEditsCRLF(t *testing.T) {
+	doc := "{\r\n  \"a\": 1,\r\n  \"b\": 2\r\n}"
+	d, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	if err := d.Delete(pointer.Pointer{"a"}); err != nil {
+		t.Fatalf("Delete(): %v", err)
+	}
+	if err := d.Set(pointer.Pointer{"c"}, 3); err != nil {
+		t.Fatalf("Set(): %v", err)
+	}
+	want := "{\r\n  \"b\": 2,\r\n  \"c\": 3\r\n}"
+	if got := string(d.Bytes()); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestEditErrors(t *testing.T) {
+	tests := []struct {
+		op      string
+		ptr     string
+		wantErr string
+	}{
+		{op: "set", ptr: "/deps/2", wantErr: `Array index "2" out of range, array length is 2`},
+		{op: "set", ptr: "/missing/a", wantErr: `Key "missing" not found at ""`},
+		{op: "set", ptr: "/version/a", wantErr: `Cannot index into string at "/version"`},
+		{op: "insert", ptr: "/deps/3", wantErr: `Array index "3" out of range, array length is 3`},
+		{op: "delete", ptr: "", wantErr: "Cannot delete the whole document"},
+		{op: "delete", ptr: "/missing", wantErr: `Key "missing" not found`},
+	}
+
+	for _, test := range tests {
+		d, _ := ParseWithOptions(strings.NewReader(config), Options{JSONC: true})
+		ptr := pointer.MustParse(test.ptr)
+
+		var err error
+		switch test.op {
+		case "set":
+			err = d.Set(ptr, 1)
+		case "insert":
+			err = d.Insert(ptr, 1)
+		case "delete":
+			err = d.Delete(ptr)
+		}
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("%s %q: got err: %v, want: %s", test.op, test.ptr, err, test.wantErr)
+		}
+		if got := string(d.Bytes()); got != config {
+			t.Errorf("%s %q: the document changed: %s", test.op, test.ptr, got)
+		}
+	}
+}
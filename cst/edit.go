@@ -0,0 +1,314 @@
+package cst
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// Find returns the Node that ptr refers to within the Document
+func (d *Document) Find(ptr pointer.Pointer) (*Node, error) {
+	n := d.Root
+	for i, t := range ptr {
+		switch n.Kind {
+		case Object:
+			idx := n.member(t)
+			if idx < 0 {
+				return nil, fmt.Errorf("Key %q not found at %q", t, ptr[:i].String())
+			}
+			n = n.Members[idx].Value
+		case Array:
+			idx, err := pointer.Index(t, len(n.Elems))
+			if err != nil {
+				return nil, err
+			}
+			n = n.Elems[idx].Value
+		default:
+			return nil, fmt.Errorf("Cannot index into %s at %q", n.Kind, ptr[:i].String())
+		}
+	}
+	return n, nil
+}
+
+// the index of the member named name, -1 if there is none
+func (n *Node) member(name string) int {
+	for i, m := range n.Members {
+		if m.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Set replaces the value at ptr with v, keeping the trivia around it
+// a member missing from an object is added as Insert adds it
+//
+// v is written compactly, and is expected to be made up of the values
+// the parser produces: map[string]interface{}, []interface{}, string, int,
+// float64, bool or nil
+func (d *Document) Set(ptr pointer.Pointer, v interface{}) error {
+	node, err := newNode(v)
+	if err != nil {
+		return err
+	}
+	if len(ptr) == 0 {
+		d.Root = node
+		return nil
+	}
+	parent, t, err := d.parent(ptr)
+	if err != nil {
+		return err
+	}
+	if parent.Kind == Array {
+		idx, err := pointer.Index(t, len(parent.Elems))
+		if err != nil {
+			return err
+		}
+		parent.Elems[idx].Value = node
+		return nil
+	}
+	if idx := parent.member(t); idx >= 0 {
+		parent.Members[idx].Value = node
+		return nil
+	}
+	parent.insert(len(parent.Members), t, node)
+	return nil
+}
+
+// Insert adds v at ptr as the add operation of JSON Patch does: an element is
+// inserted into an array before the given index, or appended for "-",
+// and a member is added to the end of an object, or replaces one of the same name
+// the trivia of the new member or element follows that of its neighbours,
+// e.g. it is placed on a line of its own, indented as they are
+func (d *Document) Insert(ptr pointer.Pointer, v interface{}) error {
+	if len(ptr) == 0 {
+		return d.Set(ptr, v)
+	}
+	node, err := newNode(v)
+	if err != nil {
+		return err
+	}
+	parent, t, err := d.parent(ptr)
+	if err != nil {
+		return err
+	}
+	if parent.Kind == Object {
+		if idx := parent.member(t); idx >= 0 {
+			parent.Members[idx].Value = node
+			return nil
+		}
+		parent.insert(len(parent.Members), t, node)
+		return nil
+	}
+
+	idx := len(parent.Elems)
+	if t != "-" {
+		if idx, err = pointer.Index(t, len(parent.Elems)+1); err != nil {
+			return err
+		}
+	}
+	parent.insert(idx, "", node)
+	return nil
+}
+
+// Delete removes the value at ptr from its object or array, along with the
+// trivia before it, and its comma
+func (d *Document) Delete(ptr pointer.Pointer) error {
+	if len(ptr) == 0 {
+		return fmt.Errorf("Cannot delete the whole document")
+	}
+	parent, t, err := d.parent(ptr)
+	if err != nil {
+		return err
+	}
+	var idx int
+	if parent.Kind == Object {
+		if idx = parent.member(t); idx < 0 {
+			return fmt.Errorf("Key %q not found", t)
+		}
+	} else if idx, err = pointer.Index(t, len(parent.Elems)); err != nil {
+		return err
+	}
+	parent.delete(idx)
+	return nil
+}
+
+// returns the object or array holding the value at ptr, which is not empty,
+// and the last token of ptr
+func (d *Document) parent(ptr pointer.Pointer) (*Node, string, error) {
+	parent, err := d.Find(ptr[:len(ptr)-1])
+	if err != nil {
+		return nil, "", err
+	}
+	if parent.Kind != Object && parent.Kind != Array {
+		return nil, "", fmt.Errorf("Cannot index into %s at %q", parent.Kind, ptr[:len(ptr)-1].String())
+	}
+	return parent, ptr[len(ptr)-1], nil
+}
+
+// the Node of v written compactly
+func newNode(v interface{}) (*Node, error) {
+	b, err := encode.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{data: b}
+	return p.value()
+}
+
+// the trivia around the member or element of n at i
+func (n *Node) trivia(i int) (leading, trailing *[]byte) {
+	if n.Kind == Object {
+		return &n.Members[i].Leading, &n.Members[i].Trailing
+	}
+	return &n.Elems[i].Leading, &n.Elems[i].Trailing
+}
+
+func (n *Node) len() int {
+	if n.Kind == Object {
+		return len(n.Members)
+	}
+	return len(n.Elems)
+}
+
+// inserts node at i into the object or array n, as the member name of an object
+func (n *Node) insert(i int, name string, node *Node) {
+	var leading, trailing []byte
+	sep := n.separator()
+	switch count := n.len(); {
+	case count == 0:
+	case i < count:
+		// the new item takes the place of the one at i, which moves down
+		prev, _ := n.trivia(i)
+		leading, *prev = *prev, sep
+	default:
+		// a comment at the end of the last item has to move behind the new comma,
+		// and the last line break stays before the closing bracket
+		_, last := n.trivia(count - 1)
+		leading = sep
+		if nl := lastNewline(*last); nl >= 0 {
+			if comment := (*last)[:nl]; !isSpace(comment) {
+				leading = concat(comment, sep)
+			}
+			trailing = concat((*last)[nl:])
+			*last = nil
+		}
+	}
+
+	if n.Kind == Array {
+		e := &Elem{Leading: leading, Value: node, Trailing: trailing}
+		n.Elems = append(n.Elems, nil)
+		copy(n.Elems[i+1:], n.Elems[i:])
+		n.Elems[i] = e
+		return
+	}
+
+	var key bytes.Buffer
+	encode.WriteString(&key, name)
+	m := &Member{Leading: leading, Key: key.Bytes(), Name: name, AfterColon: []byte(" "), Value: node, Trailing: trailing}
+	if len(n.Members) > 0 {
+		like := n.Members[len(n.Members)-1]
+		m.BeforeColon, m.AfterColon = concat(like.BeforeColon), concat(like.AfterColon)
+	}
+	n.Members = append(n.Members, nil)
+	copy(n.Members[i+1:], n.Members[i:])
+	n.Members[i] = m
+}
+
+// removes the member or element of n at i
+func (n *Node) delete(i int) {
+	count := n.len()
+	leading, trailing := n.trivia(i)
+	switch {
+	case count == 1:
+		n.End = nil
+	case i < count-1:
+		// the next item takes over what is on the line the removed one began on,
+		// the comments above the removed item go with it
+		next, _ := n.trivia(i + 1)
+		if first := bytes.IndexByte(*leading, '\n'); first < 0 {
+			*next = *leading
+		} else if line := bytes.TrimRight((*leading)[:first], "\r"); lastNewline(*next) >= 0 {
+			*next = concat(line, (*next)[lastNewline(*next):])
+		} else {
+			*next = concat(line, (*leading)[lastNewline(*leading):])
+		}
+	default:
+		// the previous item takes over the comment on its line,
+		// and the last line break before the closing bracket
+		_, prev := n.trivia(i - 1)
+		*prev = concat(*prev, sameLine(*leading))
+		if nl := lastNewline(*trailing); nl >= 0 {
+			*prev = concat(*prev, (*trailing)[nl:])
+		}
+	}
+
+	if n.Kind == Array {
+		n.Elems = append(n.Elems[:i], n.Elems[i+1:]...)
+	} else {
+		n.Members = append(n.Members[:i], n.Members[i+1:]...)
+	}
+}
+
+// the trivia that goes before a new member or element of n: the indentation
+// of its second item, or of the first if it is on a line of its own,
+// a space if they are on one line, and nothing if n is empty
+func (n *Node) separator() []byte {
+	var ref []byte
+	switch n.len() {
+	case 0:
+		return nil
+	case 1:
+		first, _ := n.trivia(0)
+		ref = *first
+	default:
+		second, _ := n.trivia(1)
+		ref = *second
+		if lastNewline(ref) < 0 && isSpace(ref) {
+			return concat(ref)
+		}
+	}
+	if nl := lastNewline(ref); nl >= 0 {
+		return concat(ref[nl:])
+	}
+	return []byte(" ")
+}
+
+// the index of the line break that ends the last line of trivia,
+// including the carriage return of a CRLF, -1 if there is none
+func lastNewline(trivia []byte) int {
+	nl := bytes.LastIndexByte(trivia, '\n')
+	if nl > 0 && trivia[nl-1] == '\r' {
+		nl--
+	}
+	return nl
+}
+
+// the comment that trivia begins with on the line it begins on, if there is one
+// and the trivia goes on to another line
+func sameLine(trivia []byte) []byte {
+	nl := bytes.IndexByte(trivia, '\n')
+	if nl < 0 {
+		return nil
+	}
+	line := bytes.TrimRight(trivia[:nl], "\r")
+	if isSpace(line) {
+		return nil
+	}
+	return line
+}
+
+func isSpace(trivia []byte) bool {
+	return len(bytes.Trim(trivia, " \t\r\n")) == 0
+}
+
+// concatenates parts into a new slice, so that no trivia shares its bytes
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
@@ -0,0 +1,242 @@
+package gojson
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Unmarshal decodes the JSON document in data into the value v points to
+//
+// objects decode into structs, whose fields are matched by their json tag
+// or their Go name exactly, and into maps with string keys or keys
+// implementing encoding.TextUnmarshaler
+// arrays decode into slices and arrays, numbers into any integer or float type
+// that can hold them, and null sets pointers, slices, maps and interfaces to nil
+// while leaving other values alone
+// values of type interface{} receive the same values Parse returns
+// types implementing ValueDecoder or Unmarshaler decode themselves, types
+// implementing encoding.TextUnmarshaler decode themselves from strings
+func Unmarshal(data []byte, v interface{}) error {
+	return Decode(bytes.NewReader(data), v)
+}
+
+// Decode is like Unmarshal but reads the document from r
+func Decode(r io.Reader, v interface{}) error {
+	l := lex.New(r)
+	if err := DecodeValue(l, l.ReadToken(), v); err != nil {
+		return err
+	}
+	if eof := l.ReadToken(); eof.TokenType != tok.EOF {
+		return fmt.Errorf("Expected end of document, found: %q", eof.Literal)
+	}
+	return nil
+}
+
+// DecodeValue decodes the value that begins with first into the value v points to,
+// as Unmarshal would, leaving l positioned after the end of the value
+func DecodeValue(l lex.Lexer, first tok.Token, v interface{}) error {
+	if d, ok := v.(ValueDecoder); ok {
+		return d.DecodeJSON(l, first)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Expected a non-nil pointer to decode into, got: %T", v)
+	}
+	return decodeValue(l, first, rv.Elem())
+}
+
+func decodeValue(l lex.Lexer, t tok.Token, rv reflect.Value) error {
+	if t.TokenType == tok.Invalid || t.TokenType == tok.EOF {
+		return unexpected("value", t)
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if t.TokenType == tok.Null {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decodeValue(l, t, rv.Elem())
+	}
+	if v, h := receiver(rv); h != 0 {
+		if ok, err := decodeHook(l, t, v, h); ok {
+			return err
+		}
+	}
+
+	if t.TokenType == tok.Null {
+		switch rv.Kind() {
+		case reflect.Interface, reflect.Slice, reflect.Map:
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return fmt.Errorf("Cannot decode into non-empty interface %s", rv.Type())
+		}
+		v, err := parse.ParseValue(l, t)
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(v))
+	case reflect.Bool:
+		v, err := DecodeBool(t)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(v)
+	case reflect.String:
+		v, err := DecodeString(t)
+		if err != nil {
+			return err
+		}
+		rv.SetString(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := DecodeInt(t, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v, err := DecodeUint(t, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := DecodeFloat(t, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(v)
+	case reflect.Slice:
+		return decodeSlice(l, t, rv)
+	case reflect.Array:
+		return decodeArray(l, t, rv)
+	case reflect.Map:
+		return decodeMap(l, t, rv)
+	case reflect.Struct:
+		return decodeStruct(l, t, rv)
+	default:
+		return fmt.Errorf("Unsupported type: %s", rv.Type())
+	}
+	return nil
+}
+
+// elements are decoded into zeroed slots, reusing the slice's backing array
+func decodeSlice(l lex.Lexer, t tok.Token, rv reflect.Value) error {
+	n := 0
+	err := DecodeArray(l, t, func(t tok.Token) error {
+		if n < rv.Cap() {
+			rv.SetLen(n + 1)
+			rv.Index(n).Set(reflect.Zero(rv.Type().Elem()))
+		} else {
+			rv.Set(reflect.Append(rv, reflect.Zero(rv.Type().Elem())))
+		}
+		n++
+		return decodeValue(l, t, rv.Index(n-1))
+	})
+	if err != nil {
+		return err
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+	}
+	rv.SetLen(n)
+	return nil
+}
+
+// elements beyond the length of the array are skipped,
+// elements missing from the document are zeroed
+func decodeArray(l lex.Lexer, t tok.Token, rv reflect.Value) error {
+	n := 0
+	err := DecodeArray(l, t, func(t tok.Token) error {
+		n++
+		if n > rv.Len() {
+			return SkipValue(l, t)
+		}
+		return decodeValue(l, t, rv.Index(n-1))
+	})
+	if err != nil {
+		return err
+	}
+	for i := n; i < rv.Len(); i++ {
+		rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+	}
+	return nil
+}
+
+// keys decode into string types and into types implementing encoding.TextUnmarshaler
+func decodeMap(l lex.Lexer, t tok.Token, rv reflect.Value) error {
+	mt := rv.Type()
+	kt := mt.Key()
+	textKeys := kt.Kind() != reflect.String
+	if textKeys && cachedHooks(reflect.PtrTo(kt))&textUnmarshalerHook == 0 {
+		return fmt.Errorf("Unsupported map key type: %s", kt)
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(mt))
+	}
+	return DecodeObject(l, t, func(key string, t tok.Token) error {
+		kv := reflect.New(kt)
+		if textKeys {
+			if err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+				return err
+			}
+		} else {
+			kv.Elem().SetString(key)
+		}
+
+		elem := reflect.New(mt.Elem()).Elem()
+		if err := decodeValue(l, t, elem); err != nil {
+			return err
+		}
+		rv.SetMapIndex(kv.Elem(), elem)
+		return nil
+	})
+}
+
+// keys without a matching field are skipped
+func decodeStruct(l lex.Lexer, t tok.Token, rv reflect.Value) error {
+	fields := cachedFields(rv.Type())
+	return DecodeObject(l, t, func(key string, t tok.Token) error {
+		f, ok := fields.byName[key]
+		if !ok {
+			return SkipValue(l, t)
+		}
+		fv, err := fieldByIndex(rv, f.index)
+		if err != nil {
+			return err
+		}
+		return decodeValue(l, t, fv)
+	})
+}
+
+// allocates the nil embedded struct pointers on the way to a promoted field
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("Cannot set embedded pointer to unexported struct %s", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
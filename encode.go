@@ -0,0 +1,205 @@
+package gojson
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/vyevs/gojson/encode"
+)
+
+// Marshal returns the compact JSON encoding of v
+//
+// structs are encoded as objects with a member for every field Unmarshal
+// would decode, in declaration order, fields tagged omitempty are left out
+// when they hold false, 0, "", a nil pointer or interface, or an empty
+// slice, map or array, maps are encoded with their keys sorted,
+// maps need string keys or keys implementing encoding.TextMarshaler
+// nil slices, maps and pointers are encoded as null
+// types implementing ValueEncoder or Marshaler encode themselves, types
+// implementing encoding.TextMarshaler are encoded as strings
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := EncodeValue(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeValue writes the compact JSON encoding of v to buf, as Marshal would
+func EncodeValue(buf *bytes.Buffer, v interface{}) error {
+	if e, ok := v.(ValueEncoder); ok {
+		return e.EncodeJSON(buf)
+	}
+	return encodeValue(buf, reflect.ValueOf(v))
+}
+
+func encodeValue(buf *bytes.Buffer, rv reflect.Value) error {
+	if !rv.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+	if v, h := receiver(rv); h != 0 {
+		if ok, err := encodeHook(buf, v, h); ok {
+			return err
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return encodeValue(buf, rv.Elem())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(rv.Bool()))
+	case reflect.String:
+		encode.WriteString(buf, rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.WriteString(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return encode.WriteFloat(buf, rv.Float(), rv.Type().Bits())
+	case reflect.Slice:
+		if rv.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeArray(buf, rv)
+	case reflect.Array:
+		return encodeArray(buf, rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeMap(buf, rv)
+	case reflect.Struct:
+		return encodeStruct(buf, rv)
+	default:
+		return fmt.Errorf("Unsupported type: %s", rv.Type())
+	}
+	return nil
+}
+
+func encodeArray(buf *bytes.Buffer, rv reflect.Value) error {
+	buf.WriteByte('[')
+	for i := 0; i < rv.Len(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeValue(buf, rv.Index(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// keys of types implementing encoding.TextMarshaler rather than
+// of a string type are encoded as the text they marshal to
+func encodeMap(buf *bytes.Buffer, rv reflect.Value) error {
+	kt := rv.Type().Key()
+	textKeys := kt.Kind() != reflect.String
+	if textKeys && cachedHooks(kt)&textMarshalerHook == 0 {
+		return fmt.Errorf("Unsupported map key type: %s", kt)
+	}
+
+	type member struct {
+		key string
+		v   reflect.Value
+	}
+	members := make([]member, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		key := k.String()
+		if textKeys {
+			text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return err
+			}
+			key = string(text)
+		}
+		members = append(members, member{key: key, v: rv.MapIndex(k)})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encode.WriteString(buf, m.key)
+		buf.WriteByte(':')
+		if err := encodeValue(buf, m.v); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func encodeStruct(buf *bytes.Buffer, rv reflect.Value) error {
+	buf.WriteByte('{')
+	first := true
+	for _, f := range cachedFields(rv.Type()).list {
+		fv, ok := promotedField(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		encode.WriteString(buf, f.name)
+		buf.WriteByte(':')
+		if err := encodeValue(buf, fv); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// fields promoted through a nil embedded pointer are left out
+func promotedField(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// IsEmptyValue reports whether Marshal leaves out
+// a struct field tagged omitempty that holds v
+func IsEmptyValue(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return !rv.IsValid() || isEmptyValue(rv)
+}
+
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
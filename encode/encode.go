@@ -0,0 +1,292 @@
+package encode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+// Marshal returns the compact JSON encoding of v
+//
+// v is expected to be made up of the values the parser produces:
+// map[string]interface{}, []interface{}, string, int, float64, bool or nil
+// object keys are written in sorted order so the output is deterministic
+func Marshal(v interface{}) ([]byte, error) {
+	return MarshalWithOptions(v, Options{})
+}
+
+// MarshalIndent is like Marshal but places every array element and object member
+// on its own line, beginning with prefix followed by copies of indent
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return MarshalWithOptions(v, Options{Prefix: prefix, Indent: indent})
+}
+
+// Encode writes the compact JSON encoding of v to w
+func Encode(w io.Writer, v interface{}) error {
+	b, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Replacer is called with every value before it is written, so a value is
+// replaced before its members and elements are, along with the path to the
+// value, which is empty for the whole document
+// the value returned is written in place of value, returning parse.Drop leaves
+// the value out of its object or array, any other error aborts the encoding
+// dropping the whole document makes it null, and path must not be retained
+// after the call
+type Replacer func(path []parse.PathElem, value interface{}) (interface{}, error)
+
+// Options configures MarshalWithOptions
+type Options struct {
+	// Prefix and Indent are used as by MarshalIndent,
+	// the encoding is compact if both are empty
+	Prefix, Indent string
+
+	// Replacer is called with every value written, if set
+	Replacer Replacer
+
+	// JSON5 writes JSON5, https://spec.json5.org, with keys that are identifiers
+	// unquoted, strings in single quotes if that needs fewer escapes, and
+	// NaN and infinities, which are errors otherwise, written as NaN and Infinity
+	JSON5 bool
+}
+
+// MarshalWithOptions is like Marshal but formats and replaces values as set in opts
+// e.g. a Replacer leaving out secrets or turning time.Time into strings
+func MarshalWithOptions(v interface{}, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	e := &encoder{buf: &buf, prefix: opts.Prefix, indent: opts.Indent, replacer: opts.Replacer, json5: opts.JSON5}
+	v, keep, err := e.replace(v)
+	if err != nil {
+		return nil, err
+	}
+	if !keep {
+		v = nil
+	}
+	if err := e.writeValue(v, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type encoder struct {
+	buf      *bytes.Buffer
+	prefix   string
+	indent   string
+	replacer Replacer
+	path     []parse.PathElem // to the value being written, tracked only with a replacer
+	json5    bool
+}
+
+func (e *encoder) writeValue(v interface{}, depth int) error {
+	switch actual := v.(type) {
+	case nil:
+		e.buf.WriteString("null")
+	case bool:
+		e.buf.WriteString(strconv.FormatBool(actual))
+	case string:
+		if e.json5 {
+			writeJSON5String(e.buf, actual)
+		} else {
+			WriteString(e.buf, actual)
+		}
+	case int:
+		e.buf.WriteString(strconv.Itoa(actual))
+	case int64:
+		e.buf.WriteString(strconv.FormatInt(actual, 10))
+	case float64:
+		if e.json5 {
+			return writeJSON5Float(e.buf, actual)
+		}
+		return WriteFloat(e.buf, actual, 64)
+	case []interface{}:
+		return e.writeArray(actual, depth)
+	case map[string]interface{}:
+		return e.writeObject(actual, depth)
+	default:
+		return fmt.Errorf("Unsupported value type: %T", v)
+	}
+	return nil
+}
+
+func (e *encoder) writeArray(arr []interface{}, depth int) error {
+	e.buf.WriteByte('[')
+	var n int
+	for i, v := range arr {
+		written, err := e.writeElem(parse.PathElem{Index: i}, v, n == 0, depth+1)
+		if err != nil {
+			return err
+		}
+		if written {
+			n++
+		}
+	}
+	if n > 0 {
+		e.writeNewline(depth)
+	}
+	e.buf.WriteByte(']')
+	return nil
+}
+
+func (e *encoder) writeObject(obj map[string]interface{}, depth int) error {
+	e.buf.WriteByte('{')
+	var n int
+	for _, k := range SortedKeys(obj) {
+		written, err := e.writeElem(parse.PathElem{Key: k, Index: -1}, obj[k], n == 0, depth+1)
+		if err != nil {
+			return err
+		}
+		if written {
+			n++
+		}
+	}
+	if n > 0 {
+		e.writeNewline(depth)
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// replaces and writes the object member or array element v found at elem,
+// preceded by a comma unless it is the first one written
+// reports false if the replacer dropped v
+func (e *encoder) writeElem(elem parse.PathElem, v interface{}, first bool, depth int) (bool, error) {
+	if e.replacer != nil {
+		e.path = append(e.path, elem)
+		defer func() { e.path = e.path[:len(e.path)-1] }()
+	}
+	v, keep, err := e.replace(v)
+	if err != nil || !keep {
+		return false, err
+	}
+
+	if !first {
+		e.buf.WriteByte(',')
+	}
+	e.writeNewline(depth)
+	if elem.Index < 0 {
+		if e.json5 {
+			writeJSON5Key(e.buf, elem.Key)
+		} else {
+			WriteString(e.buf, elem.Key)
+		}
+		e.buf.WriteByte(':')
+		if e.indent != "" {
+			e.buf.WriteByte(' ')
+		}
+	}
+	return true, e.writeValue(v, depth)
+}
+
+// calls the replacer with v at the current path, reporting false if v is to be left out
+func (e *encoder) replace(v interface{}) (interface{}, bool, error) {
+	if e.replacer == nil {
+		return v, true, nil
+	}
+	v, err := e.replacer(e.path, v)
+	if err == parse.Drop {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("Replacer at %q: %v", parse.PathPointer(e.path), err)
+	}
+	return v, true, nil
+}
+
+// writes a newline followed by the indentation for depth
+// does nothing in compact mode
+func (e *encoder) writeNewline(depth int) {
+	if e.prefix == "" && e.indent == "" {
+		return
+	}
+	e.buf.WriteByte('\n')
+	e.buf.WriteString(e.prefix)
+	for i := 0; i < depth; i++ {
+		e.buf.WriteString(e.indent)
+	}
+}
+
+// SortedKeys returns the keys of obj in ascending order
+func SortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const hexDigits = "0123456789abcdef"
+
+// WriteString writes str to buf as a quoted JSON string
+// escaping double quotes, backslashes and control characters
+func WriteString(buf *bytes.Buffer, str string) {
+	writeQuoted(buf, str, '"')
+}
+
+// writes str to buf in quote, which is a double or single quote,
+// escaping it, backslashes and control characters
+func writeQuoted(buf *bytes.Buffer, str string, quote byte) {
+	buf.WriteByte(quote)
+	start := 0
+	for i := 0; i < len(str); i++ {
+		b := str[i]
+		if b >= 0x20 && b != quote && b != '\\' {
+			continue
+		}
+		buf.WriteString(str[start:i])
+		switch b {
+		case quote, '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		default:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hexDigits[b>>4])
+			buf.WriteByte(hexDigits[b&0xF])
+		}
+		start = i + 1
+	}
+	buf.WriteString(str[start:])
+	buf.WriteByte(quote)
+}
+
+// WriteFloat writes f to buf as a JSON number, with the shortest digits that
+// read back as the same value of bitSize bits, 32 for a float32 and 64 otherwise
+// the output always contains a decimal point or an exponent so that
+// it is parsed back as a floating point value rather than an integer
+func WriteFloat(buf *bytes.Buffer, f float64, bitSize int) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("Unsupported float value: %v", f)
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	var scratch [32]byte
+	b := strconv.AppendFloat(scratch[:0], f, format, -1, bitSize)
+	buf.Write(b)
+	if format == 'f' && bytes.IndexByte(b, '.') < 0 {
+		buf.WriteString(".0")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package encode
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		v       interface{}
+		want    string
+		wantErr bool
+	}{
+		{v: nil, want: "null"},
+		{v: true, want: "true"},
+		{v: 12, want: "12"},
+		{v: -0.5, want: "-0.5"},
+		{v: 3.0, want: "3.0"},
+		{v: 1e21, want: "1e+21"},
+		{v: "a\"b\\c\n\x01", want: `"a\"b\\c\n\u0001"`},
+		{v: []interface{}{}, want: "[]"},
+		{v: []interface{}{1, "a", nil}, want: `[1,"a",null]`},
+		{v: map[string]interface{}{}, want: "{}"},
+		{
+			v:    map[string]interface{}{"b": 1, "a": []interface{}{false}},
+			want: `{"a":[false],"b":1}`,
+		},
+		{v: struct{}{}, wantErr: true},
+		{v: []interface{}{1, struct{}{}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := Marshal(test.v)
+
+		gotErr := err != nil
+		if gotErr != test.wantErr || string(got) != test.want {
+			t.Errorf("v: %v, got: %s, want: %s, err: %v, wantErr: %v",
+				test.v, got, test.want, err, test.wantErr)
+		}
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	v := map[string]interface{}{
+		"a": []interface{}{1, 2},
+		"b": map[string]interface{}{},
+	}
+	want := `{
+  "a": [
+    1,
+    2
+  ],
+  "b": {}
+}`
+
+	got, err := MarshalIndent(v, "", "  ")
+	if err != nil || string(got) != want {
+		t.Errorf("got: %s, want: %s, err: %v", got, want, err)
+	}
+}
+
+func TestMarshalWithReplacer(t *testing.T) {
+	var calls []string
+	replacer := func(path []parse.PathElem, v interface{}) (interface{}, error) {
+		calls = append(calls, parse.PathPointer(path).String())
+
+		if len(path) > 0 && path[len(path)-1].Key == "password" {
+			return nil, parse.Drop
+		}
+		if at, ok := v.(time.Time); ok {
+			return at.Format(time.RFC3339), nil
+		}
+		if n, ok := v.(int); ok && n < 0 {
+			return nil, parse.Drop
+		}
+		return v, nil
+	}
+	v := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name":     "x",
+			"password": "hunter2",
+			"seen":     time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+		},
+		"scores": []interface{}{-1, 2, -3},
+	}
+	want := `{
+  "scores": [
+    2
+  ],
+  "user": {
+    "name": "x",
+    "seen": "2024-02-29T12:00:00Z"
+  }
+}`
+
+	got, err := MarshalWithOptions(v, Options{Indent: "  ", Replacer: replacer})
+	if err != nil || string(got) != want {
+		t.Errorf("got: %s, want: %s, err: %v", got, want, err)
+	}
+	wantCalls := []string{"", "/scores", "/scores/0", "/scores/1", "/scores/2", "/user", "/user/name", "/user/password", "/user/seen"}
+	if strings.Join(calls, " ") != strings.Join(wantCalls, " ") {
+		t.Errorf("got calls: %q, want: %q", calls, wantCalls)
+	}
+}
+
+func TestMarshalWithReplacerErrors(t *testing.T) {
+	fail := func(path []parse.PathElem, v interface{}) (interface{}, error) {
+		if v == true {
+			return nil, fmt.Errorf("no true values")
+		}
+		return v, nil
+	}
+	got, err := MarshalWithOptions(map[string]interface{}{"a": []interface{}{false, true}}, Options{Replacer: fail})
+	if err == nil || !strings.Contains(err.Error(), `"/a/1"`) {
+		t.Errorf("got: %s, err: %v, want error at /a/1", got, err)
+	}
+
+	dropAll := func(path []parse.PathElem, v interface{}) (interface{}, error) {
+		return nil, parse.Drop
+	}
+	got, err = MarshalWithOptions([]interface{}{1}, Options{Replacer: dropAll})
+	if err != nil || string(got) != "null" {
+		t.Errorf("got: %s, err: %v, want: null", got, err)
+	}
+}
+
+// values produced by the parser must survive being encoded and parsed again
+func TestMarshalRoundTrip(t *testing.T) {
+	docs := []string{
+		`{"a": "potato", "b": 1.2345, "c": null, "d": [1, 2.5, true, false, {}]}`,
+		`[{"x": -12}, [], "grass knoll", 0.001]`,
+		`-3`,
+	}
+
+	for _, doc := range docs {
+		v, err := parse.Parse(strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", doc, err)
+		}
+		encoded, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal(%v): %v", v, err)
+		}
+		again, err := parse.Parse(strings.NewReader(string(encoded)))
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", encoded, err)
+		}
+		reencoded, _ := Marshal(again)
+		if string(reencoded) != string(encoded) {
+			t.Errorf("doc: %q, 1st encoding: %s, 2nd encoding: %s", doc, encoded, reencoded)
+		}
+	}
+}
+
+func TestMarshalJSON5(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{v: map[string]interface{}{"a": 1, "$b_2": 2, "2c": 3, "": 4, "while": 5, "d-e": 6}, want: `{"":4,$b_2:2,"2c":3,a:1,"d-e":6,"while":5}`},
+		{v: []interface{}{`say "hi"`, "it's", `"'`, "\n"}, want: `['say "hi"',"it's","\"'","\n"]`},
+		{v: []interface{}{math.NaN(), math.Inf(1), math.Inf(-1), 0.5}, want: `[NaN,Infinity,-Infinity,0.5]`},
+	}
+
+	for _, test := range tests {
+		got, err := MarshalWithOptions(test.v, Options{JSON5: true})
+
+		if err != nil || string(got) != test.want {
+			t.Errorf("v: %v, got: %s, want: %s, err: %v", test.v, got, test.want, err)
+		}
+	}
+
+	got, err := MarshalWithOptions(map[string]interface{}{"a": []interface{}{1}}, Options{JSON5: true, Indent: "  "})
+	if want := "{\n  a: [\n    1\n  ]\n}"; err != nil || string(got) != want {
+		t.Errorf("got: %q, want: %q, err: %v", got, want, err)
+	}
+}
+
+// JSON5 written by the encoder is parsed back by the parser in JSON5 mode
+func TestMarshalJSON5RoundTrip(t *testing.T) {
+	v := map[string]interface{}{
+		"key":      "it's \"quoted\"",
+		"quoted'":  []interface{}{1, -2.5, math.Inf(1), true, nil},
+		"nested":   map[string]interface{}{"$": " \t"},
+		"reserved": map[string]interface{}{"null": false},
+	}
+
+	encoded, err := MarshalWithOptions(v, Options{JSON5: true})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions(): %v", err)
+	}
+	got, err := parse.ParseWithOptions(strings.NewReader(string(encoded)), parse.Options{JSON5: true})
+	if err != nil {
+		t.Fatalf("ParseWithOptions(%s): %v", encoded, err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("encoded: %s, got: %v, want: %v", encoded, got, v)
+	}
+}
@@ -0,0 +1,65 @@
+package encode
+
+import (
+	"bytes"
+	"math"
+	"strings"
+)
+
+// writes key unquoted if it is an identifier, otherwise as a string
+// identifiers are kept to ASCII letters, digits, _ and $, and do not include
+// the reserved words, which JSON5 accepts as keys but ES5 engines may not
+func writeJSON5Key(buf *bytes.Buffer, key string) {
+	if isIdentifier(key) {
+		buf.WriteString(key)
+		return
+	}
+	writeJSON5String(buf, key)
+}
+
+func isIdentifier(key string) bool {
+	if key == "" || reservedWords[key] {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		b := key[i]
+		if !(b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_' || b == '$' || i > 0 && b >= '0' && b <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+var reservedWords = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "function": true,
+	"if": true, "import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true,
+}
+
+// writes str in double quotes, or in single quotes if it has more
+// double quotes than single quotes, as JSON5.stringify does
+func writeJSON5String(buf *bytes.Buffer, str string) {
+	quote := byte('"')
+	if strings.Count(str, `"`) > strings.Count(str, "'") {
+		quote = '\''
+	}
+	writeQuoted(buf, str, quote)
+}
+
+// writes f as WriteFloat does, and NaN and the infinities as JSON5 literals
+func writeJSON5Float(buf *bytes.Buffer, f float64) error {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString("NaN")
+	case math.IsInf(f, 1):
+		buf.WriteString("Infinity")
+	case math.IsInf(f, -1):
+		buf.WriteString("-Infinity")
+	default:
+		return WriteFloat(buf, f, 64)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package gojson
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// field describes how a struct field is named and encoded
+type field struct {
+	name      string
+	index     []int // for fields promoted from embedded structs
+	omitEmpty bool
+}
+
+type structFields struct {
+	list   []field // in the order they are encoded
+	byName map[string]*field
+}
+
+var fieldCache sync.Map // reflect.Type -> *structFields
+
+// cachedFields returns the fields of struct type t
+//
+// exported fields are named by their json tag, or by their Go name without one,
+// fields tagged "-" are left out, fields of embedded structs without a tag
+// are promoted, the shallowest of several fields with the same name wins
+// and ties between fields at the same depth leave the name out entirely
+func cachedFields(t reflect.Type) *structFields {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.(*structFields)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.(*structFields)
+}
+
+func typeFields(t reflect.Type) *structFields {
+	type candidate struct {
+		field
+		depth  int
+		tagged bool
+	}
+	var candidates []candidate
+
+	type level struct {
+		t     reflect.Type
+		index []int
+	}
+	current := []level{{t: t}}
+	visited := map[reflect.Type]bool{}
+	for depth := 0; len(current) > 0; depth++ {
+		var next []level
+		for _, lv := range current {
+			if visited[lv.t] {
+				continue
+			}
+			visited[lv.t] = true
+
+			for i := 0; i < lv.t.NumField(); i++ {
+				sf := lv.t.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				index := make([]int, len(lv.index)+1)
+				copy(index, lv.index)
+				index[len(lv.index)] = i
+
+				name, opts := parseTag(tag)
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, level{t: ft, index: index})
+					continue
+				}
+				if sf.PkgPath != "" {
+					continue
+				}
+				c := candidate{
+					field:  field{name: sf.Name, index: index, omitEmpty: strings.Contains(opts, "omitempty")},
+					depth:  depth,
+					tagged: name != "",
+				}
+				if name != "" {
+					c.name = name
+				}
+				candidates = append(candidates, c)
+			}
+		}
+		current = next
+	}
+
+	// keep the dominant field for each name
+	sf := &structFields{byName: map[string]*field{}}
+	best := map[string]int{}
+	ambiguous := map[string]bool{}
+	for i, c := range candidates {
+		j, ok := best[c.name]
+		if !ok {
+			best[c.name] = i
+			continue
+		}
+		prev := candidates[j]
+		switch {
+		case c.depth > prev.depth:
+		case c.tagged && !prev.tagged:
+			best[c.name] = i
+			ambiguous[c.name] = false
+		case c.tagged == prev.tagged:
+			if c.depth == prev.depth {
+				ambiguous[c.name] = true
+			}
+		}
+	}
+	for i, c := range candidates {
+		if best[c.name] != i || ambiguous[c.name] {
+			continue
+		}
+		sf.list = append(sf.list, c.field)
+	}
+	// promoted fields are encoded where their embedded struct is declared
+	sort.Slice(sf.list, func(i, j int) bool {
+		a, b := sf.list[i].index, sf.list[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	for i := range sf.list {
+		sf.byName[sf.list[i].name] = &sf.list[i]
+	}
+	return sf
+}
+
+// splits a json struct tag into the name and the comma separated options
+func parseTag(tag string) (string, string) {
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
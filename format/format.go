@@ -0,0 +1,290 @@
+// Package format reformats JSON documents token by token, without building
+// them in memory, so that documents of any size can be compacted or indented
+//
+// the document is validated as it is written, and formatting stops with an
+// error at the first syntax error, after writing the output before it
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Options configures Format
+type Options struct {
+	// Indent is written once per level of nesting before every member and
+	// element, which are placed on lines of their own
+	// the output is compact if it is empty
+	Indent string
+
+	// MaxInlineWidth, if set along with Indent, keeps objects and arrays whose
+	// one-line form is at most this many bytes wide on one line,
+	// e.g. "coordinates": [-73.98, 40.75]
+	MaxInlineWidth int
+
+	// SortKeys writes the members of objects sorted by key, which buffers
+	// every object until it ends
+	SortKeys bool
+
+	// MaxObjectBuffer, if set, is the most bytes of output buffered for an object
+	// whose keys are sorted, Format fails for objects that need more
+	MaxObjectBuffer int
+}
+
+// Compact writes the document in r to w with all insignificant whitespace removed
+func Compact(w io.Writer, r io.Reader) error {
+	return Format(w, r, Options{})
+}
+
+// Indent writes the document in r to w with every member and element on a line
+// of its own, indented with indent once per level of nesting
+func Indent(w io.Writer, r io.Reader, indent string) error {
+	return Format(w, r, Options{Indent: indent})
+}
+
+// Format writes the document in r to w, formatted as set in opts
+// strings are written with the escapes of encode.WriteString, numbers as spelled
+func Format(w io.Writer, r io.Reader, opts Options) error {
+	f := &formatter{l: lex.New(r), opts: opts, w: w}
+	if err := f.value(f.next(), 0); err != nil {
+		return err
+	}
+	if t := f.next(); t.TokenType != tok.EOF {
+		return f.unexpected(t, "end of document")
+	}
+	return f.flush()
+}
+
+// flushed to the writer once this many bytes are buffered, unless an object is being sorted
+const flushSize = 32 * 1024
+
+// a token along with its offset in the input
+type token struct {
+	tok.Token
+	offset int64
+}
+
+type formatter struct {
+	l    lex.Lexer
+	opts Options
+	w    io.Writer
+	buf  bytes.Buffer
+
+	queue   []token // tokens read ahead to see whether a container fits on one line
+	inline  int     // the depth of containers being written on one line
+	sorting int     // the depth of objects being sorted, which are kept in buf
+	scratch bytes.Buffer
+}
+
+// returns the next token, from those read ahead first
+func (f *formatter) next() token {
+	if len(f.queue) > 0 {
+		t := f.queue[0]
+		f.queue = f.queue[1:]
+		return t
+	}
+	t := f.l.ReadToken()
+	if t.TokenType == tok.EOF {
+		return token{t, f.l.InputOffset()}
+	}
+	return token{t, f.l.TokenOffset()}
+}
+
+func (f *formatter) unexpected(t token, what string) error {
+	switch t.TokenType {
+	case tok.EOF:
+		return fmt.Errorf("Unexpected end of document at offset %d, expected %s", t.offset, what)
+	case tok.Invalid:
+		return fmt.Errorf("Invalid token at offset %d: %q", t.offset, t.Literal)
+	}
+	return fmt.Errorf("Expected %s at offset %d, found: %q", what, t.offset, t.Literal)
+}
+
+func (f *formatter) flush() error {
+	_, err := f.w.Write(f.buf.Bytes())
+	f.buf.Reset()
+	return err
+}
+
+// writes the value beginning with t at the given depth of nesting
+func (f *formatter) value(t token, depth int) error {
+	switch t.TokenType {
+	case tok.OpeningCurlyBrace, tok.OpeningSquareBracket:
+		if err := f.container(t, depth); err != nil {
+			return err
+		}
+	case tok.String:
+		encode.WriteString(&f.buf, t.Literal)
+	case tok.Integer, tok.FloatingPoint, tok.Boolean, tok.Null:
+		f.buf.WriteString(t.Literal)
+	default:
+		return f.unexpected(t, "value")
+	}
+
+	if f.sorting == 0 && f.buf.Len() >= flushSize {
+		return f.flush()
+	}
+	return nil
+}
+
+// a member of an object being sorted, written to buf from start to end
+type member struct {
+	key        string
+	start, end int
+}
+
+// writes the object or array opened by open
+func (f *formatter) container(open token, depth int) error {
+	object := open.TokenType == tok.OpeningCurlyBrace
+	closing, what := tok.ClosingSquareBracket, "comma or closing square bracket"
+	if object {
+		closing, what = tok.ClosingCurlyBrace, "comma or closing curly brace"
+	}
+
+	if f.inline > 0 || f.opts.Indent != "" && f.opts.MaxInlineWidth > 0 && f.fits() {
+		f.inline++
+		defer func() { f.inline-- }()
+	}
+	sorted := object && f.opts.SortKeys
+	if sorted {
+		f.sorting++
+		defer func() { f.sorting-- }()
+	}
+
+	f.buf.WriteString(open.Literal)
+	t := f.next()
+	if t.TokenType == closing {
+		f.buf.WriteString(t.Literal)
+		return nil
+	}
+
+	start := f.buf.Len()
+	var members []member
+	for i := 0; ; i++ {
+		if i > 0 && !sorted {
+			f.comma()
+		}
+		memberStart := f.buf.Len()
+		f.newline(depth + 1)
+
+		if object {
+			if t.TokenType != tok.String {
+				return f.unexpected(t, "key")
+			}
+			key := t.Literal
+			encode.WriteString(&f.buf, key)
+			if t = f.next(); t.TokenType != tok.Colon {
+				return f.unexpected(t, "colon")
+			}
+			f.buf.WriteByte(':')
+			if f.opts.Indent != "" {
+				f.buf.WriteByte(' ')
+			}
+			t = f.next()
+			members = append(members, member{key: key, start: memberStart})
+		}
+		if err := f.value(t, depth+1); err != nil {
+			return err
+		}
+		if sorted {
+			members[len(members)-1].end = f.buf.Len()
+			if max := f.opts.MaxObjectBuffer; max > 0 && f.buf.Len()-start > max {
+				return fmt.Errorf("Object at offset %d needs more than %d bytes to sort its keys", open.offset, max)
+			}
+		}
+
+		t = f.next()
+		if t.TokenType == closing {
+			break
+		}
+		if t.TokenType != tok.Comma {
+			return f.unexpected(t, what)
+		}
+		t = f.next()
+	}
+
+	if sorted {
+		f.sortMembers(start, members)
+	}
+	f.newline(depth)
+	f.buf.WriteString(t.Literal)
+	return nil
+}
+
+// rewrites the members of an object, written to buf from start on, sorted by key
+func (f *formatter) sortMembers(start int, members []member) {
+	sort.SliceStable(members, func(i, j int) bool { return members[i].key < members[j].key })
+	written := append([]byte(nil), f.buf.Bytes()[start:]...)
+	f.buf.Truncate(start)
+	for i, m := range members {
+		if i > 0 {
+			f.comma()
+		}
+		f.buf.Write(written[m.start-start : m.end-start])
+	}
+}
+
+// writes the comma between members or elements
+func (f *formatter) comma() {
+	f.buf.WriteByte(',')
+	if f.inline > 0 {
+		f.buf.WriteByte(' ')
+	}
+}
+
+// begins a line indented for depth, unless the output is compact
+// or the container being written is kept on one line
+func (f *formatter) newline(depth int) {
+	if f.opts.Indent == "" || f.inline > 0 {
+		return
+	}
+	f.buf.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		f.buf.WriteString(f.opts.Indent)
+	}
+}
+
+// reports whether the container whose opening bracket was just read fits on
+// one line, reading ahead at most as many tokens as it is allowed bytes
+// the tokens read are put back to be written
+func (f *formatter) fits() bool {
+	var read []token
+	width, depth := 1, 0
+	fits := false
+scan:
+	for width <= f.opts.MaxInlineWidth {
+		t := f.next()
+		read = append(read, t)
+		switch t.TokenType {
+		case tok.OpeningCurlyBrace, tok.OpeningSquareBracket:
+			depth++
+			width++
+		case tok.ClosingCurlyBrace, tok.ClosingSquareBracket:
+			width++
+			if depth == 0 {
+				fits = true
+				break scan
+			}
+			depth--
+		case tok.Comma, tok.Colon:
+			width += 2
+		case tok.String:
+			f.scratch.Reset()
+			encode.WriteString(&f.scratch, t.Literal)
+			width += f.scratch.Len()
+		case tok.EOF, tok.Invalid:
+			// left to be reported when written
+			break scan
+		default:
+			width += len(t.Literal)
+		}
+	}
+	f.queue = append(read, f.queue...)
+	return fits && width <= f.opts.MaxInlineWidth
+}
@@ -0,0 +1,190 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+func TestFormat(t *testing.T) {
+	doc := `{"name": "Times Square", "coordinates" : [-73.98, 40.75],
+		"tags":["a","b"], "empty": {}, "none": [ ], "nested": {"b": [1, {"y": 2, "x": 1}], "a": null}}`
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "compact",
+			opts: Options{},
+			want: `{"name":"Times Square","coordinates":[-73.98,40.75],"tags":["a","b"],"empty":{},"none":[],"nested":{"b":[1,{"y":2,"x":1}],"a":null}}`,
+		},
+		{
+			name: "indent",
+			opts: Options{Indent: "  "},
+			want: `{
+  "name": "Times Square",
+  "coordinates": [
+    -73.98,
+    40.75
+  ],
+  "tags": [
+    "a",
+    "b"
+  ],
+  "empty": {},
+  "none": [],
+  "nested": {
+    "b": [
+      1,
+      {
+        "y": 2,
+        "x": 1
+      }
+    ],
+    "a": null
+  }
+}`,
+		},
+		{
+			name: "inline",
+			opts: Options{Indent: "\t", MaxInlineWidth: 24},
+			want: "{\n" +
+				"\t\"name\": \"Times Square\",\n" +
+				"\t\"coordinates\": [-73.98, 40.75],\n" +
+				"\t\"tags\": [\"a\", \"b\"],\n" +
+				"\t\"empty\": {},\n" +
+				"\t\"none\": [],\n" +
+				"\t\"nested\": {\n" +
+				"\t\t\"b\": [1, {\"y\": 2, \"x\": 1}],\n" +
+				"\t\t\"a\": null\n" +
+				"\t}\n" +
+				"}",
+		},
+		{
+			name: "sorted",
+			opts: Options{SortKeys: true},
+			want: `{"coordinates":[-73.98,40.75],"empty":{},"name":"Times Square","nested":{"a":null,"b":[1,{"x":1,"y":2}]},"none":[],"tags":["a","b"]}`,
+		},
+		{
+			name: "sorted inline",
+			opts: Options{Indent: " ", MaxInlineWidth: 24, SortKeys: true},
+			want: "{\n" +
+				" \"coordinates\": [-73.98, 40.75],\n" +
+				" \"empty\": {},\n" +
+				" \"name\": \"Times Square\",\n" +
+				" \"nested\": {\n" +
+				"  \"a\": null,\n" +
+				"  \"b\": [1, {\"x\": 1, \"y\": 2}]\n" +
+				" },\n" +
+				" \"none\": [],\n" +
+				" \"tags\": [\"a\", \"b\"]\n" +
+				"}",
+		},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := Format(&out, strings.NewReader(doc), test.opts)
+
+		if err != nil || out.String() != test.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s\nerr: %v", test.name, out.String(), test.want, err)
+		}
+	}
+}
+
+// CRLF line endings are whitespace like any other
+func TestFormatCRLF(t *testing.T) {
+	doc := "{\r\n  \"a\": [1, 2]\r\n}\r\n"
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{opts: Options{}, want: `{"a":[1,2]}`},
+		{opts: Options{Indent: "  ", MaxInlineWidth: 12}, want: "{\n  \"a\": [1, 2]\n}"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := Format(&out, strings.NewReader(doc), test.opts)
+
+		if err != nil || out.String() != test.want {
+			t.Errorf("opts: %+v, got: %q, want: %q, err: %v", test.opts, out.String(), test.want, err)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		doc     string
+		opts    Options
+		wantErr string
+	}{
+		{doc: ``, wantErr: "Unexpected end of document at offset 0, expected value"},
+		{doc: `[1, 2`, wantErr: "Unexpected end of document at offset 5, expected comma or closing square bracket"},
+		{doc: `{"a" 1}`, wantErr: `Expected colon at offset 5, found: "1"`},
+		{doc: `{"a": 1,}`, wantErr: `Expected key at offset 8, found: "}"`},
+		{doc: `[1,]`, wantErr: `Expected value at offset 3, found: "]"`},
+		{doc: `{"a": 1]`, wantErr: `Expected comma or closing curly brace at offset 7, found: "]"`},
+		{doc: `[tru]`, wantErr: `Invalid token at offset 1: "tru]"`},
+		{doc: `1 2`, wantErr: `Expected end of document at offset 2, found: "2"`},
+		{doc: `[1, 2] [`, opts: Options{Indent: " ", MaxInlineWidth: 80}, wantErr: `Expected end of document at offset 7, found: "["`},
+		{doc: `[1, 01]`, opts: Options{Indent: " ", MaxInlineWidth: 80}, wantErr: `Invalid token at offset 4: "01"`},
+		{doc: `{"b": "xxxxxxxx", "a": 1}`, opts: Options{SortKeys: true, MaxObjectBuffer: 8}, wantErr: "Object at offset 0 needs more than 8 bytes to sort its keys"},
+	}
+
+	for _, test := range tests {
+		err := Format(ioutil.Discard, strings.NewReader(test.doc), test.opts)
+
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("doc: %q, got err: %v, want: %s", test.doc, err, test.wantErr)
+		}
+	}
+}
+
+// the formatted testdata parses to the same values, compact as encoding/json compacts it
+func TestFormatTestdata(t *testing.T) {
+	for _, f := range []string{"colors1.json", "colors2.json", "colors3.json", "gdp.json", "meteorites.json"} {
+		data, err := ioutil.ReadFile("../parse/testdata/" + f)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", f, err)
+		}
+		want, err := parse.Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", f, err)
+		}
+
+		for _, opts := range []Options{{}, {Indent: "  "}, {Indent: "  ", MaxInlineWidth: 60, SortKeys: true}} {
+			var out bytes.Buffer
+			if err := Format(&out, iotest.HalfReader(bytes.NewReader(data)), opts); err != nil {
+				t.Fatalf("%s: Format(%+v): %v", f, opts, err)
+			}
+			got, err := parse.Parse(bytes.NewReader(out.Bytes()))
+			if err != nil || !equal(got, want) {
+				t.Errorf("%s: Format(%+v) does not parse to the same value, err: %v", f, opts, err)
+			}
+		}
+
+		var compact, out bytes.Buffer
+		if err := json.Compact(&compact, data); err != nil {
+			t.Fatalf("json.Compact(%s): %v", f, err)
+		}
+		if err := Compact(&out, bytes.NewReader(data)); err != nil {
+			t.Fatalf("Compact(%s): %v", f, err)
+		}
+		if !bytes.Equal(out.Bytes(), compact.Bytes()) {
+			t.Errorf("%s: Compact() differs from json.Compact()", f)
+		}
+	}
+}
+
+func equal(v1, v2 interface{}) bool {
+	b1, _ := json.Marshal(v1)
+	b2, _ := json.Marshal(v2)
+	return bytes.Equal(b1, b2)
+}
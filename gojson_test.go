@@ -0,0 +1,323 @@
+package gojson_test
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vyevs/gojson"
+)
+
+type Base struct {
+	ID   int    `json:"id"`
+	Kind string `json:"kind"`
+}
+
+type Extra struct {
+	Kind string `json:"kind"`
+	Note string `json:"note"`
+}
+
+type embedding struct {
+	Base
+	*Extra
+	Name string `json:"name"`
+}
+
+type tagged struct {
+	A      int               `json:"a,omitempty"`
+	B      string            `json:"-"`
+	C      []int             `json:"c,omitempty"`
+	D      map[string]string `json:"d"`
+	E      *float64          `json:"e"`
+	F      [3]uint8          `json:"f"`
+	G      interface{}       `json:"g,omitempty"`
+	H      bool
+	hidden int
+}
+
+func TestUnmarshal(t *testing.T) {
+	f := 1.5
+	tests := []struct {
+		doc  string
+		into interface{}
+		want interface{}
+	}{
+		{doc: `"x"`, into: new(string), want: "x"},
+		{doc: `-12`, into: new(int16), want: int16(-12)},
+		{doc: `12`, into: new(float32), want: float32(12)},
+		{doc: `1.25e2`, into: new(float64), want: 125.0},
+		{doc: `true`, into: new(bool), want: true},
+		{doc: `[1, 2, 3]`, into: new([]int), want: []int{1, 2, 3}},
+		{doc: `[]`, into: new([]int), want: []int{}},
+		{doc: `null`, into: new([]int), want: []int(nil)},
+		{doc: `{"a": [1, "b", null]}`, into: new(interface{}), want: map[string]interface{}{"a": []interface{}{1, "b", nil}}},
+		{doc: `{"x": 1, "y": 2}`, into: new(map[string]int), want: map[string]int{"x": 1, "y": 2}},
+		{doc: `5`, into: new(*int), want: intPtr(5)},
+		{
+			doc:  `{"a": 1, "B": "no", "c": [7], "d": {"k": "v"}, "e": 1.5, "f": [1, 2, 3, 4], "g": [true], "H": true, "hidden": 3, "other": {}}`,
+			into: new(tagged),
+			want: tagged{A: 1, C: []int{7}, D: map[string]string{"k": "v"}, E: &f, F: [3]uint8{1, 2, 3}, G: []interface{}{true}, H: true},
+		},
+		{doc: `{"f": [9]}`, into: &tagged{F: [3]uint8{1, 2, 3}}, want: tagged{F: [3]uint8{9, 0, 0}}},
+		{doc: `{"a": null, "e": null}`, into: &tagged{A: 4, E: &f}, want: tagged{A: 4}},
+		{
+			// Kind is ambiguous between the embedded structs and is left out
+			doc:  `{"id": 1, "kind": "k", "Kind": "K", "note": "n", "name": "x"}`,
+			into: new(embedding),
+			want: embedding{Base: Base{ID: 1}, Extra: &Extra{Note: "n"}, Name: "x"},
+		},
+	}
+
+	for _, test := range tests {
+		err := gojson.Unmarshal([]byte(test.doc), test.into)
+
+		got := reflect.ValueOf(test.into).Elem().Interface()
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("doc: %s, got: %#v, want: %#v, err: %v", test.doc, got, test.want, err)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		into interface{}
+	}{
+		{doc: `1`, into: new(string)},
+		{doc: `1.5`, into: new(int)},
+		{doc: `256`, into: new(uint8)},
+		{doc: `-1`, into: new(uint)},
+		{doc: `"x"`, into: new(bool)},
+		{doc: `{}`, into: new([]int)},
+		{doc: `[]`, into: new(tagged)},
+		{doc: `{"a": 1}`, into: new(map[int]int)},
+		{doc: `[1,]`, into: new([]int)},
+		{doc: `[1] 2`, into: new([]int)},
+		{doc: ``, into: new(int)},
+		{doc: `1`, into: 1},
+		{doc: `1`, into: (*int)(nil)},
+		{doc: `1`, into: new(chan int)},
+	}
+
+	for _, test := range tests {
+		if err := gojson.Unmarshal([]byte(test.doc), test.into); err == nil {
+			t.Errorf("doc: %s, into: %T, expected error", test.doc, test.into)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	f := 2.0
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{v: nil, want: `null`},
+		{v: "a\"b", want: `"a\"b"`},
+		{v: uint16(7), want: `7`},
+		{v: 1.5, want: `1.5`},
+		{v: []string(nil), want: `null`},
+		{v: []string{}, want: `[]`},
+		{v: [2]bool{true}, want: `[true,false]`},
+		{v: map[string]int{"b": 1, "a": 2}, want: `{"a":2,"b":1}`},
+		{v: &f, want: `2.0`},
+		{v: tagged{}, want: `{"d":null,"e":null,"f":[0,0,0],"H":false}`},
+		{
+			v:    tagged{A: 1, B: "b", C: []int{1}, D: map[string]string{}, E: &f, G: 0, hidden: 1},
+			want: `{"a":1,"c":[1],"d":{},"e":2.0,"f":[0,0,0],"g":0,"H":false}`,
+		},
+		{v: embedding{Base: Base{ID: 1, Kind: "k"}, Name: "x"}, want: `{"id":1,"name":"x"}`},
+		{
+			v:    embedding{Base: Base{ID: 1}, Extra: &Extra{Kind: "K", Note: "n"}, Name: "x"},
+			want: `{"id":1,"note":"n","name":"x"}`,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := gojson.Marshal(test.v)
+
+		if err != nil || string(got) != test.want {
+			t.Errorf("v: %#v, got: %s, want: %s, err: %v", test.v, got, test.want, err)
+		}
+	}
+
+	if got, err := gojson.Marshal(map[int]int{1: 1}); err == nil {
+		t.Errorf("expected error marshaling map with int keys, got: %s", got)
+	}
+}
+
+// Money is encoded as a string holding a decimal amount
+type Money struct {
+	Cents int64
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	str, err := strconv.Unquote(string(data))
+	if err != nil {
+		return fmt.Errorf("money must be a string, got: %s", data)
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return err
+	}
+	m.Cents = int64(f*100 + 0.5)
+	return nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%d.%02d"`, m.Cents/100, m.Cents%100)), nil
+}
+
+// Level is encoded as its name
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+)
+
+var levelNames = []string{"debug", "info", "warn"}
+
+func (lv *Level) UnmarshalText(text []byte) error {
+	for i, name := range levelNames {
+		if name == string(text) {
+			*lv = Level(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level: %s", text)
+}
+
+func (lv Level) MarshalText() ([]byte, error) {
+	return []byte(levelNames[lv]), nil
+}
+
+// spaced returns its encoding with whitespace that Marshal removes
+type spaced struct{}
+
+func (spaced) MarshalJSON() ([]byte, error) {
+	return []byte(`{ "a" : [ 1 , "\u0062" ] }`), nil
+}
+
+type broken struct{}
+
+func (broken) MarshalJSON() ([]byte, error) {
+	return []byte(`{"a": }`), nil
+}
+
+type hooked struct {
+	Price   Money           `json:"price"`
+	Prices  []*Money        `json:"prices"`
+	Level   Level           `json:"level"`
+	Levels  map[Level]int   `json:"levels"`
+	At      time.Time       `json:"at"`
+	Raw     gojson.RawValue `json:"raw"`
+	Spaced  spaced          `json:"spaced"`
+	Missing *Money          `json:"missing"`
+}
+
+func TestHooks(t *testing.T) {
+	doc := `{
+		"price": "12.34",
+		"prices": ["1.5", null],
+		"level": "warn",
+		"levels": {"info": 2, "debug": 1},
+		"at": "2024-02-29T12:30:00Z",
+		"raw": {"keep" : [1.50, "\u00e9"]},
+		"spaced": {},
+		"missing": null
+	}`
+	var got hooked
+	if err := gojson.Unmarshal([]byte(doc), &got); err != nil {
+		t.Fatalf("Unmarshal(): %v", err)
+	}
+
+	want := hooked{
+		Price:  Money{Cents: 1234},
+		Prices: []*Money{{Cents: 150}, nil},
+		Level:  Warn,
+		Levels: map[Level]int{Info: 2, Debug: 1},
+		At:     time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC),
+		Raw:    gojson.RawValue(`{"keep" : [1.50, "\u00e9"]}`),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %#v, want: %#v", got, want)
+	}
+
+	out, err := gojson.Marshal(got)
+	wantOut := `{"price":"12.34","prices":["1.50",null],"level":"warn","levels":{"debug":1,"info":2},` +
+		`"at":"2024-02-29T12:30:00Z","raw":{"keep":[1.50,"é"]},"spaced":{"a":[1,"b"]},"missing":null}`
+	if err != nil || string(out) != wantOut {
+		t.Errorf("got: %s, want: %s, err: %v", out, wantOut, err)
+	}
+}
+
+func TestRawValue(t *testing.T) {
+	tests := []string{
+		`null`,
+		`  "a\"b\u0041"  `,
+		`-1.5e+3`,
+		`[ {"x": [ ] }, true ]`,
+		`{"long": "` + strings.Repeat("abc", 5000) + `"}`,
+	}
+
+	for _, test := range tests {
+		var raw gojson.RawValue
+		err := gojson.Unmarshal([]byte(test), &raw)
+
+		if want := strings.TrimSpace(test); err != nil || string(raw) != want {
+			t.Errorf("doc: %.40s, got: %.40s, want: %.40s, err: %v", test, raw, want, err)
+		}
+	}
+
+	// a RawValue decodes later into whatever it holds
+	var env struct {
+		Kind string          `json:"kind"`
+		Body gojson.RawValue `json:"body"`
+	}
+	if err := gojson.Unmarshal([]byte(`{"body": {"n": 3}, "kind": "count"}`), &env); err != nil {
+		t.Fatalf("Unmarshal(): %v", err)
+	}
+	var body struct {
+		N int `json:"n"`
+	}
+	if err := gojson.Unmarshal(env.Body, &body); err != nil || body.N != 3 {
+		t.Errorf("body: %s, got: %d, want: 3, err: %v", env.Body, body.N, err)
+	}
+}
+
+func TestHookErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		into interface{}
+	}{
+		{doc: `12.34`, into: new(Money)},
+		{doc: `"x"`, into: new(Money)},
+		{doc: `1`, into: new(Level)},
+		{doc: `"error"`, into: new(Level)},
+		{doc: `{"error": 1}`, into: new(map[Level]int)},
+		{doc: `[1, 2`, into: new(gojson.RawValue)},
+		{doc: `{"a": "12.34" "b"}`, into: new(map[string]Money)},
+	}
+
+	for _, test := range tests {
+		if err := gojson.Unmarshal([]byte(test.doc), test.into); err == nil {
+			t.Errorf("doc: %s, into: %T, expected error", test.doc, test.into)
+		}
+	}
+
+	for _, v := range []interface{}{broken{}, gojson.RawValue(`[1`), gojson.RawValue(`1 2`), map[Money]int{}} {
+		if got, err := gojson.Marshal(v); err == nil {
+			t.Errorf("v: %#v, got: %s, expected error", v, got)
+		}
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,376 @@
+// Package highlight colorizes JSON documents for terminals and web pages
+//
+// documents are rendered token by token, as they are written, with every
+// byte of the input kept, strings in the key position of an object are
+// told apart from string values so that they can be colored differently
+package highlight
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Mode is the kind of output rendered
+type Mode uint8
+
+// the Modes of output
+const (
+	// Plain is the input as is, e.g. for output that is not a terminal
+	Plain Mode = iota
+
+	// ANSI16, ANSI256 and TrueColor color the output with ANSI escape sequences,
+	// for terminals with 16 colors, 256 colors and 24-bit colors
+	ANSI16
+	ANSI256
+	TrueColor
+
+	// HTML wraps every token in a span with a CSS class, e.g.
+	// <span class="json-key">"id"</span>, the output is meant to be placed
+	// in a pre element, and styled with the rules CSS returns
+	HTML
+)
+
+// Class is the role of a token in a document
+type Class uint8
+
+// the Classes of tokens
+const (
+	Punctuation Class = iota
+	Key
+	String
+	Number
+	Bool
+	Null
+	Comment
+	Invalid
+)
+
+var classNames = map[Class]string{
+	Punctuation: "punct",
+	Key:         "key",
+	String:      "string",
+	Number:      "number",
+	Bool:        "bool",
+	Null:        "null",
+	Comment:     "comment",
+	Invalid:     "invalid",
+}
+
+// CSSClass returns the CSS class of the spans of tokens of Class c, e.g.: json-key
+func (c Class) CSSClass() string {
+	return "json-" + classNames[c]
+}
+
+// Color is a 24-bit color, the zero Color leaves the color of the output unchanged
+type Color uint32
+
+// RGB returns the Color with the given red, green and blue components
+func RGB(r, g, b uint8) Color {
+	return 1<<24 | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+func (c Color) rgb() (r, g, b int) {
+	return int(c >> 16 & 0xFF), int(c >> 8 & 0xFF), int(c & 0xFF)
+}
+
+// Style is how the tokens of a Class are rendered
+type Style struct {
+	Color Color
+	Bold  bool
+}
+
+// Theme is the Style of every Class
+// the colors are given as 24-bit colors and approximated for terminals
+// with fewer colors
+type Theme struct {
+	Punctuation Style
+	Key         Style
+	String      Style
+	Number      Style
+	Bool        Style
+	Null        Style
+	Comment     Style
+	Invalid     Style
+}
+
+func (t Theme) style(c Class) Style {
+	switch c {
+	case Key:
+		return t.Key
+	case String:
+		return t.String
+	case Number:
+		return t.Number
+	case Bool:
+		return t.Bool
+	case Null:
+		return t.Null
+	case Comment:
+		return t.Comment
+	case Invalid:
+		return t.Invalid
+	}
+	return t.Punctuation
+}
+
+// DefaultTheme uses colors of the standard 16, so that it looks the same in every Mode
+var DefaultTheme = Theme{
+	Key:     Style{Color: RGB(0x5c, 0x5c, 0xff), Bold: true},
+	String:  Style{Color: RGB(0x00, 0xcd, 0x00)},
+	Number:  Style{Color: RGB(0x00, 0xcd, 0xcd)},
+	Bool:    Style{Color: RGB(0xcd, 0xcd, 0x00)},
+	Null:    Style{Color: RGB(0x7f, 0x7f, 0x7f)},
+	Comment: Style{Color: RGB(0x7f, 0x7f, 0x7f)},
+	Invalid: Style{Color: RGB(0xff, 0x00, 0x00), Bold: true},
+}
+
+// Options configures Highlight
+type Options struct {
+	Mode  Mode
+	Theme Theme
+
+	// JSONC highlights the comments of JSONC documents
+	JSONC bool
+}
+
+// DetectMode returns the Mode for output to f: Plain unless f is a terminal,
+// and otherwise the Mode for the colors the terminal supports according to
+// the COLORTERM and TERM environment variables
+// NO_COLOR, https://no-color.org, turns colors off
+func DetectMode(f *os.File) Mode {
+	if os.Getenv("NO_COLOR") != "" {
+		return Plain
+	}
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return Plain
+	}
+	term := os.Getenv("TERM")
+	switch colorterm := os.Getenv("COLORTERM"); {
+	case term == "dumb":
+		return Plain
+	case colorterm == "truecolor" || colorterm == "24bit":
+		return TrueColor
+	case strings.Contains(term, "256color"):
+		return ANSI256
+	}
+	return ANSI16
+}
+
+// Highlight writes the document in r to w rendered as set in opts
+// the input is not validated, tokens that are not valid are rendered in the
+// Invalid Style and highlighting carries on after them
+func Highlight(w io.Writer, r io.Reader, opts Options) error {
+	l := lex.New(r)
+	if opts.JSONC {
+		l.SetMode(lex.JSONC | lex.KeepComments)
+	}
+	h := &highlighter{w: bufio.NewWriter(w), opts: opts}
+
+	// every token is captured along with the one before it,
+	// so that the whitespace between them is captured as well
+	var prevStart, prevEnd int64
+	l.StartCapture()
+	for {
+		t := l.ReadToken()
+		src := l.EndCapture()
+		start, end := l.TokenOffset(), l.InputOffset()
+		if t.TokenType == tok.EOF {
+			start = end
+		}
+
+		h.w.Write(src[prevEnd-prevStart : start-prevStart])
+		if t.TokenType == tok.EOF {
+			break
+		}
+		h.token(t.TokenType, src[start-prevStart:])
+
+		prevStart, prevEnd = start, end
+		l.StartCapture()
+	}
+	return h.w.Flush()
+}
+
+type highlighter struct {
+	w    *bufio.Writer
+	opts Options
+
+	objects   []bool // whether each container the token is in is an object
+	expectKey bool   // a string would be a key
+}
+
+// renders the token of type tt whose source is src
+func (h *highlighter) token(tt tok.TokenType, src []byte) {
+	class := Punctuation
+	switch tt {
+	case tok.OpeningCurlyBrace, tok.OpeningSquareBracket:
+		h.objects = append(h.objects, tt == tok.OpeningCurlyBrace)
+		h.expectKey = tt == tok.OpeningCurlyBrace
+	case tok.ClosingCurlyBrace, tok.ClosingSquareBracket:
+		if len(h.objects) > 0 {
+			h.objects = h.objects[:len(h.objects)-1]
+		}
+		h.expectKey = false
+	case tok.Comma:
+		h.expectKey = len(h.objects) > 0 && h.objects[len(h.objects)-1]
+	case tok.Colon:
+		h.expectKey = false
+	case tok.String:
+		class = String
+		if h.expectKey {
+			class = Key
+		}
+	case tok.Integer, tok.FloatingPoint:
+		class = Number
+	case tok.Boolean:
+		class = Bool
+	case tok.Null:
+		class = Null
+	case tok.Comment:
+		class = Comment
+	default:
+		class = Invalid
+	}
+	h.write(class, src)
+}
+
+func (h *highlighter) write(class Class, src []byte) {
+	if h.opts.Mode == Plain {
+		h.w.Write(src)
+		return
+	}
+	if h.opts.Mode == HTML {
+		fmt.Fprintf(h.w, `<span class="%s">`, class.CSSClass())
+		h.w.WriteString(html.EscapeString(string(src)))
+		h.w.WriteString("</span>")
+		return
+	}
+
+	style := h.opts.Theme.style(class)
+	seq := h.sgr(style)
+	if seq == "" {
+		h.w.Write(src)
+		return
+	}
+	h.w.WriteString(seq)
+	h.w.Write(src)
+	h.w.WriteString("\x1b[0m")
+}
+
+// the ANSI escape sequence that sets style, empty if it changes nothing
+func (h *highlighter) sgr(style Style) string {
+	var params []string
+	if style.Bold {
+		params = append(params, "1")
+	}
+	if style.Color != 0 {
+		switch h.opts.Mode {
+		case ANSI16:
+			params = append(params, ansi16(style.Color))
+		case ANSI256:
+			params = append(params, fmt.Sprintf("38;5;%d", ansi256(style.Color)))
+		case TrueColor:
+			r, g, b := style.Color.rgb()
+			params = append(params, fmt.Sprintf("38;2;%d;%d;%d", r, g, b))
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m"
+}
+
+// the colors of the standard 16, as xterm renders them
+var palette16 = [16]Color{
+	RGB(0x00, 0x00, 0x00), RGB(0xcd, 0x00, 0x00), RGB(0x00, 0xcd, 0x00), RGB(0xcd, 0xcd, 0x00),
+	RGB(0x00, 0x00, 0xee), RGB(0xcd, 0x00, 0xcd), RGB(0x00, 0xcd, 0xcd), RGB(0xe5, 0xe5, 0xe5),
+	RGB(0x7f, 0x7f, 0x7f), RGB(0xff, 0x00, 0x00), RGB(0x00, 0xff, 0x00), RGB(0xff, 0xff, 0x00),
+	RGB(0x5c, 0x5c, 0xff), RGB(0xff, 0x00, 0xff), RGB(0x00, 0xff, 0xff), RGB(0xff, 0xff, 0xff),
+}
+
+// the SGR parameter of the standard color closest to c
+func ansi16(c Color) string {
+	best := 0
+	for i := range palette16 {
+		if distance(c, palette16[i]) < distance(c, palette16[best]) {
+			best = i
+		}
+	}
+	if best < 8 {
+		return fmt.Sprint(30 + best)
+	}
+	return fmt.Sprint(90 + best - 8)
+}
+
+// the levels of the components of the 6x6x6 color cube of the 256 colors
+var cubeLevels = [6]int{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// the index of the color of the 256 closest to c, from the color cube or the grays
+func ansi256(c Color) int {
+	r, g, b := c.rgb()
+	ri, gi, bi := nearestLevel(r), nearestLevel(g), nearestLevel(b)
+	cube := RGB(uint8(cubeLevels[ri]), uint8(cubeLevels[gi]), uint8(cubeLevels[bi]))
+
+	grayIndex := ((r+g+b)/3 - 8 + 5) / 10
+	if grayIndex < 0 {
+		grayIndex = 0
+	} else if grayIndex > 23 {
+		grayIndex = 23
+	}
+	level := uint8(8 + 10*grayIndex)
+	if distance(c, RGB(level, level, level)) < distance(c, cube) {
+		return 232 + grayIndex
+	}
+	return 16 + 36*ri + 6*gi + bi
+}
+
+func nearestLevel(v int) int {
+	best := 0
+	for i, level := range cubeLevels {
+		if abs(v-level) < abs(v-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func distance(c1, c2 Color) int {
+	r1, g1, b1 := c1.rgb()
+	r2, g2, b2 := c2.rgb()
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// CSS returns the style sheet of theme for HTML output, a rule for the class of every Class
+func CSS(theme Theme) string {
+	var b strings.Builder
+	for c := Punctuation; c <= Invalid; c++ {
+		style := theme.style(c)
+		if style == (Style{}) {
+			continue
+		}
+		fmt.Fprintf(&b, ".%s {", c.CSSClass())
+		if style.Color != 0 {
+			r, g, bl := style.Color.rgb()
+			fmt.Fprintf(&b, " color: #%02x%02x%02x;", r, g, bl)
+		}
+		if style.Bold {
+			b.WriteString(" font-weight: bold;")
+		}
+		b.WriteString(" }\n")
+	}
+	return b.String()
+}
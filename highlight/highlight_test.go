@@ -0,0 +1,164 @@
+package highlight
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	theme := Theme{
+		Key:    Style{Color: RGB(0x00, 0x00, 0xee), Bold: true},
+		String: Style{Color: RGB(0x00, 0xcd, 0x00)},
+		Number: Style{Color: RGB(0x00, 0xcd, 0xcd)},
+		Bool:   Style{Color: RGB(0xcd, 0xcd, 0x00)},
+		Null:   Style{Color: RGB(0x7f, 0x7f, 0x7f)},
+	}
+
+	tests := []struct {
+		name string
+		doc  string
+		opts Options
+		want string
+	}{
+		{
+			name: "plain",
+			doc:  "{\n\t\"a\": [1, true, null]\n}\n",
+			opts: Options{Mode: Plain, Theme: theme},
+			want: "{\n\t\"a\": [1, true, null]\n}\n",
+		},
+		{
+			name: "crlf",
+			doc:  "{\r\n\t\"a\": 1\r\n}\r\n",
+			opts: Options{Mode: ANSI16, Theme: theme},
+			want: "{\r\n\t\x1b[1;34m\"a\"\x1b[0m: \x1b[36m1\x1b[0m\r\n}\r\n",
+		},
+		{
+			name: "ansi16",
+			doc:  ` {"a": "b", "c": [1.5, false, null]} `,
+			opts: Options{Mode: ANSI16, Theme: theme},
+			want: " {\x1b[1;34m\"a\"\x1b[0m: \x1b[32m\"b\"\x1b[0m, \x1b[1;34m\"c\"\x1b[0m: [\x1b[36m1.5\x1b[0m, " +
+				"\x1b[33mfalse\x1b[0m, \x1b[90mnull\x1b[0m]} ",
+		},
+		{
+			name: "strings in arrays are not keys",
+			doc:  `[{"k": ["v", {"k2": "v2"}], "k3": "v3"}, "s"]`,
+			opts: Options{Mode: ANSI16, Theme: Theme{Key: Style{Bold: true}}},
+			want: "[{\x1b[1m\"k\"\x1b[0m: [\"v\", {\x1b[1m\"k2\"\x1b[0m: \"v2\"}], \x1b[1m\"k3\"\x1b[0m: \"v3\"}, \"s\"]",
+		},
+		{
+			name: "ansi256",
+			doc:  `{"a": 1}`,
+			opts: Options{Mode: ANSI256, Theme: Theme{Key: Style{Color: RGB(0xff, 0x87, 0x00)}, Number: Style{Color: RGB(0x80, 0x80, 0x80)}}},
+			want: "{\x1b[38;5;208m\"a\"\x1b[0m: \x1b[38;5;244m1\x1b[0m}",
+		},
+		{
+			name: "truecolor",
+			doc:  `{"a": 1}`,
+			opts: Options{Mode: TrueColor, Theme: Theme{Key: Style{Color: RGB(0x12, 0x34, 0x56)}}},
+			want: "{\x1b[38;2;18;52;86m\"a\"\x1b[0m: 1}",
+		},
+		{
+			name: "html",
+			doc:  `{"<a>": "b & c"}`,
+			opts: Options{Mode: HTML},
+			want: `<span class="json-punct">{</span><span class="json-key">&#34;&lt;a&gt;&#34;</span>` +
+				`<span class="json-punct">:</span> <span class="json-string">&#34;b &amp; c&#34;</span>` +
+				`<span class="json-punct">}</span>`,
+		},
+		{
+			name: "escapes kept as written",
+			doc:  `["\u00e9\n"]`,
+			opts: Options{Mode: ANSI16, Theme: theme},
+			want: "[\x1b[32m\"\\u00e9\\n\"\x1b[0m]",
+		},
+		{
+			name: "jsonc",
+			doc:  "{\n\t// c\n\t\"a\": 1, /* d */\n}",
+			opts: Options{Mode: ANSI16, Theme: Theme{Comment: Style{Color: RGB(0x7f, 0x7f, 0x7f)}}, JSONC: true},
+			want: "{\n\t\x1b[90m// c\x1b[0m\n\t\"a\": 1, \x1b[90m/* d */\x1b[0m\n}",
+		},
+		{
+			name: "invalid",
+			doc:  `{"a": tru, "b": 1}`,
+			opts: Options{Mode: ANSI16, Theme: Theme{Invalid: Style{Bold: true}, Key: Style{Bold: true}}},
+			want: "{\x1b[1m\"a\"\x1b[0m: \x1b[1mtru, \x1b[0m\"b\": 1}",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := Highlight(&buf, strings.NewReader(test.doc), test.opts); err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: got: %q, want: %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHighlightPlainTestdata(t *testing.T) {
+	for _, name := range []string{"colors1.json", "gdp.json", "meteorites.json"} {
+		doc, err := ioutil.ReadFile("../parse/testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := Highlight(&buf, bytes.NewReader(doc), Options{Theme: DefaultTheme}); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		} else if !bytes.Equal(buf.Bytes(), doc) {
+			t.Errorf("%s: plain output differs from the input", name)
+		}
+	}
+}
+
+func TestColors(t *testing.T) {
+	tests := []struct {
+		c       Color
+		ansi16  string
+		ansi256 int
+	}{
+		{RGB(0, 0, 0), "30", 16},
+		{RGB(0xff, 0xff, 0xff), "97", 231},
+		{RGB(0xcd, 0x00, 0x00), "31", 160},
+		{RGB(0xf0, 0x10, 0x10), "91", 196},
+		{RGB(0x5f, 0x87, 0xaf), "90", 67},
+		{RGB(0x44, 0x44, 0x44), "90", 238},
+	}
+
+	for _, test := range tests {
+		if got := ansi16(test.c); got != test.ansi16 {
+			t.Errorf("ansi16(%06x): got: %s, want: %s", test.c&0xFFFFFF, got, test.ansi16)
+		}
+		if got := ansi256(test.c); got != test.ansi256 {
+			t.Errorf("ansi256(%06x): got: %d, want: %d", test.c&0xFFFFFF, got, test.ansi256)
+		}
+	}
+}
+
+func TestCSS(t *testing.T) {
+	theme := Theme{
+		Key:  Style{Color: RGB(0x00, 0x00, 0xee), Bold: true},
+		Null: Style{Color: RGB(0x7f, 0x7f, 0x7f)},
+	}
+	want := ".json-key { color: #0000ee; font-weight: bold; }\n.json-null { color: #7f7f7f; }\n"
+	if got := CSS(theme); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestDetectMode(t *testing.T) {
+	f, err := ioutil.TempFile("", "highlight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if got := DetectMode(f); got != Plain {
+		t.Errorf("regular file: got: %d, want: %d", got, Plain)
+	}
+}
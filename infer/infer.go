@@ -0,0 +1,309 @@
+package infer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/schema"
+)
+
+// the meta-schema the inferred schemas are written against
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// the string formats that are recognized, in order of preference
+// when every string observed at a location matches more than one
+var stringFormats = []string{"date-time", "date", "uuid", "email"}
+
+// strings holding decimal numbers, such as "21" or "-33.166670"
+var numericString = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Shape summarizes every value observed at one location of the samples
+//
+// values of different types may be observed at the same location,
+// each type keeps its own counts, so the shape of a location that held
+// both strings and objects describes both
+type Shape struct {
+	Count int // every value observed, including nulls
+
+	Nulls    int
+	Booleans int
+
+	Integers int
+	Floats   int     // numbers with a fraction or an exponent
+	Min, Max float64 // range of all numbers observed
+
+	Strings        int
+	NumericStrings int            // strings that hold a decimal number
+	Formats        map[string]int // format name -> strings that matched it
+
+	Objects    int
+	Properties map[string]*Shape // a property is present in Properties[key].Count objects
+
+	Arrays             int
+	Items              *Shape // the elements of every array merged together, nil if all were empty
+	MinItems, MaxItems int
+}
+
+// Inferencer merges the shapes of sample documents
+// into a description of all of them
+type Inferencer struct {
+	root    *Shape
+	samples int
+}
+
+// New returns an Inferencer that has not seen any samples
+func New() *Inferencer {
+	return &Inferencer{root: &Shape{}}
+}
+
+// Add merges a parsed document into the observed shape
+// doc must be made up of the types produced by parse.Parse
+func (inf *Inferencer) Add(doc interface{}) error {
+	if err := inf.root.add(doc); err != nil {
+		return err
+	}
+	inf.samples++
+	return nil
+}
+
+// AddNDJSON adds every document of a newline delimited stream
+// blank lines are skipped, errors report the line they occurred on
+func (inf *Inferencer) AddNDJSON(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(bytes.TrimSpace(b)) != 0 {
+			doc, perr := parse.Parse(bytes.NewReader(b))
+			if perr != nil {
+				return fmt.Errorf("Line %d: %v", line, perr)
+			}
+			if aerr := inf.Add(doc); aerr != nil {
+				return fmt.Errorf("Line %d: %v", line, aerr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// Samples returns the number of documents added so far
+func (inf *Inferencer) Samples() int {
+	return inf.samples
+}
+
+// Shape returns the merged shape of all documents added so far
+// the returned Shape is updated by later calls to Add
+func (inf *Inferencer) Shape() *Shape {
+	return inf.root
+}
+
+// Schema returns a JSON Schema (draft 2020-12) document
+// that every document added so far is valid against
+func (inf *Inferencer) Schema() map[string]interface{} {
+	s := inf.root.Schema()
+	s["$schema"] = draft
+	return s
+}
+
+func (s *Shape) add(v interface{}) error {
+	s.Count++
+	switch v := v.(type) {
+	case nil:
+		s.Nulls++
+	case bool:
+		s.Booleans++
+	case int:
+		s.addNumber(float64(v), false)
+	case int64:
+		s.addNumber(float64(v), false)
+	case float64:
+		s.addNumber(v, true)
+	case string:
+		s.addString(v)
+	case map[string]interface{}:
+		s.Objects++
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Shape, len(v))
+		}
+		for k, pv := range v {
+			ps, ok := s.Properties[k]
+			if !ok {
+				ps = &Shape{}
+				s.Properties[k] = ps
+			}
+			if err := ps.add(pv); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.Arrays == 0 || len(v) < s.MinItems {
+			s.MinItems = len(v)
+		}
+		if s.Arrays == 0 || len(v) > s.MaxItems {
+			s.MaxItems = len(v)
+		}
+		s.Arrays++
+		for _, e := range v {
+			if s.Items == nil {
+				s.Items = &Shape{}
+			}
+			if err := s.Items.add(e); err != nil {
+				return err
+			}
+		}
+	default:
+		s.Count--
+		return fmt.Errorf("Unsupported value type: %T", v)
+	}
+	return nil
+}
+
+func (s *Shape) addNumber(f float64, float bool) {
+	if s.Integers+s.Floats == 0 || f < s.Min {
+		s.Min = f
+	}
+	if s.Integers+s.Floats == 0 || f > s.Max {
+		s.Max = f
+	}
+	if float {
+		s.Floats++
+	} else {
+		s.Integers++
+	}
+}
+
+func (s *Shape) addString(str string) {
+	s.Strings++
+	if numericString.MatchString(str) {
+		s.NumericStrings++
+	}
+	for _, f := range stringFormats {
+		if valid, _ := schema.CheckFormat(f, str); valid {
+			if s.Formats == nil {
+				s.Formats = make(map[string]int)
+			}
+			s.Formats[f]++
+		}
+	}
+}
+
+// Required returns the sorted keys of the properties
+// that were present in every object observed
+func (s *Shape) Required() []string {
+	var keys []string
+	for k, ps := range s.Properties {
+		if ps.Count == s.Objects {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Types returns the JSON Schema type names of the values observed,
+// integers and floats observed together are reported as "number"
+func (s *Shape) Types() []string {
+	var types []string
+	if s.Nulls > 0 {
+		types = append(types, "null")
+	}
+	if s.Booleans > 0 {
+		types = append(types, "boolean")
+	}
+	if s.Floats > 0 {
+		types = append(types, "number")
+	} else if s.Integers > 0 {
+		types = append(types, "integer")
+	}
+	if s.Strings > 0 {
+		types = append(types, "string")
+	}
+	if s.Objects > 0 {
+		types = append(types, "object")
+	}
+	if s.Arrays > 0 {
+		types = append(types, "array")
+	}
+	return types
+}
+
+// Format returns the format every string observed matched, if any
+func (s *Shape) Format() string {
+	for _, f := range stringFormats {
+		if s.Strings > 0 && s.Formats[f] == s.Strings {
+			return f
+		}
+	}
+	return ""
+}
+
+// Schema returns a JSON Schema describing the values observed
+// a shape that never observed a value yields the empty schema
+func (s *Shape) Schema() map[string]interface{} {
+	out := map[string]interface{}{}
+
+	types := s.Types()
+	switch len(types) {
+	case 0:
+		return out
+	case 1:
+		out["type"] = types[0]
+	default:
+		ts := make([]interface{}, len(types))
+		for i, t := range types {
+			ts[i] = t
+		}
+		out["type"] = ts
+	}
+
+	if s.Integers+s.Floats > 0 {
+		out["minimum"] = schemaNumber(s.Min)
+		out["maximum"] = schemaNumber(s.Max)
+	}
+
+	if f := s.Format(); f != "" {
+		out["format"] = f
+	} else if s.Strings > 0 && s.NumericStrings == s.Strings {
+		out["pattern"] = numericString.String()
+		out["$comment"] = "all " + strconv.Itoa(s.Strings) + " strings observed hold numbers"
+	}
+
+	if s.Objects > 0 {
+		props := make(map[string]interface{}, len(s.Properties))
+		for k, ps := range s.Properties {
+			props[k] = ps.Schema()
+		}
+		out["properties"] = props
+		if required := s.Required(); len(required) > 0 {
+			req := make([]interface{}, len(required))
+			for i, k := range required {
+				req[i] = k
+			}
+			out["required"] = req
+		}
+	}
+
+	if s.Arrays > 0 && s.Items != nil {
+		out["items"] = s.Items.Schema()
+	}
+	return out
+}
+
+// integral bounds are written as integers, like the samples they came from
+func schemaNumber(f float64) interface{} {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int(f)
+	}
+	return f
+}
@@ -0,0 +1,171 @@
+package infer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/patch"
+	"github.com/vyevs/gojson/schema"
+)
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		ndjson string
+		want   string
+	}{
+		{
+			ndjson: `{"id": 1, "name": "a"}
+{"id": 7, "name": "b", "score": 1.5}
+{"id": 3, "name": null, "score": 2}`,
+			want: `{
+				"type": "object",
+				"properties": {
+					"id": {"type": "integer", "minimum": 1, "maximum": 7},
+					"name": {"type": ["null", "string"]},
+					"score": {"type": "number", "minimum": 1.5, "maximum": 2}
+				},
+				"required": ["id", "name"]
+			}`,
+		},
+		{
+			ndjson: `{"at": "2019-05-07T10:00:00Z", "day": "2019-05-07", "id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "to": "a@example.com"}
+
+{"at": "2019-05-08T11:30:00.5+02:00", "day": "2020-01-31", "id": "6ba7b811-9dad-11d1-80b4-00c04fd430c8", "to": "b@example.org"}`,
+			want: `{
+				"type": "object",
+				"properties": {
+					"at": {"type": "string", "format": "date-time"},
+					"day": {"type": "string", "format": "date"},
+					"id": {"type": "string", "format": "uuid"},
+					"to": {"type": "string", "format": "email"}
+				},
+				"required": ["at", "day", "id", "to"]
+			}`,
+		},
+		{
+			ndjson: `{"at": "2019-05-07T10:00:00Z", "mass": "21"}
+{"at": "yesterday", "mass": "-33.5"}`,
+			want: `{
+				"type": "object",
+				"properties": {
+					"at": {"type": "string"},
+					"mass": {"type": "string", "pattern": "^-?[0-9]+(\\.[0-9]+)?$", "$comment": "all 2 strings observed hold numbers"}
+				},
+				"required": ["at", "mass"]
+			}`,
+		},
+		{
+			ndjson: `[[1, 2], []]
+[[true], {"a": [1, "x"]}]`,
+			want: `{
+				"type": "array",
+				"items": {
+					"type": ["object", "array"],
+					"properties": {"a": {"type": "array", "items": {"type": ["integer", "string"], "minimum": 1, "maximum": 1}}},
+					"required": ["a"],
+					"items": {"type": ["boolean", "integer"], "minimum": 1, "maximum": 2}
+				}
+			}`,
+		},
+		{
+			ndjson: `[]
+null`,
+			want: `{"type": ["null", "array"]}`,
+		},
+		{ndjson: ``, want: `{}`},
+	}
+
+	for _, test := range tests {
+		inf := New()
+		if err := inf.AddNDJSON(strings.NewReader(test.ndjson)); err != nil {
+			t.Errorf("ndjson: %s, AddNDJSON(): %v", test.ndjson, err)
+			continue
+		}
+
+		got := inf.Schema()
+
+		want, err := parse.Parse(strings.NewReader(test.want))
+		if err != nil {
+			t.Fatalf("want: %s, Parse(): %v", test.want, err)
+		}
+		want.(map[string]interface{})["$schema"] = draft
+		if !patch.Equal(got, want) {
+			gotStr, _ := encode.Marshal(got)
+			t.Errorf("ndjson: %s, got: %s, want: %s", test.ndjson, gotStr, test.want)
+		}
+	}
+}
+
+func TestAddNDJSONError(t *testing.T) {
+	inf := New()
+	err := inf.AddNDJSON(strings.NewReader("{\"a\": 1}\n{\"a\": }\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "Line 2:") {
+		t.Errorf("got: %v, want error on line 2", err)
+	}
+	if inf.Samples() != 1 {
+		t.Errorf("samples: %d, want: 1", inf.Samples())
+	}
+}
+
+// every record of the meteorites data holds its numbers as strings
+func TestInferMeteorites(t *testing.T) {
+	f, err := os.Open("../parse/testdata/meteorites.json")
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	defer f.Close()
+	doc, err := parse.Parse(f)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+
+	inf := New()
+	records := doc.([]interface{})
+	for _, r := range records {
+		if err := inf.Add(r); err != nil {
+			t.Fatalf("Add(): %v", err)
+		}
+	}
+
+	props := inf.Schema()["properties"].(map[string]interface{})
+	for _, k := range []string{"id", "mass", "reclat", "reclong"} {
+		p := props[k].(map[string]interface{})
+		if p["pattern"] != numericString.String() || p["$comment"] == nil {
+			t.Errorf("property: %s, got: %v, want a numeric string pattern and comment", k, p)
+		}
+	}
+	if got := props["mass"].(map[string]interface{})["type"]; got != "string" {
+		t.Errorf("mass type: %v, want: string", got)
+	}
+	coords := props["geolocation"].(map[string]interface{})["properties"].(map[string]interface{})["coordinates"]
+	if got := coords.(map[string]interface{})["items"].(map[string]interface{})["type"]; got != "number" {
+		t.Errorf("coordinates items type: %v, want: number", got)
+	}
+
+	want := []string{"fall", "id", "name", "nametype", "recclass"}
+	if got := inf.Shape().Required(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("required: %v, want: %v", got, want)
+	}
+
+	// the inferred schema accepts every sample it was inferred from
+	s, err := schema.Compile(strings.NewReader(mustEncode(t, inf.Schema())))
+	if err != nil {
+		t.Fatalf("Compile(): %v", err)
+	}
+	for i, r := range records {
+		if err := s.Validate(r); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+	}
+}
+
+func mustEncode(t *testing.T, v interface{}) string {
+	b, err := encode.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal(): %v", err)
+	}
+	return string(b)
+}
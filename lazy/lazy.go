@@ -0,0 +1,293 @@
+// Package lazy parses JSON documents on demand
+//
+// Parse only validates a document and records where each value lies in it,
+// strings, numbers and containers are decoded when they are first read
+// through a Value, which makes reading a few fields of a large document cheap
+package lazy
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// Kind is the type of a JSON value
+type Kind uint8
+
+// the kinds of JSON values, numbers with a fraction or an exponent are Floats
+const (
+	Null Kind = iota
+	Bool
+	Integer
+	Float
+	String
+	Array
+	Object
+)
+
+var kindNames = [...]string{"null", "boolean", "integer", "float", "string", "array", "object"}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// node records where a value lies in the document
+type node struct {
+	kind    Kind
+	escaped bool // strings containing escape sequences need decoding
+	len     int  // elements of an array, members of an object
+	start   int  // offset of the first byte of the value
+	end     int  // offset just past the value
+	next    int  // index of the node following the value and everything in it
+}
+
+// Document is a lazily parsed JSON document
+//
+// the nodes of a document are its values in the order they appear,
+// each object member recorded as its key followed by its value
+// decoded values are cached, so a Document must not be used
+// from several goroutines at once
+type Document struct {
+	data    []byte
+	nodes   []node
+	decoded map[int]interface{} // by node index
+}
+
+// Parse validates the JSON document in data and indexes its values
+//
+// Parse accepts the documents parse.Parse accepts, except that objects with
+// duplicate keys are not rejected, Get finds the first of them
+// data is kept by the Document and must not be modified
+func Parse(data []byte) (*Document, error) {
+	x := indexer{nodes: make([]node, 0, len(data)/8+1)}
+	if err := lex.Scan(data, &x); err != nil {
+		return nil, err
+	}
+	return &Document{data: data, nodes: x.nodes}, nil
+}
+
+// Root returns the value of the whole document
+func (d *Document) Root() Value {
+	return Value{doc: d}
+}
+
+// Value is a value within a Document
+type Value struct {
+	doc *Document
+	i   int // index of the value's node
+}
+
+func (v Value) node() *node {
+	return &v.doc.nodes[v.i]
+}
+
+// Kind returns the type of the value
+func (v Value) Kind() Kind {
+	return v.node().kind
+}
+
+// Raw returns the source of the value exactly as it appears in the document,
+// without copying it, the bytes must not be modified
+func (v Value) Raw() []byte {
+	n := v.node()
+	return v.doc.data[n.start:n.end:n.end]
+}
+
+// Offset returns the offset of the first byte of the value within the document
+func (v Value) Offset() int {
+	return v.node().start
+}
+
+// Len returns the number of elements of an array or members of an object,
+// and 0 for other values
+func (v Value) Len() int {
+	return v.node().len
+}
+
+// Index returns the i-th element of an array
+func (v Value) Index(i int) (Value, error) {
+	n := v.node()
+	if n.kind != Array {
+		return Value{}, fmt.Errorf("Expected array, found: %s", n.kind)
+	}
+	if i < 0 || i >= n.len {
+		return Value{}, fmt.Errorf("Index %d out of range for array of length %d", i, n.len)
+	}
+	e := v.i + 1
+	for ; i > 0; i-- {
+		e = v.doc.nodes[e].next
+	}
+	return Value{doc: v.doc, i: e}, nil
+}
+
+// Elements returns the elements of an array
+func (v Value) Elements() ([]Value, error) {
+	n := v.node()
+	if n.kind != Array {
+		return nil, fmt.Errorf("Expected array, found: %s", n.kind)
+	}
+	out := make([]Value, 0, n.len)
+	for e := v.i + 1; e < n.next; e = v.doc.nodes[e].next {
+		out = append(out, Value{doc: v.doc, i: e})
+	}
+	return out, nil
+}
+
+// Get returns the value of the member of an object with the given key,
+// reporting false if there is none
+// keys are compared without decoding them unless they hold escape sequences
+func (v Value) Get(key string) (Value, bool) {
+	n := v.node()
+	if n.kind != Object {
+		return Value{}, false
+	}
+	for k := v.i + 1; k < n.next; k = v.doc.nodes[k+1].next {
+		if v.keyEquals(k, key) {
+			return Value{doc: v.doc, i: k + 1}, true
+		}
+	}
+	return Value{}, false
+}
+
+func (v Value) keyEquals(k int, key string) bool {
+	n := &v.doc.nodes[k]
+	if !n.escaped {
+		return string(v.doc.data[n.start+1:n.end-1]) == key
+	}
+	return Value{doc: v.doc, i: k}.decodeString() == key
+}
+
+// Keys returns the keys of an object in the order they appear
+func (v Value) Keys() ([]string, error) {
+	n := v.node()
+	if n.kind != Object {
+		return nil, fmt.Errorf("Expected object, found: %s", n.kind)
+	}
+	out := make([]string, 0, n.len)
+	for k := v.i + 1; k < n.next; k = v.doc.nodes[k+1].next {
+		out = append(out, Value{doc: v.doc, i: k}.decodeString())
+	}
+	return out, nil
+}
+
+// At returns the value that p refers to within v
+func (v Value) At(p pointer.Pointer) (Value, error) {
+	for i, token := range p {
+		var ok bool
+		switch v.Kind() {
+		case Object:
+			v, ok = v.Get(token)
+		case Array:
+			idx, err := pointer.Index(token, v.Len())
+			if ok = err == nil && idx < v.Len(); ok {
+				v, _ = v.Index(idx)
+			}
+		}
+		if !ok {
+			return Value{}, fmt.Errorf("No value found at %q", p[:i+1].String())
+		}
+	}
+	return v, nil
+}
+
+// String returns the value of a string
+func (v Value) String() (string, error) {
+	if k := v.Kind(); k != String {
+		return "", fmt.Errorf("Expected string, found: %s", k)
+	}
+	if s, ok := v.doc.decoded[v.i]; ok {
+		return s.(string), nil
+	}
+	s := v.decodeString()
+	v.cache(s)
+	return s, nil
+}
+
+// the value of the string at v, which has been validated already
+func (v Value) decodeString() string {
+	n := v.node()
+	if !n.escaped {
+		return string(v.doc.data[n.start+1 : n.end-1])
+	}
+	str, _ := lex.AppendUnescaped(nil, v.doc.data[n.start+1:n.end-1])
+	return string(str)
+}
+
+// Int returns the value of an integer that fits in an int
+func (v Value) Int() (int, error) {
+	if k := v.Kind(); k != Integer {
+		return 0, fmt.Errorf("Expected integer, found: %s", k)
+	}
+	i, err := strconv.Atoi(string(v.Raw()))
+	if err != nil {
+		return 0, fmt.Errorf("Integer %s out of range", v.Raw())
+	}
+	return i, nil
+}
+
+// Float returns the value of any number, rounded to the nearest float64
+func (v Value) Float() (float64, error) {
+	if k := v.Kind(); k != Integer && k != Float {
+		return 0, fmt.Errorf("Expected number, found: %s", k)
+	}
+	f, err := strconv.ParseFloat(string(v.Raw()), 64)
+	if err != nil {
+		return 0, fmt.Errorf("Number %s out of range", v.Raw())
+	}
+	return f, nil
+}
+
+// Bool returns the value of a boolean
+func (v Value) Bool() (bool, error) {
+	if k := v.Kind(); k != Bool {
+		return false, fmt.Errorf("Expected boolean, found: %s", k)
+	}
+	return v.doc.data[v.node().start] == 't', nil
+}
+
+// IsNull reports whether the value is null
+func (v Value) IsNull() bool {
+	return v.Kind() == Null
+}
+
+// Interface decodes the value into the form parse.Parse returns
+// decoded strings, objects and arrays are cached and shared by every call,
+// so they must not be modified
+func (v Value) Interface() (interface{}, error) {
+	switch v.Kind() {
+	case Null:
+		return nil, nil
+	case Bool:
+		return v.Bool()
+	case Integer:
+		if i, err := v.Int(); err == nil {
+			return i, nil
+		}
+		// too big for an int, a float64 as parse.Parse returns it
+		return v.Float()
+	case Float:
+		return v.Float()
+	case String:
+		return v.String()
+	}
+	if x, ok := v.doc.decoded[v.i]; ok {
+		return x, nil
+	}
+	x, err := parse.Parse(bytes.NewReader(v.Raw()))
+	if err != nil {
+		return nil, err
+	}
+	v.cache(x)
+	return x, nil
+}
+
+func (v Value) cache(x interface{}) {
+	if v.doc.decoded == nil {
+		v.doc.decoded = map[int]interface{}{}
+	}
+	v.doc.decoded[v.i] = x
+}
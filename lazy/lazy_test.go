@@ -0,0 +1,235 @@
+package lazy
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/pointer"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		doc     string
+		wantErr bool
+	}{
+		{doc: `{}`},
+		{doc: ` [ ] `},
+		{doc: `{"a": [1, -2.5e3, "x\"y", true, false, null, {"b": {}}]}`},
+		{doc: `"é\n"`},
+		{doc: `-0`},
+		{doc: `0.5E-2`},
+		{doc: "{\r\n\t\"a\": 1\r\n}\r\n"},
+		{doc: ``, wantErr: true},
+		{doc: `{"a" 1}`, wantErr: true},
+		{doc: `{"a": 1,}`, wantErr: true},
+		{doc: `[1 2]`, wantErr: true},
+		{doc: `[1,]`, wantErr: true},
+		{doc: `{a: 1}`, wantErr: true},
+		{doc: `"abc`, wantErr: true},
+		{doc: `"\x"`, wantErr: true},
+		{doc: `"\u12g4"`, wantErr: true},
+		{doc: `01`, wantErr: true},
+		{doc: `1.`, wantErr: true},
+		{doc: `1e+`, wantErr: true},
+		{doc: `-`, wantErr: true},
+		{doc: `tru`, wantErr: true},
+		{doc: `nulls`, wantErr: true},
+		{doc: `[1] [2]`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		_, err := Parse([]byte(test.doc))
+
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("doc: %q, err: %v, wantErr: %v", test.doc, err, test.wantErr)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	doc, err := Parse([]byte(`{
+		"name": "meteor",
+		"esc\"aped": "café",
+		"mass": 21,
+		"reclat": 50.775,
+		"fell": true,
+		"note": null,
+		"geo": {"type": "Point", "coordinates": [6.08333, 50.775]},
+		"tags": ["a", ["b"], {}]
+	}`))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+	root := doc.Root()
+
+	if root.Kind() != Object || root.Len() != 8 {
+		t.Errorf("root kind: %s, len: %d, want: object, 8", root.Kind(), root.Len())
+	}
+	keys, err := root.Keys()
+	wantKeys := []string{"name", `esc"aped`, "mass", "reclat", "fell", "note", "geo", "tags"}
+	if err != nil || !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("keys: %q, want: %q, err: %v", keys, wantKeys, err)
+	}
+
+	name, _ := root.Get("name")
+	if s, err := name.String(); err != nil || s != "meteor" {
+		t.Errorf("name: %q, err: %v", s, err)
+	}
+	escaped, ok := root.Get(`esc"aped`)
+	if s, err := escaped.String(); !ok || err != nil || s != "café" {
+		t.Errorf("escaped: %q, found: %v, err: %v", s, ok, err)
+	}
+	mass, _ := root.Get("mass")
+	if i, err := mass.Int(); err != nil || i != 21 {
+		t.Errorf("mass: %d, err: %v", i, err)
+	}
+	reclat, _ := root.Get("reclat")
+	if f, err := reclat.Float(); err != nil || f != 50.775 {
+		t.Errorf("reclat: %v, err: %v", f, err)
+	}
+	fell, _ := root.Get("fell")
+	if b, err := fell.Bool(); err != nil || !b {
+		t.Errorf("fell: %v, err: %v", b, err)
+	}
+	if note, _ := root.Get("note"); !note.IsNull() {
+		t.Errorf("note kind: %s, want: null", note.Kind())
+	}
+	if _, ok := root.Get("missing"); ok {
+		t.Errorf("found missing key")
+	}
+
+	geo, _ := root.Get("geo")
+	if raw := string(geo.Raw()); raw != `{"type": "Point", "coordinates": [6.08333, 50.775]}` {
+		t.Errorf("geo raw: %s", raw)
+	}
+	lng, err := geo.At(pointer.MustParse("/coordinates/0"))
+	if f, ferr := lng.Float(); err != nil || ferr != nil || f != 6.08333 {
+		t.Errorf("coordinates/0: %v, err: %v, %v", f, err, ferr)
+	}
+
+	tags, _ := root.Get("tags")
+	elems, err := tags.Elements()
+	if err != nil || len(elems) != 3 || elems[1].Kind() != Array || elems[2].Kind() != Object {
+		t.Errorf("tags elements: %v, err: %v", elems, err)
+	}
+	last, err := tags.Index(2)
+	if err != nil || string(last.Raw()) != "{}" {
+		t.Errorf("tags[2]: %s, err: %v", last.Raw(), err)
+	}
+
+	got, err := geo.Interface()
+	want := map[string]interface{}{"type": "Point", "coordinates": []interface{}{6.08333, 50.775}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("geo: %v, want: %v, err: %v", got, want, err)
+	}
+}
+
+func TestValueErrors(t *testing.T) {
+	doc, err := Parse([]byte(`{"a": [1], "big": 123456789012345678901234567890}`))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+	root := doc.Root()
+	a, _ := root.Get("a")
+	big, _ := root.Get("big")
+
+	if _, err := root.String(); err == nil {
+		t.Errorf("expected error reading object as string")
+	}
+	if _, err := a.Index(1); err == nil {
+		t.Errorf("expected error indexing past the end of the array")
+	}
+	if _, err := root.Index(0); err == nil {
+		t.Errorf("expected error indexing an object")
+	}
+	if _, err := a.Keys(); err == nil {
+		t.Errorf("expected error reading keys of an array")
+	}
+	if _, err := big.Int(); err == nil {
+		t.Errorf("expected error reading integer out of range")
+	}
+	if _, err := root.At(pointer.MustParse("/a/1")); err == nil {
+		t.Errorf("expected error for pointer past the end of the array")
+	}
+	if _, err := root.At(pointer.MustParse("/a/0/b")); err == nil {
+		t.Errorf("expected error for pointer into a number")
+	}
+}
+
+// every document in testdata decodes to what parse.Parse returns
+func TestInterfaceMatchesParse(t *testing.T) {
+	files := []string{"colors1.json", "colors2.json", "colors3.json", "gdp.json", "meteorites.json"}
+	for _, f := range files {
+		data, err := ioutil.ReadFile("../parse/testdata/" + f)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", f, err)
+		}
+		doc, err := Parse(data)
+		if err != nil {
+			t.Errorf("%s: Parse(): %v", f, err)
+			continue
+		}
+		got, err := doc.Root().Interface()
+		want, werr := parse.Parse(bytes.NewReader(data))
+		if err != nil || werr != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: lazy and parse.Parse differ, err: %v, %v", f, err, werr)
+		}
+	}
+}
+
+// integers beyond int are floats, as they are to parse.Parse, alone or in an array
+func TestInterfaceBigInteger(t *testing.T) {
+	for _, str := range []string{`18446744073709551616`, `[18446744073709551616]`} {
+		doc, err := Parse([]byte(str))
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", str, err)
+		}
+		got, err := doc.Root().Interface()
+		want, werr := parse.Parse(bytes.NewReader([]byte(str)))
+		if err != nil || werr != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("doc: %s, got: %v, want: %v, err: %v, %v", str, got, want, err, werr)
+		}
+	}
+}
+
+// reading two fields of every record
+func BenchmarkMeteorites(b *testing.B) {
+	data, err := ioutil.ReadFile("../parse/testdata/meteorites.json")
+	if err != nil {
+		b.Fatalf("ReadFile(): %v", err)
+	}
+
+	b.Run("lazy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			doc, err := Parse(data)
+			if err != nil {
+				b.Fatalf("Parse(): %v", err)
+			}
+			records, _ := doc.Root().Elements()
+			for _, r := range records {
+				name, _ := r.Get("name")
+				id, _ := r.Get("id")
+				name.String()
+				id.String()
+			}
+		}
+	})
+	b.Run("parse.Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			v, err := parse.Parse(bytes.NewReader(data))
+			if err != nil {
+				b.Fatalf("Parse(): %v", err)
+			}
+			for _, r := range v.([]interface{}) {
+				record := r.(map[string]interface{})
+				_ = record["name"]
+				_ = record["id"]
+			}
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package lazy
+
+import "github.com/vyevs/gojson/tok"
+
+// indexer records the position of every value lex.Scan finds in a document
+type indexer struct {
+	nodes []node
+	open  []int // indexes of the nodes of the objects and arrays not yet ended
+}
+
+var kinds = map[tok.TokenType]Kind{
+	tok.Null:          Null,
+	tok.Boolean:       Bool,
+	tok.Integer:       Integer,
+	tok.FloatingPoint: Float,
+	tok.String:        String,
+}
+
+func (x *indexer) Value(t tok.TokenType, start, end int, escaped bool) error {
+	x.nodes = append(x.nodes, node{kind: kinds[t], escaped: escaped, start: start, end: end, next: len(x.nodes) + 1})
+	return nil
+}
+
+func (x *indexer) Begin(t tok.TokenType, start int) error {
+	kind := Array
+	if t == tok.OpeningCurlyBrace {
+		kind = Object
+	}
+	x.open = append(x.open, len(x.nodes))
+	x.nodes = append(x.nodes, node{kind: kind, start: start})
+	return nil
+}
+
+func (x *indexer) End(t tok.TokenType, end, count int) error {
+	n := &x.nodes[x.open[len(x.open)-1]]
+	x.open = x.open[:len(x.open)-1]
+	n.end, n.len, n.next = end, count, len(x.nodes)
+	return nil
+}
@@ -0,0 +1,161 @@
+package lex
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/vyevs/gojson/tok"
+)
+
+// source records the bytes the lexer reads from the input,
+// so that the source of a value can be returned by EndCapture
+//
+// the input is only recorded while a capture has not ended, from its beginning on,
+// a capture that begins once the input after the most recently read token has
+// been read rebuilds the token from its literal, as it would take copying the
+// whole input to keep it, in JSON5 mode the input from the most recently read
+// token on is always recorded, as its tokens are not rebuilt
+type source struct {
+	r      io.Reader
+	n      int64   // bytes read from r
+	buf    []byte  // the bytes read from r from offset start on
+	start  int64   // offset of buf[0]
+	mark   int64   // offset of the most recently read token
+	starts []int64 // offsets of the captures that have not ended
+
+	last    tok.Token // the most recently read token
+	lastSrc []byte    // its source, if it is a string that holds escape sequences or is not valid
+
+	scratch    []byte    // reused to read string and number literals into
+	interner   *Interner // for the literals of keys, if set
+	key        bool      // whether the token being read is where a key is expected
+	mode       Mode
+	pending    tok.Token // read by PeekTokenType, to be returned by ReadToken
+	hasPending bool
+	reader     *bufio.Reader // of the Lexer, kept with the source when pooled
+
+	trackLines bool
+	lines      []int64 // offsets of the newlines read, if lines are tracked
+}
+
+func (s *source) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if len(s.starts) > 0 || s.mode&JSON5 != 0 {
+		s.record(p[:n])
+	}
+	if s.trackLines {
+		for i := 0; i < n; {
+			nl := bytes.IndexByte(p[i:n], '\n')
+			if nl < 0 {
+				break
+			}
+			s.lines = append(s.lines, s.n+int64(i+nl))
+			i += nl + 1
+		}
+	}
+	s.n += int64(n)
+	return n, err
+}
+
+// appends p to buf, dropping the bytes before the oldest capture,
+// or before the most recently read token if there is none
+func (s *source) record(p []byte) {
+	keep := s.mark
+	if len(s.starts) > 0 {
+		keep = s.starts[0]
+	}
+	s.discard(keep)
+	s.buf = append(s.buf, p...)
+}
+
+// drops the bytes of buf before offset
+func (s *source) discard(offset int64) {
+	if offset > s.start {
+		s.buf = s.buf[:copy(s.buf, s.buf[offset-s.start:])]
+		s.start = offset
+	}
+}
+
+// offset of the next byte the lexer reads
+func (l Lexer) offset() int64 {
+	return l.src.n - int64(l.r.Buffered())
+}
+
+// TokenOffset returns the offset in the input of the most recently read token
+func (l Lexer) TokenOffset() int64 {
+	return l.src.mark
+}
+
+// TrackLines makes the Lexer record where the lines of its input begin,
+// so that Position can tell the line and column of an offset
+// it must be called before any input is read, and is kept by Reset
+func (l Lexer) TrackLines() {
+	l.src.trackLines = true
+}
+
+// Position returns the 1-based line and column, counted in bytes, of offset,
+// which must not be past the input read, lines must be tracked with TrackLines
+func (l Lexer) Position(offset int64) (line, column int) {
+	lines := l.src.lines
+	n := sort.Search(len(lines), func(i int) bool { return lines[i] >= offset })
+	lineStart := int64(0)
+	if n > 0 {
+		lineStart = lines[n-1] + 1
+	}
+	return n + 1, int(offset-lineStart) + 1
+}
+
+// StartCapture starts recording the source of the input,
+// beginning with the most recently read token
+// captures nest, every StartCapture must be matched by an EndCapture
+func (l Lexer) StartCapture() {
+	s := l.src
+	start := s.mark
+	if len(s.starts) == 0 && (s.start > s.mark || s.start+int64(len(s.buf)) != s.n) {
+		// the input has been read on without being recorded
+		src := l.tokenSource()
+		ahead, _ := l.r.Peek(l.r.Buffered())
+		start = l.offset() - int64(len(src))
+		s.buf = append(append(s.buf[:0], src...), ahead...)
+		s.start = start
+	}
+	s.starts = append(s.starts, start)
+}
+
+// the source of the most recently read token, or nil if it cannot be rebuilt,
+// which is the case for the end of the input and for some tokens that are not valid
+func (l Lexer) tokenSource() []byte {
+	s := l.src
+	var src []byte
+	switch {
+	case l.offset() == s.mark || s.last.TokenType == tok.EOF:
+		return nil
+	case len(s.lastSrc) > 0:
+		src = s.lastSrc
+	case s.last.TokenType == tok.String:
+		src = []byte(`"` + s.last.Literal + `"`)
+	default:
+		src = []byte(s.last.Literal)
+	}
+	if int64(len(src)) != l.offset()-s.mark {
+		return nil
+	}
+	return src
+}
+
+// EndCapture returns a copy of the source read since the matching StartCapture,
+// from the beginning of the token read before it to the end of the last token read
+func (l Lexer) EndCapture() []byte {
+	s := l.src
+	start := s.starts[len(s.starts)-1]
+	s.starts = s.starts[:len(s.starts)-1]
+
+	captured := append([]byte(nil), s.buf[start-s.start:l.offset()-s.start]...)
+	if len(s.starts) == 0 && s.mode&JSON5 == 0 {
+		// only what a capture beginning with the most recently read token needs is kept
+		s.discard(s.mark)
+	}
+	return captured
+}
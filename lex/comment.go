@@ -0,0 +1,102 @@
+package lex
+
+import "github.com/vyevs/gojson/tok"
+
+// Mode selects the extensions to JSON a Lexer accepts
+type Mode uint8
+
+const (
+	// JSONC accepts the // line and /* block */ comments of JSONC documents,
+	// e.g. tsconfig.json, and skips them like whitespace
+	JSONC Mode = 1 << iota
+
+	// KeepComments makes a JSONC Lexer return comments as Comment tokens,
+	// whose literals are the comments with their delimiters, rather than skip them
+	KeepComments
+
+	// JSON5 accepts JSON5 documents, https://spec.json5.org, along with
+	// their comments as JSONC does, the tokens beyond those of JSON are read as:
+	//	unquoted keys          Identifier tokens, their literals the decoded names
+	//	single-quoted strings  String tokens, with JSON5 escape sequences decoded
+	//	hexadecimal integers   Integer tokens, e.g.: "0xC8", "-0x1f"
+	//	Infinity and NaN       FloatingPoint tokens, as are numbers with a leading
+	//	                       or trailing decimal point, e.g.: ".5", "5."
+	// numbers keep their literals, including a leading + sign, so that they can be
+	// converted by strconv.ParseInt with base 0 and strconv.ParseFloat,
+	// but for a signed NaN, which ParseFloat does not accept
+	JSON5
+)
+
+// SetMode sets the extensions to JSON the Lexer accepts, it is kept by Reset
+func (l Lexer) SetMode(m Mode) {
+	l.src.mode = m
+}
+
+// consumes whitespace, and comments unless they are kept, returning the token
+// found in their place if there is one: EOF, a comment that is not valid,
+// or a comment that is kept, which is only read if peek is false
+func (l Lexer) skipInsignificant(peek bool) (tok.Token, bool) {
+	for {
+		var more bool
+		if l.src.mode&JSON5 != 0 {
+			more = l.consumeJSON5WhiteSpace()
+		} else {
+			more = consumeWhiteSpace(l.r)
+		}
+		if !more {
+			return tok.EOFToken, true
+		}
+		l.src.mark = l.offset()
+		if l.src.mode&(JSONC|JSON5) == 0 {
+			return tok.Token{}, false
+		}
+		if b, _ := l.r.Peek(1); b[0] != '/' {
+			return tok.Token{}, false
+		}
+		if peek && l.src.mode&KeepComments != 0 {
+			return tok.Token{TokenType: tok.Comment}, true
+		}
+		t := l.readComment()
+		if t.TokenType != tok.Comment || l.src.mode&KeepComments != 0 {
+			return t, true
+		}
+	}
+}
+
+// reads the comment beginning with the next byte, a slash, returning an Invalid
+// token if it is not a comment or a block comment is not terminated
+// a line comment ends before the newline that terminates it, or in JSON5 mode
+// before any of its line terminators
+func (l Lexer) readComment() tok.Token {
+	b, _ := l.r.Peek(2)
+	if len(b) < 2 || b[1] != '/' && b[1] != '*' {
+		_, _ = l.r.Discard(1)
+		return tok.Token{TokenType: tok.Invalid, Literal: "/"}
+	}
+	block := b[1] == '*'
+	l.src.scratch = append(l.src.scratch[:0], b...)
+	_, _ = l.r.Discard(2)
+
+	for {
+		c, err := l.r.ReadByte()
+		if err != nil {
+			if block {
+				return tok.Token{TokenType: tok.Invalid, Literal: string(l.src.scratch)}
+			}
+			break
+		}
+		if !block && (c == '\n' || c == '\r' && l.src.mode&JSON5 != 0) {
+			_ = l.r.UnreadByte()
+			break
+		}
+		if !block && l.src.mode&JSON5 != 0 && l.readLineSeparator(c) {
+			// whitespace in JSON5, so it is not left to be read
+			break
+		}
+		l.src.scratch = append(l.src.scratch, c)
+		if block && c == '/' && len(l.src.scratch) > 3 && l.src.scratch[len(l.src.scratch)-2] == '*' {
+			break
+		}
+	}
+	return tok.Token{TokenType: tok.Comment, Literal: string(l.src.scratch)}
+}
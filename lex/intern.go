@@ -0,0 +1,50 @@
+package lex
+
+import "sync"
+
+// maxInternLen is the length of the longest strings an Interner keeps,
+// longer strings are rarely repeated
+const maxInternLen = 64
+
+// Interner holds a single copy of each distinct short string it is given,
+// so that strings repeated within and across documents, such as the keys of
+// records sharing a schema, are allocated once rather than every time they are read
+//
+// an Interner keeps at most the number of strings it was made with, once full
+// it still returns the strings it holds but new ones are not added
+// an Interner may be shared by Lexers on several goroutines
+type Interner struct {
+	mu   sync.Mutex
+	strs map[string]string
+	max  int
+}
+
+// NewInterner returns an Interner that keeps at most max strings
+func NewInterner(max int) *Interner {
+	return &Interner{strs: make(map[string]string), max: max}
+}
+
+// Intern returns a string with the contents of b, the same string
+// for all b with the same contents as long as the Interner keeps it
+func (in *Interner) Intern(b []byte) string {
+	if len(b) > maxInternLen {
+		return string(b)
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if s, ok := in.strs[string(b)]; ok {
+		return s
+	}
+	s := string(b)
+	if len(in.strs) < in.max {
+		in.strs[s] = s
+	}
+	return s
+}
+
+// Len returns the number of strings the Interner holds
+func (in *Interner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.strs)
+}
@@ -0,0 +1,323 @@
+package lex
+
+import (
+	"bufio"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/vyevs/gojson/tok"
+)
+
+// consumes the whitespace of JSON5 documents
+// returns whether there are any more characters to be read
+func (l Lexer) consumeJSON5WhiteSpace() bool {
+	for {
+		r, _, err := l.r.ReadRune()
+		if err != nil {
+			return false
+		}
+		if !isJSON5Whitespace(r) {
+			_ = l.r.UnreadRune()
+			return true
+		}
+	}
+}
+
+func isJSON5Whitespace(r rune) bool {
+	switch r {
+	case '\t', '\n', '\v', '\f', '\r', ' ', '\u00a0', '\u2028', '\u2029', '\ufeff':
+		return true
+	}
+	return unicode.Is(unicode.Zs, r)
+}
+
+// the type of the JSON5 token beginning with the next rune, judged as PeekTokenType does
+func json5TokenType(r *bufio.Reader) tok.TokenType {
+	c, _, _ := r.ReadRune()
+	_ = r.UnreadRune()
+	switch {
+	case c == '\'':
+		return tok.String
+	case c == '+' || c == '.':
+		return tok.Integer
+	case c < utf8.RuneSelf && tok.ByteToTokenType(byte(c)) != tok.Invalid:
+		return tok.ByteToTokenType(byte(c))
+	case isIdentifierStart(c) || c == '\\':
+		return tok.Identifier
+	}
+	return tok.Invalid
+}
+
+// reads the rest of U+2028 or U+2029, the line terminators of JSON5 other
+// than newlines and carriage returns, if c, which has been read, begins one
+func (l Lexer) readLineSeparator(c byte) bool {
+	// encoded as E2 80 A8 and E2 80 A9
+	if c != 0xE2 {
+		return false
+	}
+	next, _ := l.r.Peek(2)
+	if len(next) < 2 || next[0] != 0x80 || next[1] != 0xA8 && next[1] != 0xA9 {
+		return false
+	}
+	_, _ = l.r.Discard(2)
+	return true
+}
+
+// reads the JSON5 token beginning with r, which has been read
+func (l Lexer) readJSON5Token(r rune) tok.Token {
+	switch {
+	case r == '"' || r == '\'':
+		return l.readJSON5String(r)
+	case r == '+' || r == '-' || r == '.' || r < utf8.RuneSelf && isDigit(byte(r)):
+		_ = l.r.UnreadRune()
+		return l.readJSON5Number()
+	case r < utf8.RuneSelf && tok.ByteToTokenType(byte(r)) != tok.Invalid &&
+		!isIdentifierStart(r):
+		// punctuation
+		return readTokenBeginningWithByte(l.r, byte(r))
+	}
+
+	_ = l.r.UnreadRune()
+	name, ok := l.readIdentifier()
+	if !ok {
+		return tok.Token{TokenType: tok.Invalid, Literal: name}
+	}
+	switch name {
+	case "true", "false":
+		return tok.Token{TokenType: tok.Boolean, Literal: name}
+	case "null":
+		return tok.NullToken
+	case "Infinity", "NaN":
+		return tok.Token{TokenType: tok.FloatingPoint, Literal: name}
+	}
+	return tok.Token{TokenType: tok.Identifier, Literal: name}
+}
+
+// reads an ECMAScript IdentifierName, in which \u escape sequences are decoded
+// reports false if there is none or an escape sequence is not valid
+func (l Lexer) readIdentifier() (string, bool) {
+	var builder strings.Builder
+	for {
+		r, _, err := l.r.ReadRune()
+		if err != nil {
+			break
+		}
+		escaped := r == '\\'
+		if escaped {
+			if next, _ := l.r.Peek(1); len(next) == 0 || next[0] != 'u' {
+				builder.WriteRune(r)
+				return builder.String(), false
+			}
+			_, _ = l.r.Discard(1)
+			var ok bool
+			if r, ok = readHexRune(l.r); !ok {
+				return builder.String(), false
+			}
+		}
+		if isIdentifierStart(r) || builder.Len() > 0 && isIdentifierPart(r) {
+			builder.WriteRune(r)
+			continue
+		}
+		if escaped {
+			// an escape sequence must stand for a character of the identifier
+			builder.WriteRune(r)
+			return builder.String(), false
+		}
+		_ = l.r.UnreadRune()
+		break
+	}
+	return builder.String(), builder.Len() > 0
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || r == '\u200c' || r == '\u200d' ||
+		unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc)
+}
+
+// reads a JSON5 number, which may be signed, hexadecimal, Infinity or NaN
+func (l Lexer) readJSON5Number() tok.Token {
+	var builder strings.Builder
+	b, _ := l.r.ReadByte()
+	builder.WriteByte(b)
+	if b == '+' || b == '-' {
+		if next, _ := l.r.Peek(1); len(next) > 0 && (next[0] == 'I' || next[0] == 'N') {
+			name, _ := l.readIdentifier()
+			builder.WriteString(name)
+			if name != "Infinity" && name != "NaN" {
+				return tok.Token{TokenType: tok.Invalid, Literal: builder.String()}
+			}
+			return tok.Token{TokenType: tok.FloatingPoint, Literal: builder.String()}
+		}
+	}
+
+	// the rest of the number, along with any letters and digits that run into it
+	for {
+		b, err := l.r.ReadByte()
+		if err != nil {
+			break
+		}
+		prev := builder.String()[builder.Len()-1]
+		exponentSign := (b == '+' || b == '-') && (prev == 'e' || prev == 'E') && !isHexLiteral(builder.String())
+		if !exponentSign && !isIdentifierByte(b) && b != '.' {
+			_ = l.r.UnreadByte()
+			break
+		}
+		builder.WriteByte(b)
+	}
+
+	literal := builder.String()
+	return tok.Token{TokenType: json5NumberType(literal), Literal: literal}
+}
+
+func isIdentifierByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || isDigit(b) || b == '_' || b == '$'
+}
+
+func isHexLiteral(literal string) bool {
+	literal = strings.TrimLeft(literal, "+-")
+	return len(literal) >= 2 && literal[0] == '0' && (literal[1] == 'x' || literal[1] == 'X')
+}
+
+// returns Integer or FloatingPoint for a literal that follows the grammar of
+// JSON5 numbers, other than Infinity and NaN, and Invalid for any other literal
+func json5NumberType(literal string) tok.TokenType {
+	unsigned := literal
+	if unsigned != "" && (unsigned[0] == '+' || unsigned[0] == '-') {
+		unsigned = unsigned[1:]
+	}
+
+	if isHexLiteral(unsigned) {
+		for _, b := range []byte(unsigned[2:]) {
+			if _, ok := hexRune([]byte{'0', '0', '0', b}); !ok {
+				return tok.Invalid
+			}
+		}
+		if len(unsigned) == 2 {
+			return tok.Invalid
+		}
+		return tok.Integer
+	}
+
+	// JSON numbers, with a decimal point that may lead or trail
+	integer := leadingDigits(unsigned)
+	if integer > 1 && unsigned[0] == '0' {
+		return tok.Invalid
+	}
+	rest := unsigned[integer:]
+	typ := tok.Integer
+	if rest != "" && rest[0] == '.' {
+		typ = tok.FloatingPoint
+		fraction := leadingDigits(rest[1:])
+		if integer == 0 && fraction == 0 {
+			return tok.Invalid
+		}
+		rest = rest[1+fraction:]
+	} else if integer == 0 {
+		return tok.Invalid
+	}
+	if rest != "" && (rest[0] == 'e' || rest[0] == 'E') {
+		typ = tok.FloatingPoint
+		rest = rest[1:]
+		if rest != "" && (rest[0] == '+' || rest[0] == '-') {
+			rest = rest[1:]
+		}
+		exponent := leadingDigits(rest)
+		if exponent == 0 {
+			return tok.Invalid
+		}
+		rest = rest[exponent:]
+	}
+	if rest != "" {
+		return tok.Invalid
+	}
+	return typ
+}
+
+var json5EscapedRunes = map[rune]rune{
+	'\'': '\'',
+	'"':  '"',
+	'\\': '\\',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'v':  '\v',
+}
+
+// reads a JSON5 string quoted with quote, which has been read
+func (l Lexer) readJSON5String(quote rune) tok.Token {
+	var builder strings.Builder
+	invalid := func() tok.Token {
+		return tok.Token{TokenType: tok.Invalid, Literal: builder.String()}
+	}
+	for {
+		r, _, err := l.r.ReadRune()
+		if err != nil || r == '\n' || r == '\r' {
+			return invalid()
+		}
+		if r == quote {
+			return tok.Token{TokenType: tok.String, Literal: builder.String()}
+		}
+		if r != '\\' {
+			builder.WriteRune(r)
+			continue
+		}
+
+		r, _, err = l.r.ReadRune()
+		if err != nil {
+			return invalid()
+		}
+		if escaped, ok := json5EscapedRunes[r]; ok {
+			builder.WriteRune(escaped)
+			continue
+		}
+		switch {
+		case r == '\n', r == '\u2028', r == '\u2029':
+			// a line continuation
+		case r == '\r':
+			if next, _ := l.r.Peek(1); len(next) > 0 && next[0] == '\n' {
+				_, _ = l.r.Discard(1)
+			}
+		case r == '0':
+			if next, _ := l.r.Peek(1); len(next) > 0 && isDigit(next[0]) {
+				return invalid()
+			}
+			builder.WriteByte(0)
+		case r >= '1' && r <= '9':
+			return invalid()
+		case r == 'x':
+			digits, _ := l.r.Peek(2)
+			_, _ = l.r.Discard(len(digits))
+			x, ok := hexRune(append([]byte("00"), digits...))
+			if !ok {
+				return invalid()
+			}
+			builder.WriteRune(x)
+		case r == 'u':
+			r1, ok := readHexRune(l.r)
+			if !ok {
+				return invalid()
+			}
+			if utf16.IsSurrogate(r1) {
+				next, _ := l.r.Peek(6)
+				if r2, ok := lowSurrogate(r1, next); ok {
+					_, _ = l.r.Discard(6)
+					r1 = utf16.DecodeRune(r1, r2)
+				} else {
+					r1 = utf8.RuneError
+				}
+			}
+			builder.WriteRune(r1)
+		default:
+			// any other character stands for itself
+			builder.WriteRune(r)
+		}
+	}
+}
@@ -2,27 +2,184 @@ package lex
 
 import (
 	"bufio"
+	"bytes"
 	"io"
+	"sync"
 
 	"github.com/vyevs/gojson/tok"
 )
 
 // Lexer reads bytes from r into Tokens
 type Lexer struct {
-	r *bufio.Reader
+	r   *bufio.Reader
+	src *source
 }
 
 // New returns a Lexer that will tokenize the input from r
 func New(r io.Reader) Lexer {
-	return Lexer{r: bufio.NewReader(r)}
+	src := &source{r: r}
+	return Lexer{r: bufio.NewReader(src), src: src}
+}
+
+var pool = sync.Pool{
+	New: func() interface{} {
+		src := &source{}
+		src.reader = bufio.NewReader(src)
+		return src
+	},
+}
+
+// NewPooled is like New but reuses the buffers of a Lexer that was released,
+// for programs that lex many documents
+// the Lexer should be released with Release once it is no longer needed
+func NewPooled(r io.Reader) Lexer {
+	src := pool.Get().(*source)
+	src.r = r
+	return Lexer{r: src.reader, src: src}
+}
+
+// Release returns the buffers of a Lexer made by NewPooled to be reused,
+// neither the Lexer nor copies of it may be used afterwards
+func (l Lexer) Release() {
+	l.Reset(nil)
+	l.src.interner = nil
+	l.src.mode = 0
+	l.src.trackLines = false
+	pool.Put(l.src)
+}
+
+// Reset discards the state of the Lexer and any input it has buffered,
+// and makes it tokenize the input from r, reusing its buffers
+// offsets are counted from the beginning of r, the Interner, the mode
+// and whether lines are tracked are kept
+func (l Lexer) Reset(r io.Reader) {
+	s := l.src
+	*s = source{
+		r:          r,
+		buf:        s.buf[:0],
+		starts:     s.starts[:0],
+		scratch:    s.scratch[:0],
+		lastSrc:    s.lastSrc[:0],
+		interner:   s.interner,
+		mode:       s.mode,
+		reader:     s.reader,
+		trackLines: s.trackLines,
+		lines:      s.lines[:0],
+	}
+	l.r.Reset(s)
+}
+
+// InputOffset returns the offset in the input just past the last token read
+func (l Lexer) InputOffset() int64 {
+	return l.offset()
+}
+
+// Buffered returns the input the Lexer has read ahead of its last token,
+// so that the rest of a stream can be read in a different protocol once a
+// JSON value has been read from it, by reading from io.MultiReader(l.Buffered(), r)
+// the reader is valid until the Lexer is next used
+func (l Lexer) Buffered() io.Reader {
+	b, _ := l.r.Peek(l.r.Buffered())
+	return bytes.NewReader(b)
+}
+
+// PeekTokenType returns the type of the next token without reading it,
+// judged from its first byte, so every number is reported as an Integer
+// and a token that is not valid is reported by its first byte alone,
+// e.g.: Boolean for "tru"
+// whitespace before the token is consumed, as are comments that are skipped
+// in JSON5 mode a first byte that can only begin an identifier is reported as
+// Identifier, including those of Infinity and NaN
+func (l Lexer) PeekTokenType() tok.TokenType {
+	if l.src.hasPending {
+		return l.src.pending.TokenType
+	}
+	if t, ok := l.skipInsignificant(true); ok {
+		if t.TokenType == tok.Invalid {
+			// a comment that is not valid has been read, ReadToken returns it
+			l.src.pending, l.src.hasPending = t, true
+		}
+		return t.TokenType
+	}
+	b, _ := l.r.Peek(1)
+	if l.src.mode&JSON5 != 0 {
+		return json5TokenType(l.r)
+	}
+	return tok.ByteToTokenType(b[0])
+}
+
+// SetInterner makes the Lexer intern the literals of the strings ReadKeyToken reads
+// with in, a nil Interner turns interning off
+func (l Lexer) SetInterner(in *Interner) {
+	l.src.interner = in
 }
 
 // ReadToken reads a single Token from the Lexer
 func (l Lexer) ReadToken() tok.Token {
-	if !consumeWhiteSpace(l.r) {
-		return tok.EOFToken
+	l.src.lastSrc = l.src.lastSrc[:0]
+	t := l.readToken()
+	l.src.last = t
+	return t
+}
+
+func (l Lexer) readToken() tok.Token {
+	if l.src.hasPending {
+		l.src.hasPending = false
+		return l.src.pending
+	}
+	if t, ok := l.skipInsignificant(false); ok {
+		return t
+	}
+	if l.src.mode&JSON5 != 0 {
+		r, _, _ := l.r.ReadRune()
+		return l.readJSON5Token(r)
 	}
-	return readTokenNoWhitespace(l.r)
+
+	// strings and numbers are read into the scratch buffer,
+	// so that only their literals are allocated
+	b, _ := l.r.ReadByte()
+	switch {
+	case b == '"':
+		return l.readStringToken()
+	case b == '-' || isDigit(b):
+		_ = l.r.UnreadByte()
+		return l.readNumericToken()
+	}
+	return readTokenBeginningWithByte(l.r, b)
+}
+
+// ReadKeyToken is like ReadToken, for where an object key is expected,
+// the literal of a string is interned if the Lexer has an Interner
+// values are not, they are rarely repeated as often as keys are
+func (l Lexer) ReadKeyToken() tok.Token {
+	l.src.key = true
+	t := l.ReadToken()
+	l.src.key = false
+	return t
+}
+
+// like readStringToken, interning the literal of a key if the Lexer has an Interner
+func (l Lexer) readStringToken() tok.Token {
+	var ok bool
+	l.src.scratch, l.src.lastSrc, ok = appendStringLiteral(l.src.scratch[:0], l.src.lastSrc, l.r)
+	if !ok {
+		return tok.Token{TokenType: tok.Invalid, Literal: string(l.src.scratch)}
+	}
+	if l.src.interner != nil && l.src.key {
+		return tok.Token{TokenType: tok.String, Literal: l.src.interner.Intern(l.src.scratch)}
+	}
+	return tok.Token{TokenType: tok.String, Literal: string(l.src.scratch)}
+}
+
+// like readNumericToken
+func (l Lexer) readNumericToken() tok.Token {
+	var ok bool
+	l.src.scratch, ok = appendNumericLiteral(l.src.scratch[:0], l.r)
+	literal := string(l.src.scratch)
+	if !ok || !validateNumericLiteral(literal) {
+		return tok.Token{TokenType: tok.Invalid, Literal: literal}
+	}
+	return tok.Token{TokenType: numericLiteralTokenType(literal), Literal: literal}
 }
 
 // consumes all whitespace characters as defined by isWhiteSpace()
@@ -42,14 +199,7 @@ func consumeWhiteSpace(r *bufio.Reader) bool {
 }
 
 func isWhitespace(b byte) bool {
-	return b == ' ' || b == '\n' || b == '\t'
-}
-
-// reads a single token that begins with the next byte read from r
-func readTokenNoWhitespace(r *bufio.Reader) tok.Token {
-	b, _ := r.ReadByte()
-
-	return readTokenBeginningWithByte(r, b)
+	return b == ' ' || b == '\n' || b == '\t' || b == '\r'
 }
 
 func readTokenBeginningWithByte(r *bufio.Reader, b byte) tok.Token {
@@ -2,8 +2,13 @@ package lex
 
 import (
 	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/vyevs/gojson/tok"
 )
@@ -156,6 +161,13 @@ func TestReadNumericLiteral(t *testing.T) {
 		{str: "-123.123", want: "-123.123", wantOk: true},
 		{str: "-123.123.", want: "-123.123.", wantOk: false},
 		{str: "-0.123", want: "-0.123", wantOk: true},
+		{str: "1e5", want: "1e5", wantOk: true},
+		{str: "1.5E-3,", want: "1.5E-3", wantOk: true},
+		{str: "-2e+10]", want: "-2e+10", wantOk: true},
+		{str: "1e", want: "1e", wantOk: true},
+		{str: "1ea", want: "1e", wantOk: true},
+		{str: "1e5e5", want: "1e5e", wantOk: false},
+		{str: "1e5.5", want: "1e5.", wantOk: false},
 	}
 
 	for _, test := range tests {
@@ -231,6 +243,38 @@ func TestReadNumericToken(t *testing.T) {
 			str:  "-0.123",
 			want: tok.Token{TokenType: tok.FloatingPoint, Literal: "-0.123"},
 		},
+		{
+			str:  "1E30",
+			want: tok.Token{TokenType: tok.FloatingPoint, Literal: "1E30"},
+		},
+		{
+			str:  "-0.5e-7",
+			want: tok.Token{TokenType: tok.FloatingPoint, Literal: "-0.5e-7"},
+		},
+		{
+			str:  "2e+3",
+			want: tok.Token{TokenType: tok.FloatingPoint, Literal: "2e+3"},
+		},
+		{
+			str:  "1e",
+			want: tok.Token{TokenType: tok.Invalid, Literal: "1e"},
+		},
+		{
+			str:  "1e+",
+			want: tok.Token{TokenType: tok.Invalid, Literal: "1e+"},
+		},
+		{
+			str:  "1.e5",
+			want: tok.Token{TokenType: tok.Invalid, Literal: "1.e5"},
+		},
+		{
+			str:  "-",
+			want: tok.Token{TokenType: tok.Invalid, Literal: "-"},
+		},
+		{
+			str:  "-a",
+			want: tok.Token{TokenType: tok.Invalid, Literal: "-"},
+		},
 	}
 
 	for _, test := range tests {
@@ -256,6 +300,19 @@ func TestReadStringLiteral(t *testing.T) {
 		{str: `"`, want: "", wantOk: true},
 		{str: ``, want: "", wantOk: false},
 		{str: `"""`, want: "", wantOk: true},
+		{str: `a\"b"`, want: `a"b`, wantOk: true},
+		{str: `\\\/\b\f\n\r\t"`, want: "\\/\b\f\n\r\t", wantOk: true},
+		{str: `\u0041\u00e9\u20AC"`, want: "A\u00e9\u20ac", wantOk: true},
+		{str: `\ud83d\ude00"`, want: "\U0001F600", wantOk: true},
+		{str: `\ud83d"`, want: "\uFFFD", wantOk: true},
+		{str: `\ud83dx"`, want: "\uFFFDx", wantOk: true},
+		{str: `\ud83d\u0041"`, want: "\uFFFDA", wantOk: true},
+		{str: `\ude00\ud83d"`, want: "\uFFFD\uFFFD", wantOk: true},
+		{str: `\ude00\ud83d\ude00"`, want: "\uFFFD\U0001F600", wantOk: true},
+		{str: `\ud83d\ud83d\ude00"`, want: "\uFFFD\U0001F600", wantOk: true},
+		{str: `a\x"`, want: "a", wantOk: false},
+		{str: `\u12"`, want: "", wantOk: false},
+		{str: `a\`, want: "a", wantOk: false},
 	}
 
 	for _, test := range tests {
@@ -270,6 +327,38 @@ func TestReadStringLiteral(t *testing.T) {
 	}
 }
 
+// AppendUnescaped decodes what readStringLiteral does
+func TestAppendUnescaped(t *testing.T) {
+	tests := []string{
+		`potato`,
+		`a\"b`,
+		`\\\/\b\f\n\r\t`,
+		`\u0041\u00e9\u20AC`,
+		`\ud83d\ude00`,
+		`\ud83d`,
+		`\ud83dx`,
+		`\ud83d\u0041`,
+		`\ude00\ud83d`,
+		`\ude00\ud83d\ude00`,
+		`\ud83d\ud83d\ude00`,
+		`\ud83d\x`,
+		`\ud83d\u12`,
+		`a\x`,
+		`\u12`,
+		`a\`,
+	}
+
+	for _, test := range tests {
+		want, wantOk := readStringLiteral(bufio.NewReader(strings.NewReader(test + `"`)))
+
+		got, ok := AppendUnescaped([]byte("prefix "), []byte(test))
+
+		if ok != wantOk || (ok && string(got) != "prefix "+want) {
+			t.Errorf("str: %q, want: %q, got: %q, wantOk: %v, got ok: %v", test, want, got, wantOk, ok)
+		}
+	}
+}
+
 func TestReadStringToken(t *testing.T) {
 	tests := []struct {
 		str  string
@@ -338,6 +427,17 @@ func TestReadToken(t *testing.T) {
 				tok.EOFToken,
 			},
 		},
+		{
+			str: "[\r\n\t1,\r\n\t2\r\n]\r\n",
+			want: []tok.Token{
+				tok.OpeningSquareBracketToken,
+				{TokenType: tok.Integer, Literal: "1"},
+				tok.CommaToken,
+				{TokenType: tok.Integer, Literal: "2"},
+				tok.ClosingSquareBracketToken,
+				tok.EOFToken,
+			},
+		},
 		{
 			str: `   "a"   123 true`,
 			want: []tok.Token{
@@ -437,6 +537,89 @@ func TestReadToken(t *testing.T) {
 	}
 }
 
+func TestCapture(t *testing.T) {
+	long := `"` + strings.Repeat("x", 10000) + `"`
+	tests := []struct {
+		str    string
+		skip   int // tokens read before the capture
+		tokens int // tokens read during the capture, including the first
+		want   string
+	}{
+		{str: `[1, {"a" : "\u0041"} ]`, skip: 3, tokens: 5, want: `{"a" : "\u0041"}`},
+		{str: `  12.5e1  `, skip: 0, tokens: 1, want: `12.5e1`},
+		{str: `{"k":  -3}`, skip: 3, tokens: 1, want: `-3`},
+		{str: `[` + long + `, ` + long + `]`, skip: 3, tokens: 1, want: long},
+		{str: `[` + long + `, [true,` + "\n\t" + `null]]`, skip: 3, tokens: 5, want: "[true,\n\tnull]"},
+		{str: `[ "a\"b\u00e9\ud83d\ude00\ud83d" , 1]`, skip: 1, tokens: 1, want: `"a\"b\u00e9\ud83d\ude00\ud83d"`},
+		{str: `[1, "abc"]`, skip: 3, tokens: 2, want: `"abc"]`},
+		{str: `{"a": tru, "b": 2}`, skip: 3, tokens: 4, want: `tru, "b": 2`},
+		{str: `["ab`, skip: 1, tokens: 1, want: `"ab`},
+		{str: `1 `, skip: 1, tokens: 1, want: ``},
+	}
+
+	for _, test := range tests {
+		for _, r := range []io.Reader{strings.NewReader(test.str), iotest.OneByteReader(strings.NewReader(test.str))} {
+			lexer := New(r)
+			var first tok.Token
+			for i := 0; i <= test.skip; i++ {
+				first = lexer.ReadToken()
+			}
+			lexer.StartCapture()
+			for i := 1; i < test.tokens; i++ {
+				lexer.ReadToken()
+			}
+
+			if got := string(lexer.EndCapture()); got != test.want {
+				t.Errorf("str: %q, first: %v, got: %q, want: %q", test.str, first, got, test.want)
+			}
+		}
+	}
+}
+
+// the input is not recorded while no capture is active
+func TestCaptureRecording(t *testing.T) {
+	lexer := New(iotest.OneByteReader(strings.NewReader(`[{"a": "\u0041"}, 1, 2]`)))
+	for i := 0; i < 5; i++ {
+		lexer.ReadToken()
+	}
+	if len(lexer.src.buf) != 0 {
+		t.Errorf("recorded before the capture: %q", lexer.src.buf)
+	}
+	lexer.StartCapture()
+	lexer.ReadToken()
+	if got := string(lexer.EndCapture()); got != `"\u0041"}` {
+		t.Errorf("got: %q, want: %q", got, `"\u0041"}`)
+	}
+	readAllTokens(lexer)
+	if len(lexer.src.buf) > len(`"\u0041"}`) {
+		t.Errorf("recorded after the capture: %q", lexer.src.buf)
+	}
+}
+
+func TestNestedCapture(t *testing.T) {
+	lexer := New(iotest.HalfReader(strings.NewReader(`{"a": [1, [2, 3], 4]}`)))
+	for i := 0; i < 4; i++ {
+		lexer.ReadToken()
+	}
+	lexer.StartCapture()
+	for i := 0; i < 3; i++ {
+		lexer.ReadToken()
+	}
+	lexer.StartCapture()
+	for i := 0; i < 4; i++ {
+		lexer.ReadToken()
+	}
+	inner := string(lexer.EndCapture())
+	for i := 0; i < 3; i++ {
+		lexer.ReadToken()
+	}
+	outer := string(lexer.EndCapture())
+
+	if inner != "[2, 3]" || outer != "[1, [2, 3], 4]" {
+		t.Errorf("inner: %q, outer: %q, want: %q, %q", inner, outer, "[2, 3]", "[1, [2, 3], 4]")
+	}
+}
+
 func readAllTokens(lexer Lexer) []tok.Token {
 	toks := make([]tok.Token, 0)
 	for {
@@ -459,3 +642,438 @@ func equalTokenSlices(t1, t2 []tok.Token) bool {
 	}
 	return true
 }
+
+func TestInterner(t *testing.T) {
+	in := NewInterner(2)
+	key := []byte("key")
+
+	if got := in.Intern(key); got != "key" {
+		t.Errorf("got: %q, want: %q", got, "key")
+	}
+	// an interned string is not allocated again
+	if allocs := testing.AllocsPerRun(10, func() { in.Intern(key) }); allocs != 0 {
+		t.Errorf("got allocs: %v, want: 0", allocs)
+	}
+
+	in.Intern([]byte("other"))
+	in.Intern([]byte("full"))
+	in.Intern([]byte(strings.Repeat("x", maxInternLen+1)))
+	if in.Len() != 2 {
+		t.Errorf("got len: %d, want: 2", in.Len())
+	}
+}
+
+func TestPooledLexer(t *testing.T) {
+	in := NewInterner(10)
+	for i := 0; i < 3; i++ {
+		l := NewPooled(strings.NewReader(`{"id": 1, "name": "x\n"}`))
+		l.SetInterner(in)
+		var got []tok.Token
+		read := l.ReadToken
+		for tk := read(); tk.TokenType != tok.EOF; tk = read() {
+			got = append(got, tk)
+			// keys follow { and ,
+			read = l.ReadToken
+			if tk.TokenType == tok.OpeningCurlyBrace || tk.TokenType == tok.Comma {
+				read = l.ReadKeyToken
+			}
+		}
+		l.Release()
+
+		want := []tok.Token{
+			tok.OpeningCurlyBraceToken,
+			{TokenType: tok.String, Literal: "id"},
+			tok.ColonToken,
+			{TokenType: tok.Integer, Literal: "1"},
+			tok.CommaToken,
+			{TokenType: tok.String, Literal: "name"},
+			tok.ColonToken,
+			{TokenType: tok.String, Literal: "x\n"},
+			tok.ClosingCurlyBraceToken,
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got: %v, want: %v", got, want)
+		}
+		for j := range want {
+			if got[j] != want[j] {
+				t.Errorf("token %d: got: %v, want: %v", j, got[j], want[j])
+			}
+		}
+	}
+	// the keys, but not the value
+	if in.Len() != 2 {
+		t.Errorf("got interned: %d, want: 2", in.Len())
+	}
+}
+
+func TestReset(t *testing.T) {
+	l := New(strings.NewReader(`["unread", 1, 2]`))
+	l.ReadToken()
+	l.ReadToken()
+
+	l.Reset(strings.NewReader(`  {"a": 1}`))
+	if got := l.ReadToken(); got != tok.OpeningCurlyBraceToken {
+		t.Errorf("got: %v, want: %v", got, tok.OpeningCurlyBraceToken)
+	}
+	if got := l.InputOffset(); got != 3 {
+		t.Errorf("got offset: %d, want: 3", got)
+	}
+}
+
+// a JSON header followed by raw bytes, as in a connection-oriented protocol
+func TestBuffered(t *testing.T) {
+	l := New(iotest.OneByteReader(strings.NewReader(`{"length": 4} DATA`)))
+
+	var got []tok.Token
+	for depth := 0; ; {
+		tk := l.ReadToken()
+		got = append(got, tk)
+		switch tk.TokenType {
+		case tok.OpeningCurlyBrace:
+			depth++
+		case tok.ClosingCurlyBrace:
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if len(got) != 5 {
+		t.Errorf("got tokens: %v, want 5", got)
+	}
+	if off := l.InputOffset(); off != 13 {
+		t.Errorf("got offset: %d, want: 13", off)
+	}
+
+	rest, err := ioutil.ReadAll(io.MultiReader(l.Buffered(), l.src.r))
+	if err != nil || string(rest) != " DATA" {
+		t.Errorf("got rest: %q, want: %q, err: %v", rest, " DATA", err)
+	}
+}
+
+func TestPeekTokenType(t *testing.T) {
+	tests := []struct {
+		str  string
+		want tok.TokenType
+	}{
+		{str: "", want: tok.EOF},
+		{str: "  \n", want: tok.EOF},
+		{str: ` "a"`, want: tok.String},
+		{str: "-1.5", want: tok.Integer},
+		{str: "1.5", want: tok.Integer},
+		{str: "{", want: tok.OpeningCurlyBrace},
+		{str: "]", want: tok.ClosingSquareBracket},
+		{str: "tru", want: tok.Boolean},
+		{str: "null", want: tok.Null},
+		{str: "x", want: tok.Invalid},
+	}
+
+	for _, test := range tests {
+		l := New(strings.NewReader(test.str))
+
+		got := l.PeekTokenType()
+
+		if got != test.want {
+			t.Errorf("str: %q, got: %v, want: %v", test.str, got, test.want)
+		}
+		// peeking does not consume the token
+		trimmed := strings.TrimSpace(test.str)
+		if next := l.ReadToken(); trimmed != "" && !strings.Contains(trimmed, next.Literal) {
+			t.Errorf("str: %q, read: %v after peeking: %v", test.str, next, got)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	str := "// config\n{\"a\": /* one */ 1, /**/ /* x*/\n\"b\": 2 // trailing\n}"
+	tests := []struct {
+		mode Mode
+		want []tok.Token
+	}{
+		{
+			mode: JSONC,
+			want: []tok.Token{
+				tok.OpeningCurlyBraceToken,
+				{TokenType: tok.String, Literal: "a"},
+				tok.ColonToken,
+				{TokenType: tok.Integer, Literal: "1"},
+				tok.CommaToken,
+				{TokenType: tok.String, Literal: "b"},
+				tok.ColonToken,
+				{TokenType: tok.Integer, Literal: "2"},
+				tok.ClosingCurlyBraceToken,
+			},
+		},
+		{
+			mode: JSONC | KeepComments,
+			want: []tok.Token{
+				{TokenType: tok.Comment, Literal: "// config"},
+				tok.OpeningCurlyBraceToken,
+				{TokenType: tok.String, Literal: "a"},
+				tok.ColonToken,
+				{TokenType: tok.Comment, Literal: "/* one */"},
+				{TokenType: tok.Integer, Literal: "1"},
+				tok.CommaToken,
+				{TokenType: tok.Comment, Literal: "/**/"},
+				{TokenType: tok.Comment, Literal: "/* x*/"},
+				{TokenType: tok.String, Literal: "b"},
+				tok.ColonToken,
+				{TokenType: tok.Integer, Literal: "2"},
+				{TokenType: tok.Comment, Literal: "// trailing"},
+				tok.ClosingCurlyBraceToken,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		l := New(strings.NewReader(str))
+		l.SetMode(test.mode)
+
+		var got []tok.Token
+		for tk := l.ReadToken(); tk.TokenType != tok.EOF; tk = l.ReadToken() {
+			got = append(got, tk)
+		}
+
+		if len(got) != len(test.want) {
+			t.Errorf("mode: %d, got: %v, want: %v", test.mode, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("mode: %d, token %d: got: %v, want: %v", test.mode, i, got[i], test.want[i])
+			}
+		}
+	}
+}
+
+func TestInvalidComments(t *testing.T) {
+	tests := []struct {
+		str      string
+		mode     Mode
+		wantPeek tok.TokenType
+		want     tok.Token
+	}{
+		{str: "// c", mode: 0, wantPeek: tok.Invalid, want: tok.Token{TokenType: tok.Invalid, Literal: "/"}},
+		{str: "/ 1", mode: JSONC, wantPeek: tok.Invalid, want: tok.Token{TokenType: tok.Invalid, Literal: "/"}},
+		{str: "/* open", mode: JSONC, wantPeek: tok.Invalid, want: tok.Token{TokenType: tok.Invalid, Literal: "/* open"}},
+		{str: "// c\n1", mode: JSONC, wantPeek: tok.Integer, want: tok.Token{TokenType: tok.Integer, Literal: "1"}},
+		{str: "// c", mode: JSONC, wantPeek: tok.EOF, want: tok.EOFToken},
+		{str: " /*c*/1", mode: JSONC | KeepComments, wantPeek: tok.Comment, want: tok.Token{TokenType: tok.Comment, Literal: "/*c*/"}},
+	}
+
+	for _, test := range tests {
+		l := New(strings.NewReader(test.str))
+		l.SetMode(test.mode)
+
+		peek := l.PeekTokenType()
+		got := l.ReadToken()
+
+		if peek != test.wantPeek || got != test.want {
+			t.Errorf("str: %q, got peek: %v, token: %v, want peek: %v, token: %v", test.str, peek, got, test.wantPeek, test.want)
+		}
+	}
+}
+
+func TestJSON5Tokens(t *testing.T) {
+	str := "{a\\u0062: 'x\\'', $_1: +.5e1, // c\n\"k\": [0x1F, -Infinity, NaN, 5.,],}"
+	want := []tok.Token{
+		tok.OpeningCurlyBraceToken,
+		{TokenType: tok.Identifier, Literal: "ab"},
+		tok.ColonToken,
+		{TokenType: tok.String, Literal: "x'"},
+		tok.CommaToken,
+		{TokenType: tok.Identifier, Literal: "$_1"},
+		tok.ColonToken,
+		{TokenType: tok.FloatingPoint, Literal: "+.5e1"},
+		tok.CommaToken,
+		{TokenType: tok.String, Literal: "k"},
+		tok.ColonToken,
+		tok.OpeningSquareBracketToken,
+		{TokenType: tok.Integer, Literal: "0x1F"},
+		tok.CommaToken,
+		{TokenType: tok.FloatingPoint, Literal: "-Infinity"},
+		tok.CommaToken,
+		{TokenType: tok.FloatingPoint, Literal: "NaN"},
+		tok.CommaToken,
+		{TokenType: tok.FloatingPoint, Literal: "5."},
+		tok.CommaToken,
+		tok.ClosingSquareBracketToken,
+		tok.CommaToken,
+		tok.ClosingCurlyBraceToken,
+	}
+
+	l := New(strings.NewReader(str))
+	l.SetMode(JSON5)
+	var got []tok.Token
+	for tk := l.ReadToken(); tk.TokenType != tok.EOF; tk = l.ReadToken() {
+		got = append(got, tk)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("token %d: got: %v, want: %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJSON5PeekTokenType(t *testing.T) {
+	tests := []struct {
+		str  string
+		want tok.TokenType
+	}{
+		{str: "'a'", want: tok.String},
+		{str: "+1", want: tok.Integer},
+		{str: ".5", want: tok.Integer},
+		{str: " key", want: tok.Identifier},
+		{str: "\\u0061", want: tok.Identifier},
+		{str: "// c null", want: tok.Null},
+		{str: "#", want: tok.Invalid},
+	}
+
+	for _, test := range tests {
+		l := New(strings.NewReader(test.str))
+		l.SetMode(JSON5)
+
+		if got := l.PeekTokenType(); got != test.want {
+			t.Errorf("str: %q, got: %v, want: %v", test.str, got, test.want)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	str := "[1,\n  22,\n\n 333]"
+	l := New(iotest.OneByteReader(strings.NewReader(str)))
+	l.TrackLines()
+
+	type pos struct {
+		literal      string
+		offset       int64
+		line, column int
+	}
+	var got []pos
+	for tk := l.ReadToken(); tk.TokenType != tok.EOF; tk = l.ReadToken() {
+		if tk.TokenType == tok.Integer {
+			line, column := l.Position(l.TokenOffset())
+			got = append(got, pos{tk.Literal, l.TokenOffset(), line, column})
+		}
+	}
+
+	want := []pos{{"1", 1, 1, 2}, {"22", 6, 2, 3}, {"333", 12, 4, 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestLines(t *testing.T) {
+	lines := NewLines([]byte("[1,\n  22,\r\n\n 333]"))
+	tests := []struct {
+		offset       int
+		line, column int
+	}{
+		{offset: 0, line: 1, column: 1},
+		{offset: 3, line: 1, column: 4},
+		{offset: 6, line: 2, column: 3},
+		{offset: 10, line: 2, column: 7},
+		{offset: 11, line: 3, column: 1},
+		{offset: 13, line: 4, column: 2},
+	}
+
+	for _, test := range tests {
+		if line, column := lines.Position(test.offset); line != test.line || column != test.column {
+			t.Errorf("offset: %d, got: %d:%d, want: %d:%d", test.offset, line, column, test.line, test.column)
+		}
+	}
+}
+
+func TestEscapeLen(t *testing.T) {
+	tests := []struct {
+		str  string
+		want int
+	}{
+		{str: `\n`, want: 2},
+		{str: `\"abc`, want: 2},
+		{str: `\u00e9x`, want: 6},
+		{str: `\ud83d\ude00`, want: 6},
+		{str: `\u00g9`, want: 0},
+		{str: `\u00e`, want: 0},
+		{str: `\x`, want: 0},
+		{str: `\`, want: 0},
+		{str: `n`, want: 0},
+	}
+
+	for _, test := range tests {
+		if got := EscapeLen([]byte(test.str)); got != test.want {
+			t.Errorf("str: %q, got: %d, want: %d", test.str, got, test.want)
+		}
+	}
+}
+
+// records what Scan tells it as strings, e.g.: Integer 1-3
+type scanRecorder []string
+
+func (r *scanRecorder) Value(t tok.TokenType, start, end int, escaped bool) error {
+	*r = append(*r, fmt.Sprintf("%s %d-%d %v", t, start, end, escaped))
+	return nil
+}
+
+func (r *scanRecorder) Begin(t tok.TokenType, start int) error {
+	*r = append(*r, fmt.Sprintf("%s %d", t, start))
+	return nil
+}
+
+func (r *scanRecorder) End(t tok.TokenType, end, count int) error {
+	*r = append(*r, fmt.Sprintf("%s %d %d", t, end, count))
+	return nil
+}
+
+func TestScan(t *testing.T) {
+	var got scanRecorder
+	if err := Scan([]byte("{\"a\\n\": [1, -2.5e1],\r\n \"b\": {}, \"c\": null} "), &got); err != nil {
+		t.Fatalf("Scan(): %v", err)
+	}
+	want := scanRecorder{
+		fmt.Sprintf("%s 0", tok.OpeningCurlyBrace),
+		fmt.Sprintf("%s 1-6 true", tok.String),
+		fmt.Sprintf("%s 8", tok.OpeningSquareBracket),
+		fmt.Sprintf("%s 9-10 false", tok.Integer),
+		fmt.Sprintf("%s 12-18 false", tok.FloatingPoint),
+		fmt.Sprintf("%s 19 2", tok.ClosingSquareBracket),
+		fmt.Sprintf("%s 23-26 false", tok.String),
+		fmt.Sprintf("%s 28", tok.OpeningCurlyBrace),
+		fmt.Sprintf("%s 30 0", tok.ClosingCurlyBrace),
+		fmt.Sprintf("%s 32-35 false", tok.String),
+		fmt.Sprintf("%s 37-41 false", tok.Null),
+		fmt.Sprintf("%s 42 3", tok.ClosingCurlyBrace),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{doc: ``, want: `Expected value at offset 0, found: end of document`},
+		{doc: `{"a" 1}`, want: `Expected colon at offset 5, found: '1'`},
+		{doc: `{"a": 1,}`, want: `Expected key at offset 8, found: '}'`},
+		{doc: `[1 2]`, want: `Expected comma or closing square bracket at offset 3, found: '2'`},
+		{doc: `"\x"`, want: `Invalid escape sequence at offset 1`},
+		{doc: `"\u12g4"`, want: `Invalid escape sequence at offset 1`},
+		{doc: `"abc`, want: `Unterminated string beginning at offset 0`},
+		{doc: `01`, want: `Expected end of document at offset 1, found: '1'`},
+		{doc: `1.`, want: `Expected digit at offset 2, found: end of document`},
+		{doc: `nulls`, want: `Expected end of document at offset 4, found: 's'`},
+	}
+
+	for _, test := range tests {
+		var r scanRecorder
+		if err := Scan([]byte(test.doc), &r); err == nil || err.Error() != test.want {
+			t.Errorf("doc: %q, got: %v, want: %s", test.doc, err, test.want)
+		}
+	}
+}
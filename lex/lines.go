@@ -0,0 +1,24 @@
+package lex
+
+import "sort"
+
+// Lines holds the offsets at which the lines of a document begin,
+// for telling the position of an offset in a document read as a whole
+type Lines []int
+
+// NewLines finds the lines of data
+func NewLines(data []byte) Lines {
+	lines := Lines{0}
+	for i, b := range data {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// Position returns the 1-based line and column, counted in bytes, of offset
+func (lines Lines) Position(offset int) (line, column int) {
+	n := sort.Search(len(lines), func(i int) bool { return lines[i] > offset }) - 1
+	return n + 1, offset - lines[n] + 1
+}
@@ -26,61 +26,121 @@ func readNumericToken(r *bufio.Reader) tok.Token {
 
 // readNumericLiteral attempts to read a numeric literal(either integer or floating point) from r
 // consumes only the bytes of the numeric literal, not the byte after
+// the literal may have a fraction and an exponent, e.g.: -12.5e+3
 func readNumericLiteral(r *bufio.Reader) (string, bool) {
+	literal, ok := appendNumericLiteral(nil, r)
+	return string(literal), ok
+}
+
+// like readNumericLiteral, but appends the literal to dst
+func appendNumericLiteral(dst []byte, r *bufio.Reader) ([]byte, bool) {
 	b, err := r.ReadByte()
 	if err != nil {
-		return "", false
+		return dst, false
 	} else if !isDigit(b) && b != '-' {
-		return string(b), false
+		return append(dst, b), false
 	}
 
-	var seenPeriod bool
-	var builder strings.Builder
-	builder.WriteByte(b)
+	var seenPeriod, seenExponent bool
+	dst = append(dst, b)
 	for {
 		b, err := r.ReadByte()
 		if err != nil {
-			return builder.String(), true
+			return dst, true
 		}
 		if b == '.' {
-			if seenPeriod {
-				builder.WriteByte(b)
-				return builder.String(), false
+			if seenPeriod || seenExponent {
+				return append(dst, b), false
 			}
 			seenPeriod = true
+		} else if b == 'e' || b == 'E' {
+			if seenExponent {
+				return append(dst, b), false
+			}
+			seenExponent = true
+			dst = append(dst, b)
+
+			// the exponent may be signed
+			b, err = r.ReadByte()
+			if err != nil {
+				return dst, true
+			}
+			if b != '+' && b != '-' {
+				_ = r.UnreadByte()
+				continue
+			}
 		} else if !isDigit(b) {
 			break
 		}
-		builder.WriteByte(b)
+		dst = append(dst, b)
 	}
 	_ = r.UnreadByte()
 
-	return builder.String(), true
+	return dst, true
+}
+
+// NumericTokenType returns Integer or FloatingPoint for a literal that follows
+// the grammar of JSON numbers, and Invalid for any other literal
+func NumericTokenType(literal string) tok.TokenType {
+	if literal == "" || !validateNumericLiteral(literal) {
+		return tok.Invalid
+	}
+	return numericLiteralTokenType(literal)
 }
 
 // checks the numeric type of the literal, either token.Integer or token.FloatingPoint
 func numericLiteralTokenType(literal string) tok.TokenType {
-	if strings.Contains(literal, ".") {
+	if strings.ContainsAny(literal, ".eE") {
 		return tok.FloatingPoint
 	}
 	return tok.Integer
 }
 
-// validates that the literal does not begin with an illegal 0
-// e.g.: 01, 01.1, -01, -01.1
+// validates that the literal follows the JSON number grammar
+// i.e. it does not begin with an illegal 0 (e.g.: 01, 01.1, -01, -01.1)
+// and every period or exponent is followed by digits (e.g.: 1., 1.e5, 1e+)
 func validateNumericLiteral(literal string) bool {
 	// strip minus for negative number
 	if literal[0] == '-' {
 		literal = literal[1:]
 	}
 
-	if len(literal) > 1 && literal[0] == '0' && literal[1] != '.' {
+	integer := leadingDigits(literal)
+	if integer == 0 || (integer > 1 && literal[0] == '0') {
 		return false
 	}
-	if literal[len(literal)-1] == '.' {
-		return false
+	literal = literal[integer:]
+
+	if len(literal) > 0 && literal[0] == '.' {
+		fraction := leadingDigits(literal[1:])
+		if fraction == 0 {
+			return false
+		}
+		literal = literal[1+fraction:]
+	}
+
+	if len(literal) > 0 && (literal[0] == 'e' || literal[0] == 'E') {
+		literal = literal[1:]
+		if len(literal) > 0 && (literal[0] == '+' || literal[0] == '-') {
+			literal = literal[1:]
+		}
+		exponent := leadingDigits(literal)
+		if exponent == 0 {
+			return false
+		}
+		literal = literal[exponent:]
+	}
+
+	return len(literal) == 0
+}
+
+// returns the number of digits str begins with
+func leadingDigits(str string) int {
+	var n int
+	for n < len(str) && isDigit(str[n]) {
+		n++
 	}
-	return true
+	return n
 }
 
 func isDigit(b byte) bool {
@@ -0,0 +1,236 @@
+package lex
+
+import (
+	"fmt"
+
+	"github.com/vyevs/gojson/tok"
+)
+
+// Visitor is told of the values Scan finds, in the order they appear,
+// the keys of object members are reported as String values before their values
+// the offsets are those of the document scanned
+type Visitor interface {
+	// Value is called with a string, number, boolean or null, e.g.: Integer,
+	// the offsets of its first byte and just past it, and for a string
+	// whether it holds escape sequences, which have been checked to be valid
+	Value(t tok.TokenType, start, end int, escaped bool) error
+
+	// Begin is called at the opening brace or bracket of an object or array,
+	// with its type, OpeningCurlyBrace or OpeningSquareBracket
+	Begin(t tok.TokenType, start int) error
+
+	// End is called at the end of an object or array, with the type of its closing
+	// brace or bracket, the offset just past it and the number of members or elements
+	End(t tok.TokenType, end, count int) error
+}
+
+// Scan checks that data holds a single JSON document, with nothing but
+// whitespace around it, telling v of its values as it goes
+// strings and numbers are checked against the grammar but not decoded
+// the first error found in data, or returned by v, is returned
+func Scan(data []byte, v Visitor) error {
+	s := scanner{data: data, v: v}
+	if err := s.scanValue(); err != nil {
+		return err
+	}
+	s.skipWhitespace()
+	if s.i < len(data) {
+		return s.expected("end of document")
+	}
+	return nil
+}
+
+// scanner scans a document held in memory
+type scanner struct {
+	data []byte
+	i    int
+	v    Visitor
+}
+
+func (s *scanner) skipWhitespace() {
+	for s.i < len(s.data) && isWhitespace(s.data[s.i]) {
+		s.i++
+	}
+}
+
+// describes what is found at the current position, for error messages
+func (s *scanner) found() string {
+	if s.i >= len(s.data) {
+		return "end of document"
+	}
+	return fmt.Sprintf("%q", s.data[s.i])
+}
+
+func (s *scanner) expected(what string) error {
+	return fmt.Errorf("Expected %s at offset %d, found: %s", what, s.i, s.found())
+}
+
+// scans the value beginning at the current position, after any whitespace
+func (s *scanner) scanValue() error {
+	s.skipWhitespace()
+	if s.i >= len(s.data) {
+		return s.expected("value")
+	}
+
+	start := s.i
+	var t tok.TokenType
+	var escaped bool
+	var err error
+	switch b := s.data[s.i]; {
+	case b == '{':
+		return s.scanContainer(tok.OpeningCurlyBrace, tok.ClosingCurlyBrace)
+	case b == '[':
+		return s.scanContainer(tok.OpeningSquareBracket, tok.ClosingSquareBracket)
+	case b == '"':
+		t = tok.String
+		escaped, err = s.scanString()
+	case b == '-' || isDigit(b):
+		t, err = s.scanNumber()
+	case b == 't':
+		t, err = tok.Boolean, s.scanLiteral("true")
+	case b == 'f':
+		t, err = tok.Boolean, s.scanLiteral("false")
+	case b == 'n':
+		t, err = tok.Null, s.scanLiteral("null")
+	default:
+		err = s.expected("value")
+	}
+	if err != nil {
+		return err
+	}
+	return s.v.Value(t, start, s.i, escaped)
+}
+
+// scans an object or an array, whose members are a key, a colon and a value
+func (s *scanner) scanContainer(open, close tok.TokenType) error {
+	if err := s.v.Begin(open, s.i); err != nil {
+		return err
+	}
+	s.i++
+	object := open == tok.OpeningCurlyBrace
+	closing, what := byte(']'), "comma or closing square bracket"
+	if object {
+		closing, what = '}', "comma or closing curly brace"
+	}
+
+	count := 0
+	s.skipWhitespace()
+	if s.i < len(s.data) && s.data[s.i] == closing {
+		s.i++
+		return s.v.End(close, s.i, count)
+	}
+	for {
+		if object {
+			s.skipWhitespace()
+			if s.i >= len(s.data) || s.data[s.i] != '"' {
+				return s.expected("key")
+			}
+			start := s.i
+			escaped, err := s.scanString()
+			if err != nil {
+				return err
+			}
+			if err := s.v.Value(tok.String, start, s.i, escaped); err != nil {
+				return err
+			}
+			s.skipWhitespace()
+			if s.i >= len(s.data) || s.data[s.i] != ':' {
+				return s.expected("colon")
+			}
+			s.i++
+		}
+		if err := s.scanValue(); err != nil {
+			return err
+		}
+		count++
+
+		s.skipWhitespace()
+		if s.i >= len(s.data) {
+			return s.expected(what)
+		}
+		switch s.data[s.i] {
+		case ',':
+			s.i++
+		case closing:
+			s.i++
+			return s.v.End(close, s.i, count)
+		default:
+			return s.expected(what)
+		}
+	}
+}
+
+// scans past the string beginning at the current position,
+// reporting whether it contains escape sequences
+func (s *scanner) scanString() (bool, error) {
+	start := s.i
+	s.i++
+	var escaped bool
+	for s.i < len(s.data) {
+		switch s.data[s.i] {
+		case '"':
+			s.i++
+			return escaped, nil
+		case '\\':
+			n := EscapeLen(s.data[s.i:])
+			if n == 0 {
+				return false, fmt.Errorf("Invalid escape sequence at offset %d", s.i)
+			}
+			escaped = true
+			s.i += n
+		default:
+			s.i++
+		}
+	}
+	return false, fmt.Errorf("Unterminated string beginning at offset %d", start)
+}
+
+// scans past the number beginning at the current position,
+// which follows the grammar of JSON numbers, e.g.: -12.5e+3
+func (s *scanner) scanNumber() (tok.TokenType, error) {
+	t := tok.Integer
+	if s.data[s.i] == '-' {
+		s.i++
+	}
+	if s.i < len(s.data) && s.data[s.i] == '0' {
+		s.i++
+	} else if !s.scanDigits() {
+		return t, s.expected("digit")
+	}
+
+	if s.i < len(s.data) && s.data[s.i] == '.' {
+		t = tok.FloatingPoint
+		s.i++
+		if !s.scanDigits() {
+			return t, s.expected("digit")
+		}
+	}
+	if s.i < len(s.data) && (s.data[s.i] == 'e' || s.data[s.i] == 'E') {
+		t = tok.FloatingPoint
+		s.i++
+		if s.i < len(s.data) && (s.data[s.i] == '+' || s.data[s.i] == '-') {
+			s.i++
+		}
+		if !s.scanDigits() {
+			return t, s.expected("digit")
+		}
+	}
+	return t, nil
+}
+
+// scans past a run of digits, reporting whether there were any
+func (s *scanner) scanDigits() bool {
+	start := s.i
+	for s.i < len(s.data) && isDigit(s.data[s.i]) {
+		s.i++
+	}
+	return s.i > start
+}
+
+func (s *scanner) scanLiteral(literal string) error {
+	if len(s.data)-s.i < len(literal) || string(s.data[s.i:s.i+len(literal)]) != literal {
+		return s.expected(literal)
+	}
+	s.i += len(literal)
+	return nil
+}
@@ -2,7 +2,8 @@ package lex
 
 import (
 	"bufio"
-	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/vyevs/gojson/tok"
 )
@@ -21,17 +22,208 @@ func readStringToken(r *bufio.Reader) tok.Token {
 // attempts to read a string token (literal contained in double quotes)
 // expects the beginning double quote to have been consumed already
 // consumes all bytes up to and including the terminating double quote
-// TODO: DOES NOT CURRENTLY SUPPORT ESCAPE SEQUENCES
+// escape sequences are replaced by the characters they represent
 func readStringLiteral(r *bufio.Reader) (string, bool) {
-	var builder strings.Builder
+	literal, _, ok := appendStringLiteral(nil, nil, r)
+	return string(literal), ok
+}
+
+// like readStringLiteral, but appends the literal to dst
+// the source of a string that has escape sequences, or is not valid, is appended
+// to src, from the opening double quote on, the source of any other string is
+// its literal within double quotes, and src is left as it is
+func appendStringLiteral(dst, src []byte, r *bufio.Reader) ([]byte, []byte, bool) {
+	start := len(dst)
+	escaped := false
 	for {
 		b, err := r.ReadByte()
 		if err != nil {
-			return builder.String(), false
+			if !escaped {
+				src = append(append(src, '"'), dst[start:]...)
+			}
+			return dst, src, false
 		}
 		if b == '"' {
-			return builder.String(), true
+			if escaped {
+				src = append(src, b)
+			}
+			return dst, src, true
+		}
+		if b != '\\' {
+			dst = append(dst, b)
+			if escaped {
+				src = append(src, b)
+			}
+			continue
+		}
+
+		if !escaped {
+			escaped = true
+			src = append(append(src, '"'), dst[start:]...)
+		}
+		// an escape sequence is at most 11 bytes after the backslash, e.g.: ud83d\ude00
+		var sequence [12]byte
+		sequence[0] = b
+		ahead, _ := r.Peek(len(sequence) - 1)
+		copy(sequence[1:], ahead)
+		buffered := r.Buffered()
+		var ok bool
+		dst, ok = readEscapeSequence(r, dst)
+		src = append(src, sequence[:1+buffered-r.Buffered()]...)
+		if !ok {
+			return dst, src, false
+		}
+	}
+}
+
+var escapedBytes = map[byte]byte{
+	'"':  '"',
+	'\\': '\\',
+	'/':  '/',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+}
+
+// reads the escape sequence following a backslash and appends the character
+// it represents to dst, returns false if the sequence is invalid
+// a \u escape of a lone UTF-16 surrogate is replaced by utf8.RuneError
+func readEscapeSequence(r *bufio.Reader, dst []byte) ([]byte, bool) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return dst, false
+	}
+	if escaped, ok := escapedBytes[b]; ok {
+		return append(dst, escaped), true
+	}
+	if b != 'u' {
+		return dst, false
+	}
+
+	r1, ok := readHexRune(r)
+	if !ok {
+		return dst, false
+	}
+	if !utf16.IsSurrogate(r1) {
+		return appendRune(dst, r1), true
+	}
+
+	// a high surrogate must be followed by an escaped low surrogate, anything
+	// else after it is left to be read on its own
+	next, _ := r.Peek(6)
+	if r2, ok := lowSurrogate(r1, next); ok {
+		_, _ = r.Discard(6)
+		return appendRune(dst, utf16.DecodeRune(r1, r2)), true
+	}
+	return appendRune(dst, utf8.RuneError), true
+}
+
+// reads the 4 hex digits of a \u escape sequence
+func readHexRune(r *bufio.Reader) (rune, bool) {
+	digits, _ := r.Peek(4)
+	_, _ = r.Discard(len(digits))
+	return hexRune(digits)
+}
+
+// AppendUnescaped appends the contents of a string literal, without its
+// surrounding double quotes, to dst with escape sequences replaced by the
+// characters they represent, as ReadToken does
+// returns false if str holds an invalid escape sequence
+func AppendUnescaped(dst, str []byte) ([]byte, bool) {
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\\' {
+			dst = append(dst, str[i])
+			continue
+		}
+		i++
+		if i >= len(str) {
+			return dst, false
+		}
+		if escaped, ok := escapedBytes[str[i]]; ok {
+			dst = append(dst, escaped)
+			continue
+		}
+		if str[i] != 'u' {
+			return dst, false
+		}
+
+		r1, ok := hexRune(str[i+1:])
+		if !ok {
+			return dst, false
+		}
+		i += 4
+		if !utf16.IsSurrogate(r1) {
+			dst = appendRune(dst, r1)
+			continue
+		}
+
+		// a high surrogate must be followed by an escaped low surrogate, anything
+		// else after it is left to be read on its own
+		if r2, ok := lowSurrogate(r1, str[i+1:]); ok {
+			dst = appendRune(dst, utf16.DecodeRune(r1, r2))
+			i += 6
+			continue
+		}
+		dst = appendRune(dst, utf8.RuneError)
+	}
+	return dst, true
+}
+
+// decodes the low surrogate escaped at the start of str that pairs with r1,
+// reporting false if r1 is not a high surrogate or str does not begin with one
+func lowSurrogate(r1 rune, str []byte) (rune, bool) {
+	if r1 >= 0xdc00 || len(str) < 6 || str[0] != '\\' || str[1] != 'u' {
+		return 0, false
+	}
+	r2, ok := hexRune(str[2:])
+	return r2, ok && r2 >= 0xdc00 && r2 <= 0xdfff
+}
+
+// decodes the 4 hex digits of a \u escape sequence at the start of str
+func hexRune(str []byte) (rune, bool) {
+	if len(str) < 4 {
+		return 0, false
+	}
+	var out rune
+	for _, b := range str[:4] {
+		var digit byte
+		switch {
+		case b >= '0' && b <= '9':
+			digit = b - '0'
+		case b >= 'a' && b <= 'f':
+			digit = b - 'a' + 10
+		case b >= 'A' && b <= 'F':
+			digit = b - 'A' + 10
+		default:
+			return 0, false
+		}
+		out = out<<4 | rune(digit)
+	}
+	return out, true
+}
+
+func appendRune(dst []byte, r rune) []byte {
+	var scratch [utf8.UTFMax]byte
+	n := utf8.EncodeRune(scratch[:], r)
+	return append(dst, scratch[:n]...)
+}
+
+// EscapeLen returns the length of the valid escape sequence str begins with,
+// 0 if it does not begin with one, a \u escape is counted alone,
+// e.g.: 6 for \ud83d\ude00
+func EscapeLen(str []byte) int {
+	if len(str) < 2 || str[0] != '\\' {
+		return 0
+	}
+	switch str[1] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return 2
+	case 'u':
+		if _, ok := hexRune(str[2:]); ok {
+			return 6
 		}
-		builder.WriteByte(b)
 	}
+	return 0
 }
@@ -0,0 +1,209 @@
+package gojson
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Unmarshaler is implemented by types that decode themselves
+// from the JSON encoding of a value
+//
+// UnmarshalJSON receives the source of the value exactly as it appears
+// in the document, including "null", and must copy it to keep it
+// the method set matches that of encoding/json, so types written for it work as is
+type Unmarshaler interface {
+	UnmarshalJSON(data []byte) error
+}
+
+// Marshaler is implemented by types that produce their own JSON encoding
+//
+// MarshalJSON must return a single valid JSON value,
+// Marshal checks it and removes its insignificant whitespace
+// the method set matches that of encoding/json, so types written for it work as is
+type Marshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// hooks are the interfaces through which a type takes over its own decoding or encoding
+// in order of precedence, types implementing encoding.TextUnmarshaler and
+// encoding.TextMarshaler are decoded from and encoded as strings
+type hooks uint8
+
+const (
+	valueDecoderHook hooks = 1 << iota
+	unmarshalerHook
+	textUnmarshalerHook
+	valueEncoderHook
+	marshalerHook
+	textMarshalerHook
+)
+
+var hookTypes = [...]reflect.Type{
+	reflect.TypeOf((*ValueDecoder)(nil)).Elem(),
+	reflect.TypeOf((*Unmarshaler)(nil)).Elem(),
+	reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem(),
+	reflect.TypeOf((*ValueEncoder)(nil)).Elem(),
+	reflect.TypeOf((*Marshaler)(nil)).Elem(),
+	reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem(),
+}
+
+var hookCache sync.Map // reflect.Type -> hooks
+
+// cachedHooks returns the hooks implemented by t
+func cachedHooks(t reflect.Type) hooks {
+	if h, ok := hookCache.Load(t); ok {
+		return h.(hooks)
+	}
+	var h hooks
+	for i, it := range hookTypes {
+		if t.Implements(it) {
+			h |= 1 << uint(i)
+		}
+	}
+	hookCache.Store(t, h)
+	return h
+}
+
+// receiver returns the value whose methods decode or encode rv, with the hooks it implements
+// addressable values are passed by pointer, which finds methods with pointer receivers
+// and avoids copying rv into an interface
+// other values are copied to be passed by pointer only for EncodeJSON, which gojson-codec
+// generates with a pointer receiver, Marshaler and TextMarshaler methods with pointer
+// receivers are not called on them, as encoding/json does not call them
+func receiver(rv reflect.Value) (interface{}, hooks) {
+	if !rv.CanInterface() {
+		return nil, 0
+	}
+	if rv.CanAddr() {
+		if h := cachedHooks(reflect.PtrTo(rv.Type())); h != 0 {
+			return rv.Addr().Interface(), h
+		}
+		return nil, 0
+	}
+	if h := cachedHooks(rv.Type()); h != 0 {
+		return rv.Interface(), h
+	}
+	if cachedHooks(reflect.PtrTo(rv.Type()))&valueEncoderHook != 0 {
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		return p.Interface(), valueEncoderHook
+	}
+	return nil, 0
+}
+
+// decodeHook decodes the value that begins with t through the hook v implements,
+// it reports false if v implements none
+func decodeHook(l lex.Lexer, t tok.Token, v interface{}, h hooks) (bool, error) {
+	switch {
+	case h&valueDecoderHook != 0:
+		return true, v.(ValueDecoder).DecodeJSON(l, t)
+	case h&unmarshalerHook != 0:
+		data, err := captureValue(l, t)
+		if err != nil {
+			return true, err
+		}
+		return true, v.(Unmarshaler).UnmarshalJSON(data)
+	case h&textUnmarshalerHook != 0:
+		if t.TokenType == tok.Null {
+			return true, nil
+		}
+		str, err := DecodeString(t)
+		if err != nil {
+			return true, err
+		}
+		return true, v.(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+	}
+	return false, nil
+}
+
+// encodeHook writes the encoding of v produced by the hook it implements,
+// it reports false if v implements none
+func encodeHook(buf *bytes.Buffer, v interface{}, h hooks) (bool, error) {
+	switch {
+	case h&valueEncoderHook != 0:
+		return true, v.(ValueEncoder).EncodeJSON(buf)
+	case h&marshalerHook != 0:
+		data, err := v.(Marshaler).MarshalJSON()
+		if err != nil {
+			return true, err
+		}
+		if err := compact(buf, data); err != nil {
+			return true, fmt.Errorf("Invalid JSON from MarshalJSON of %T: %v", v, err)
+		}
+		return true, nil
+	case h&textMarshalerHook != 0:
+		text, err := v.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return true, err
+		}
+		encode.WriteString(buf, string(text))
+		return true, nil
+	}
+	return false, nil
+}
+
+// captureValue reads past the value that begins with first, the token
+// most recently read from l, and returns its source
+func captureValue(l lex.Lexer, first tok.Token) ([]byte, error) {
+	l.StartCapture()
+	err := SkipValue(l, first)
+	data := l.EndCapture()
+	return data, err
+}
+
+// compact writes the single JSON value in data to buf without insignificant whitespace
+func compact(buf *bytes.Buffer, data []byte) error {
+	l := lex.New(bytes.NewReader(data))
+	if err := compactValue(buf, l, l.ReadToken()); err != nil {
+		return err
+	}
+	if eof := l.ReadToken(); eof.TokenType != tok.EOF {
+		return fmt.Errorf("Expected end of value, found: %q", eof.Literal)
+	}
+	return nil
+}
+
+func compactValue(buf *bytes.Buffer, l lex.Lexer, first tok.Token) error {
+	switch first.TokenType {
+	case tok.OpeningCurlyBrace:
+		buf.WriteByte('{')
+		n := 0
+		err := DecodeObject(l, first, func(key string, t tok.Token) error {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			n++
+			encode.WriteString(buf, key)
+			buf.WriteByte(':')
+			return compactValue(buf, l, t)
+		})
+		buf.WriteByte('}')
+		return err
+	case tok.OpeningSquareBracket:
+		buf.WriteByte('[')
+		n := 0
+		err := DecodeArray(l, first, func(t tok.Token) error {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			n++
+			return compactValue(buf, l, t)
+		})
+		buf.WriteByte(']')
+		return err
+	case tok.String:
+		encode.WriteString(buf, first.Literal)
+	case tok.Integer, tok.FloatingPoint, tok.Boolean, tok.Null:
+		buf.WriteString(first.Literal)
+	default:
+		return unexpected("value", first)
+	}
+	return nil
+}
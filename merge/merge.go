@@ -0,0 +1,290 @@
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/patch"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// MergePatch applies the merge patch p to target as described by RFC 7386
+// (JSON Merge Patch) and returns the result
+//
+// members of a patch object replace the corresponding members of target,
+// objects are merged recursively and a null member removes the key from target
+// any patch that isn't an object replaces target entirely
+// neither target nor p is modified, and the result shares no objects or arrays with them
+func MergePatch(target, p interface{}) interface{} {
+	patchObj, ok := p.(map[string]interface{})
+	if !ok {
+		return patch.DeepCopy(p)
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	out := make(map[string]interface{}, len(targetObj))
+	for k, v := range targetObj {
+		out[k] = patch.DeepCopy(v)
+	}
+
+	for k, v := range patchObj {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		out[k] = MergePatch(out[k], v)
+	}
+	return out
+}
+
+// ArrayStrategy determines how DeepMerge combines two arrays found at the same path
+type ArrayStrategy struct {
+	mode arrayMode
+	key  string
+}
+
+type arrayMode int
+
+const (
+	replaceMode arrayMode = iota
+	appendMode
+	byKeyMode
+)
+
+var (
+	// ReplaceArrays makes the array from src replace the one from dst, the default
+	ReplaceArrays = ArrayStrategy{mode: replaceMode}
+	// AppendArrays appends the elements of the array from src to the one from dst
+	AppendArrays = ArrayStrategy{mode: appendMode}
+)
+
+// MergeArraysByKey treats both arrays as collections of objects identified by
+// their field member, objects of src are deep merged into the object of dst that
+// has an equal field, objects without a match in dst are appended
+// elements that aren't objects or don't have the field are always appended
+func MergeArraysByKey(field string) ArrayStrategy {
+	return ArrayStrategy{mode: byKeyMode, key: field}
+}
+
+// ConflictFunc decides the merged value at path when dst and src hold values
+// there that can't be merged, i.e. they are not both objects or both arrays,
+// and they are not equal
+// returning an error aborts the whole merge
+type ConflictFunc func(path pointer.Pointer, dst, src interface{}) (interface{}, error)
+
+// Options configures DeepMerge
+type Options struct {
+	// Arrays maps JSON Pointers to the strategy used for arrays found there
+	// a "*" token in a pointer matches any single key or index,
+	// e.g. "/servers/*/ports" matches the ports of every server
+	// an exact pointer is preferred over patterns, and patterns with
+	// fewer wildcards are preferred over ones with more
+	Arrays map[string]ArrayStrategy
+
+	// DefaultArrays is used for arrays at paths not found in Arrays
+	// the zero value is ReplaceArrays
+	DefaultArrays ArrayStrategy
+
+	// Conflict is called for every conflicting value
+	// if nil, the value from src wins
+	Conflict ConflictFunc
+}
+
+// DeepMerge merges src into dst and returns the result, src takes precedence
+//
+// objects are merged member by member, recursively
+// arrays are combined according to the ArrayStrategy configured for their path
+// other values found at the same path in both are resolved by opts.Conflict
+// neither dst nor src is modified
+// e.g. layering configs: DeepMerge(DeepMerge(defaults, env, opts), user, opts)
+func DeepMerge(dst, src interface{}, opts Options) (interface{}, error) {
+	m := merger{opts: opts, patterns: compilePatterns(opts.Arrays)}
+	return m.merge(pointer.Pointer{}, dst, src)
+}
+
+type merger struct {
+	opts     Options
+	patterns []pattern
+}
+
+func (m merger) merge(path pointer.Pointer, dst, src interface{}) (interface{}, error) {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		if d, ok := dst.(map[string]interface{}); ok {
+			return m.mergeObjects(path, d, s)
+		}
+	case []interface{}:
+		if d, ok := dst.([]interface{}); ok {
+			return m.mergeArrays(path, d, s)
+		}
+	}
+
+	if patch.Equal(dst, src) {
+		return src, nil
+	}
+	if m.opts.Conflict == nil {
+		return patch.DeepCopy(src), nil
+	}
+	v, err := m.opts.Conflict(path, dst, src)
+	if err != nil {
+		return nil, fmt.Errorf("Conflict at %q: %v", path.String(), err)
+	}
+	return v, nil
+}
+
+func (m merger) mergeObjects(path pointer.Pointer, dst, src map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = patch.DeepCopy(v)
+	}
+	// sorted so that the conflict callback sees paths in a stable order
+	for _, k := range encode.SortedKeys(src) {
+		existing, ok := out[k]
+		if !ok {
+			out[k] = patch.DeepCopy(src[k])
+			continue
+		}
+		v, err := m.merge(path.Append(k), existing, src[k])
+		if err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (m merger) mergeArrays(path pointer.Pointer, dst, src []interface{}) ([]interface{}, error) {
+	strategy := m.strategyFor(path)
+	switch strategy.mode {
+	case appendMode:
+		out := make([]interface{}, 0, len(dst)+len(src))
+		for _, v := range dst {
+			out = append(out, patch.DeepCopy(v))
+		}
+		for _, v := range src {
+			out = append(out, patch.DeepCopy(v))
+		}
+		return out, nil
+	case byKeyMode:
+		return m.mergeArraysByKey(path, dst, src, strategy.key)
+	default:
+		return patch.DeepCopy(src).([]interface{}), nil
+	}
+}
+
+func (m merger) mergeArraysByKey(path pointer.Pointer, dst, src []interface{}, field string) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(dst)+len(src))
+	// position in out of the dst element with each encoded key
+	positions := map[string]int{}
+	for _, v := range dst {
+		if key, ok := keyOf(v, field); ok {
+			if _, seen := positions[key]; !seen {
+				positions[key] = len(out)
+			}
+		}
+		out = append(out, patch.DeepCopy(v))
+	}
+
+	for _, v := range src {
+		key, ok := keyOf(v, field)
+		if !ok {
+			out = append(out, patch.DeepCopy(v))
+			continue
+		}
+		i, ok := positions[key]
+		if !ok {
+			positions[key] = len(out)
+			out = append(out, patch.DeepCopy(v))
+			continue
+		}
+		merged, err := m.merge(path.AppendIndex(i), out[i], v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = merged
+	}
+	return out, nil
+}
+
+// returns the encoding of the field member of v, which identifies v
+// when merging arrays by key
+func keyOf(v interface{}, field string) (string, bool) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	key, ok := obj[field]
+	if !ok {
+		return "", false
+	}
+	encoded, err := encode.Marshal(key)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+func (m merger) strategyFor(path pointer.Pointer) ArrayStrategy {
+	if s, ok := m.opts.Arrays[path.String()]; ok {
+		return s
+	}
+	for _, p := range m.patterns {
+		if p.matches(path) {
+			return m.opts.Arrays[p.str]
+		}
+	}
+	return m.opts.DefaultArrays
+}
+
+// pattern is a JSON Pointer from Options.Arrays that contains wildcards
+type pattern struct {
+	str       string
+	ptr       pointer.Pointer
+	wildcards int
+}
+
+func (p pattern) matches(path pointer.Pointer) bool {
+	if len(p.ptr) != len(path) {
+		return false
+	}
+	for i, t := range p.ptr {
+		if t != "*" && t != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// collects the wildcard patterns of arrays, most specific first
+// keys that aren't valid JSON Pointers can never match and are ignored
+func compilePatterns(arrays map[string]ArrayStrategy) []pattern {
+	var out []pattern
+	for str := range arrays {
+		if !strings.Contains(str, "*") {
+			continue
+		}
+		ptr, err := pointer.Parse(str)
+		if err != nil {
+			continue
+		}
+		var wildcards int
+		for _, t := range ptr {
+			if t == "*" {
+				wildcards++
+			}
+		}
+		out = append(out, pattern{str: str, ptr: ptr, wildcards: wildcards})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].wildcards != out[j].wildcards {
+			return out[i].wildcards < out[j].wildcards
+		}
+		return out[i].str < out[j].str
+	})
+	return out
+}
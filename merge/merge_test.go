@@ -0,0 +1,171 @@
+package merge
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/patch"
+	"github.com/vyevs/gojson/pointer"
+)
+
+func mustParse(t *testing.T, str string) interface{} {
+	v, err := parse.Parse(strings.NewReader(str))
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", str, err)
+	}
+	return v
+}
+
+// cases from Appendix A of RFC 7386
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{target: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{target: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{target: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{target: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{target: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{
+			target: `{"a": {"b": "c"}}`,
+			patch:  `{"a": {"b": "d", "c": null}}`,
+			want:   `{"a": {"b": "d"}}`,
+		},
+		{target: `{"a": [{"b":"c"}]}`, patch: `{"a": [1]}`, want: `{"a": [1]}`},
+		{target: `["a","b"]`, patch: `["c","d"]`, want: `["c","d"]`},
+		{target: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{target: `{"a":"foo"}`, patch: `null`, want: `null`},
+		{target: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{target: `{"e":null}`, patch: `{"a":1}`, want: `{"e":null,"a":1}`},
+		{target: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{target: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		target := mustParse(t, test.target)
+		p := mustParse(t, test.patch)
+
+		got := MergePatch(target, p)
+
+		if !patch.Equal(got, mustParse(t, test.want)) {
+			t.Errorf("target: %s, patch: %s, got: %v, want: %s", test.target, test.patch, got, test.want)
+		}
+		if !patch.Equal(target, mustParse(t, test.target)) {
+			t.Errorf("target: %s, patch: %s, target was modified to: %v", test.target, test.patch, target)
+		}
+	}
+}
+
+func TestMergePatchCopiesTarget(t *testing.T) {
+	target := mustParse(t, `{"a": {"b": [1, 2]}, "c": 3}`)
+
+	got := MergePatch(target, mustParse(t, `{"c": 4}`)).(map[string]interface{})
+	got["a"].(map[string]interface{})["b"].([]interface{})[0] = 5
+
+	if !patch.Equal(target, mustParse(t, `{"a": {"b": [1, 2]}, "c": 3}`)) {
+		t.Errorf("target was modified through the result to: %v", target)
+	}
+}
+
+func TestDeepMerge(t *testing.T) {
+	tests := []struct {
+		dst, src string
+		opts     Options
+		want     string
+	}{
+		{
+			dst:  `{"a": 1, "b": {"c": 1, "d": 2}}`,
+			src:  `{"b": {"d": 3, "e": 4}, "f": null}`,
+			want: `{"a": 1, "b": {"c": 1, "d": 3, "e": 4}, "f": null}`,
+		},
+		{
+			dst:  `{"tags": ["a", "b"]}`,
+			src:  `{"tags": ["c"]}`,
+			want: `{"tags": ["c"]}`,
+		},
+		{
+			dst:  `{"tags": ["a", "b"]}`,
+			src:  `{"tags": ["c"]}`,
+			opts: Options{DefaultArrays: AppendArrays},
+			want: `{"tags": ["a", "b", "c"]}`,
+		},
+		{
+			dst:  `{"tags": ["a"], "hosts": ["x"]}`,
+			src:  `{"tags": ["b"], "hosts": ["y"]}`,
+			opts: Options{Arrays: map[string]ArrayStrategy{"/tags": AppendArrays}},
+			want: `{"tags": ["a", "b"], "hosts": ["y"]}`,
+		},
+		{
+			dst: `{"servers": [{"name": "a", "port": 80, "tls": false}, {"name": "b", "port": 81}]}`,
+			src: `{"servers": [{"name": "b", "port": 8081}, {"name": "c", "port": 82}, "stray"]}`,
+			opts: Options{
+				Arrays: map[string]ArrayStrategy{"/servers": MergeArraysByKey("name")},
+			},
+			want: `{"servers": [{"name": "a", "port": 80, "tls": false}, {"name": "b", "port": 8081}, {"name": "c", "port": 82}, "stray"]}`,
+		},
+		{
+			dst: `{"servers": [{"name": "a", "ports": [80]}, {"name": "b", "ports": [81]}]}`,
+			src: `{"servers": [{"name": "a", "ports": [443]}]}`,
+			opts: Options{
+				Arrays: map[string]ArrayStrategy{
+					"/servers":         MergeArraysByKey("name"),
+					"/servers/*/ports": AppendArrays,
+				},
+			},
+			want: `{"servers": [{"name": "a", "ports": [80, 443]}, {"name": "b", "ports": [81]}]}`,
+		},
+		{
+			dst: `{"a": {"b": [1]}}`,
+			src: `{"a": {"b": [2]}}`,
+			opts: Options{
+				Arrays: map[string]ArrayStrategy{
+					"/*/*": ReplaceArrays,
+					"/a/*": AppendArrays,
+				},
+			},
+			want: `{"a": {"b": [1, 2]}}`,
+		},
+		{
+			dst: `{"a": 1, "b": "x", "c": {"d": true}}`,
+			src: `{"a": 2, "b": "x", "c": [1]}`,
+			opts: Options{
+				Conflict: func(path pointer.Pointer, dst, src interface{}) (interface{}, error) {
+					return path.String(), nil
+				},
+			},
+			want: `{"a": "/a", "b": "x", "c": "/c"}`,
+		},
+	}
+
+	for _, test := range tests {
+		dst, src := mustParse(t, test.dst), mustParse(t, test.src)
+
+		got, err := DeepMerge(dst, src, test.opts)
+
+		if err != nil || !patch.Equal(got, mustParse(t, test.want)) {
+			t.Errorf("dst: %s, src: %s, got: %v, want: %s, err: %v", test.dst, test.src, got, test.want, err)
+		}
+		if !patch.Equal(dst, mustParse(t, test.dst)) || !patch.Equal(src, mustParse(t, test.src)) {
+			t.Errorf("dst: %s, src: %s, inputs were modified", test.dst, test.src)
+		}
+	}
+}
+
+func TestDeepMergeConflictError(t *testing.T) {
+	dst := mustParse(t, `{"a": {"b": 1}}`)
+	src := mustParse(t, `{"a": {"b": 2}}`)
+	errConflict := errors.New("keep dst")
+	opts := Options{
+		Conflict: func(path pointer.Pointer, dst, src interface{}) (interface{}, error) {
+			return nil, errConflict
+		},
+	}
+
+	got, err := DeepMerge(dst, src, opts)
+	if err == nil || !strings.Contains(err.Error(), `"/a/b"`) {
+		t.Errorf("got: %v, err: %v, want error mentioning /a/b", got, err)
+	}
+}
@@ -0,0 +1,59 @@
+package parse
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/vyevs/gojson/tok"
+)
+
+// returns the key of an object member that t holds, reporting false if it holds none
+// the keys of JSON5 documents may also be identifiers, including those
+// the lexer reads as literals, e.g.: {null: 1, NaN: 2}
+func (p *parser) key(t tok.Token) (string, bool) {
+	switch {
+	case t.TokenType == tok.String:
+		return t.Literal, true
+	case !p.json5:
+		return "", false
+	case t.TokenType == tok.Identifier:
+		return t.Literal, true
+	case t.TokenType == tok.Boolean || t.TokenType == tok.Null:
+		return t.Literal, true
+	case t.TokenType == tok.FloatingPoint:
+		return t.Literal, t.Literal == "Infinity" || t.Literal == "NaN"
+	}
+	return "", false
+}
+
+// parses a JSON5 integer, which may be signed with + and hexadecimal
+// the lexer has checked its grammar, so the prefixes for the other bases
+// strconv.ParseInt accepts, and its underscores, do not reach it
+// an integer too big for an int is a float64, as every JSON5 number is a double
+func parseJSON5Integer(lit string) (interface{}, error) {
+	v, err := strconv.ParseInt(lit, 0, 64)
+	if err == nil && int64(int(v)) == v {
+		return int(v), nil
+	}
+	if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+		return nil, fmt.Errorf("Invalid value found: %q", lit)
+	}
+	n, ok := new(big.Int).SetString(lit, 0)
+	if !ok {
+		return nil, fmt.Errorf("Invalid value found: %q", lit)
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f, nil
+}
+
+// parses a JSON5 floating point number, which may be Infinity or NaN,
+// either of them signed
+func parseJSON5FloatingPoint(lit string) (float64, error) {
+	if strings.HasSuffix(lit, "NaN") {
+		return math.NaN(), nil
+	}
+	return parseFloatingPoint(lit)
+}
@@ -0,0 +1,244 @@
+package parse
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// the cases of the official JSON5 test suite, https://github.com/json5/json5-tests,
+// transcribed rather than vendored, named by their files in it
+// by the conventions of the suite, .json and .json5 files are valid,
+// .js files are valid ECMAScript but not JSON5, and .txt files are not valid at all
+var json5Suite = []struct {
+	name string
+	doc  string
+	want interface{}
+}{
+	// arrays
+	{name: "arrays/empty-array.json", doc: `[]`, want: []interface{}{}},
+	{name: "arrays/leading-comma-array.js", doc: "[\n    ,null\n]"},
+	{name: "arrays/lone-trailing-comma-array.js", doc: "[\n    ,\n]"},
+	{name: "arrays/no-comma-array.txt", doc: "[\n    true\n    false\n]"},
+	{name: "arrays/regular-array.json", doc: "[\n    true,\n    false,\n    null\n]", want: []interface{}{true, false, nil}},
+	{name: "arrays/trailing-comma-array.json5", doc: "[\n    null,\n]", want: []interface{}{nil}},
+
+	// comments
+	{name: "comments/block-comment-following-array-element.json5", doc: "[\n    false\n    /*\n        true\n    */\n]", want: []interface{}{false}},
+	{name: "comments/block-comment-following-top-level-value.json5", doc: "null\n/*\n    Some non-comment top-level value is needed;\n    we use null above.\n*/", want: nil},
+	{name: "comments/block-comment-in-string.json", doc: `"This /* block comment */ isn't really a block comment."`, want: "This /* block comment */ isn't really a block comment."},
+	{name: "comments/block-comment-preceding-top-level-value.json5", doc: "/*\n    Some non-comment top-level value is needed;\n    we use null below.\n*/\nnull", want: nil},
+	{name: "comments/block-comment-with-asterisks.json5", doc: "/**\n * This is a JavaDoc-like block comment.\n * It contains asterisks inside of it.\n * It might also be closed with multiple asterisks.\n * Like this:\n **/\ntrue", want: true},
+	{name: "comments/inline-comment-following-array-element.json5", doc: "[\n    false   // true\n]", want: []interface{}{false}},
+	{name: "comments/inline-comment-following-top-level-value.json5", doc: "null // Some non-comment top-level value is needed; we use null here.", want: nil},
+	{name: "comments/inline-comment-in-string.json", doc: `"This inline comment // isn't really an inline comment."`, want: "This inline comment // isn't really an inline comment."},
+	{name: "comments/inline-comment-preceding-top-level-value.json5", doc: "// Some non-comment top-level value is needed; we use null below.\nnull", want: nil},
+	{name: "comments/top-level-block-comment.txt", doc: "/*\n    This should fail;\n    comments cannot be the only top-level value.\n*/"},
+	{name: "comments/top-level-inline-comment.txt", doc: "// This should fail; comments cannot be the only top-level value."},
+	{name: "comments/unterminated-block-comment.txt", doc: "true\n/*\n    This block comment doesn't terminate.\n    There was a legitimate value before this,\n    but this is still invalid JS/JSON5.\n"},
+
+	// misc
+	{name: "misc/empty.txt", doc: ``},
+	{name: "misc/readme-example.json5", doc: `{
+    foo: 'bar',
+    while: true,
+
+    this: 'is a \
+multi-line string',
+
+    // this is an inline comment
+    here: 'is another', // inline comment
+
+    /* this is a block comment
+       that continues on another line */
+
+    hex: 0xDEADbeef,
+    half: .5,
+    delta: +10,
+    to: Infinity,   // and beyond!
+
+    finally: 'a trailing comma',
+    oh: [
+        "we shouldn't forget",
+        'arrays can have',
+        'trailing commas too',
+    ],
+}`, want: map[string]interface{}{
+		"foo":     "bar",
+		"while":   true,
+		"this":    "is a multi-line string",
+		"here":    "is another",
+		"hex":     0xDEADbeef,
+		"half":    .5,
+		"delta":   10,
+		"to":      math.Inf(1),
+		"finally": "a trailing comma",
+		"oh":      []interface{}{"we shouldn't forget", "arrays can have", "trailing commas too"},
+	}},
+	{name: "misc/valid-whitespace.json5", doc: "{\n    \f   // An invalid form feed character (\\x0c) has been entered before this comment.\n    // Be careful not to delete it.\n  \"a\": true\n}\n", want: map[string]interface{}{"a": true}},
+
+	// new-lines
+	{name: "new-lines/comment-cr.json5", doc: "{\r    // This comment is terminated with `\\r`.\r}\r", want: map[string]interface{}{}},
+	{name: "new-lines/comment-crlf.json5", doc: "{\r\n    // This comment is terminated with `\\r\\n`.\r\n}\r\n", want: map[string]interface{}{}},
+	{name: "new-lines/comment-lf.json5", doc: "{\n    // This comment is terminated with `\\n`.\n}\n", want: map[string]interface{}{}},
+	{name: "new-lines/escaped-cr.json5", doc: "{\r    // the following string contains an escaped `\\r`\r    a: 'line 1 \\\rline 2'\r}\r", want: map[string]interface{}{"a": "line 1 line 2"}},
+	{name: "new-lines/escaped-crlf.json5", doc: "{\r\n    // the following string contains an escaped `\\r\\n`\r\n    a: 'line 1 \\\r\nline 2'\r\n}\r\n", want: map[string]interface{}{"a": "line 1 line 2"}},
+	{name: "new-lines/escaped-lf.json5", doc: "{\n    // the following string contains an escaped `\\n`\n    a: 'line 1 \\\nline 2'\n}\n", want: map[string]interface{}{"a": "line 1 line 2"}},
+
+	// numbers
+	{name: "numbers/float-leading-decimal-point.json5", doc: `.5`, want: .5},
+	{name: "numbers/float-leading-zero.json", doc: `0.5`, want: .5},
+	{name: "numbers/float-trailing-decimal-point-with-integer-exponent.json5", doc: `5.e4`, want: 5e4},
+	{name: "numbers/float-trailing-decimal-point.json5", doc: `5.`, want: 5.0},
+	{name: "numbers/float-with-integer-exponent.json", doc: `1.2e3`, want: 1.2e3},
+	{name: "numbers/float.json", doc: `1.2`, want: 1.2},
+	{name: "numbers/hexadecimal-empty.txt", doc: `0x`},
+	{name: "numbers/hexadecimal-lowercase-letter.json5", doc: `0xc8`, want: 200},
+	{name: "numbers/hexadecimal-uppercase-x.json5", doc: `0XC8`, want: 200},
+	{name: "numbers/hexadecimal-with-integer-exponent.json5", doc: `0xc8e4`, want: 0xc8e4},
+	{name: "numbers/hexadecimal.json5", doc: `0xC8`, want: 200},
+	{name: "numbers/infinity.json5", doc: `Infinity`, want: math.Inf(1)},
+	{name: "numbers/integer-with-float-exponent.txt", doc: `1e2.3`},
+	{name: "numbers/integer-with-hexadecimal-exponent.txt", doc: `1e0x4`},
+	{name: "numbers/integer-with-integer-exponent.json", doc: `2e23`, want: 2e23},
+	{name: "numbers/integer-with-negative-float-exponent.txt", doc: `1e-2.3`},
+	{name: "numbers/integer-with-negative-hexadecimal-exponent.txt", doc: `1e-0x4`},
+	{name: "numbers/integer-with-negative-integer-exponent.json", doc: `2e-23`, want: 2e-23},
+	{name: "numbers/integer-with-negative-zero-integer-exponent.json", doc: `5e-0`, want: 5.0},
+	{name: "numbers/integer-with-positive-float-exponent.txt", doc: `1e+2.3`},
+	{name: "numbers/integer-with-positive-hexadecimal-exponent.txt", doc: `1e+0x4`},
+	{name: "numbers/integer-with-positive-integer-exponent.json", doc: `1e+2`, want: 100.0},
+	{name: "numbers/integer-with-positive-zero-integer-exponent.json", doc: `5e+0`, want: 5.0},
+	{name: "numbers/integer-with-zero-integer-exponent.json", doc: `5e0`, want: 5.0},
+	{name: "numbers/integer.json", doc: `15`, want: 15},
+	{name: "numbers/lone-decimal-point.txt", doc: `.`},
+	{name: "numbers/nan.json5", doc: `NaN`, want: math.NaN()},
+	{name: "numbers/negative-float-leading-decimal-point.json5", doc: `-.5`, want: -.5},
+	{name: "numbers/negative-float-leading-zero.json", doc: `-0.5`, want: -.5},
+	{name: "numbers/negative-float-trailing-decimal-point.json5", doc: `-5.`, want: -5.0},
+	{name: "numbers/negative-float.json", doc: `-1.2`, want: -1.2},
+	{name: "numbers/negative-hexadecimal.json5", doc: `-0xC8`, want: -200},
+	{name: "numbers/negative-infinity.json5", doc: `-Infinity`, want: math.Inf(-1)},
+	{name: "numbers/negative-integer.json", doc: `-15`, want: -15},
+	{name: "numbers/negative-noctal.js", doc: `-098`},
+	{name: "numbers/negative-octal.txt", doc: `-0123`},
+	{name: "numbers/negative-zero-float-leading-decimal-point.json5", doc: `-.0`, want: math.Copysign(0, -1)},
+	{name: "numbers/negative-zero-float-trailing-decimal-point.json5", doc: `-0.`, want: math.Copysign(0, -1)},
+	{name: "numbers/negative-zero-float.json", doc: `-0.0`, want: math.Copysign(0, -1)},
+	{name: "numbers/negative-zero-hexadecimal.json5", doc: `-0x0`, want: 0},
+	{name: "numbers/negative-zero-integer.json", doc: `-0`, want: 0},
+	{name: "numbers/negative-zero-octal.txt", doc: `-00`},
+	{name: "numbers/noctal-with-leading-octal-digit.js", doc: `0780`},
+	{name: "numbers/noctal.js", doc: `080`},
+	{name: "numbers/octal.txt", doc: `010`},
+	{name: "numbers/positive-float-leading-decimal-point.json5", doc: `+.5`, want: .5},
+	{name: "numbers/positive-float-leading-zero.json5", doc: `+0.5`, want: .5},
+	{name: "numbers/positive-float-trailing-decimal-point.json5", doc: `+5.`, want: 5.0},
+	{name: "numbers/positive-float.json5", doc: `+1.2`, want: 1.2},
+	{name: "numbers/positive-hexadecimal.json5", doc: `+0xC8`, want: 200},
+	{name: "numbers/positive-infinity.json5", doc: `+Infinity`, want: math.Inf(1)},
+	{name: "numbers/positive-integer.json5", doc: `+15`, want: 15},
+	{name: "numbers/positive-noctal.js", doc: `+098`},
+	{name: "numbers/positive-octal.txt", doc: `+0123`},
+	{name: "numbers/positive-zero-float-leading-decimal-point.json5", doc: `+.0`, want: 0.0},
+	{name: "numbers/positive-zero-float-trailing-decimal-point.json5", doc: `+0.`, want: 0.0},
+	{name: "numbers/positive-zero-float.json5", doc: `+0.0`, want: 0.0},
+	{name: "numbers/positive-zero-hexadecimal.json5", doc: `+0x0`, want: 0},
+	{name: "numbers/positive-zero-integer.json5", doc: `+0`, want: 0},
+	{name: "numbers/positive-zero-octal.txt", doc: `+00`},
+	{name: "numbers/zero-float-leading-decimal-point.json5", doc: `.0`, want: 0.0},
+	{name: "numbers/zero-float-trailing-decimal-point.json5", doc: `0.`, want: 0.0},
+	{name: "numbers/zero-float.json", doc: `0.0`, want: 0.0},
+	{name: "numbers/zero-hexadecimal.json5", doc: `0x0`, want: 0},
+	{name: "numbers/zero-integer-with-integer-exponent.json", doc: `0e23`, want: 0.0},
+	{name: "numbers/zero-integer.json", doc: `0`, want: 0},
+	{name: "numbers/zero-octal.txt", doc: `00`},
+
+	// objects
+	{name: "objects/duplicate-keys.json", doc: "{\n    \"a\": true,\n    \"a\": false\n}", want: map[string]interface{}{"a": false}},
+	{name: "objects/empty-object.json", doc: `{}`, want: map[string]interface{}{}},
+	{name: "objects/illegal-unquoted-key-number.txt", doc: "{\n    10twenty: \"ten twenty\"\n}"},
+	{name: "objects/illegal-unquoted-key-symbol.txt", doc: "{\n    multi-word: \"multi-word\"\n}"},
+	{name: "objects/leading-comma-object.txt", doc: "{\n    ,\"foo\": \"bar\"\n}"},
+	{name: "objects/lone-trailing-comma-object.txt", doc: "{\n    ,\n}"},
+	{name: "objects/no-comma-object.txt", doc: "{\n    \"foo\": \"bar\"\n    \"hello\": \"world\"\n}"},
+	{name: "objects/reserved-unquoted-key.json5", doc: "{\n    while: true\n}", want: map[string]interface{}{"while": true}},
+	{name: "objects/single-quoted-key.json5", doc: "{\n    'hello': \"world\"\n}", want: map[string]interface{}{"hello": "world"}},
+	{name: "objects/trailing-comma-object.json5", doc: "{\n    \"foo\": \"bar\",\n}", want: map[string]interface{}{"foo": "bar"}},
+	{name: "objects/unquoted-keys.json5", doc: "{\n    hello: \"world\",\n    _: \"underscore\",\n    $: \"dollar sign\",\n    one1: \"numerals\",\n    _$_: \"multiple symbols\",\n    $_$hello123world_$_: \"mixed\"\n}", want: map[string]interface{}{
+		"hello": "world", "_": "underscore", "$": "dollar sign", "one1": "numerals", "_$_": "multiple symbols", "$_$hello123world_$_": "mixed",
+	}},
+
+	// strings
+	{name: "strings/escaped-single-quoted-string.json5", doc: `'I can\'t wait'`, want: "I can't wait"},
+	{name: "strings/multi-line-string.json5", doc: "'hello\\\n world'", want: "hello world"},
+	{name: "strings/single-quoted-string.json5", doc: `'hello world'`, want: "hello world"},
+	{name: "strings/unescaped-multi-line-string.txt", doc: "\"foo\nbar\""},
+}
+
+func TestParseJSON5Suite(t *testing.T) {
+	for _, test := range json5Suite {
+		got, err := ParseWithOptions(strings.NewReader(test.doc), Options{JSON5: true})
+
+		valid := strings.HasSuffix(test.name, ".json") || strings.HasSuffix(test.name, ".json5")
+		if !valid {
+			if err == nil {
+				t.Errorf("%s: got: %v, want an error", test.name, got)
+			}
+			continue
+		}
+		if err != nil || !json5Equal(got, test.want) {
+			t.Errorf("%s: got: %v, want: %v, err: %v", test.name, got, test.want, err)
+		}
+	}
+}
+
+// like equal, but NaN equals NaN, and negative zero does not equal zero
+func json5Equal(v1, v2 interface{}) bool {
+	f1, ok1 := v1.(float64)
+	f2, ok2 := v2.(float64)
+	if ok1 && ok2 {
+		return f1 == f2 && math.Signbit(f1) == math.Signbit(f2) || math.IsNaN(f1) && math.IsNaN(f2)
+	}
+	return equal(v1, v2)
+}
+
+func TestParseJSON5(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want interface{}
+	}{
+		{doc: `{'a': "it's", b: 'say "hi"'}`, want: map[string]interface{}{"a": "it's", "b": `say "hi"`}},
+		{doc: `'\x41é\0\v\/\q😀'`, want: "Aé\x00\v/q\U0001F600"},
+		{doc: "'a\\ b\\ c '", want: "abc "},
+		{doc: `{ab: 1, café: 2, λx: 3}`, want: map[string]interface{}{"ab": 1, "café": 2, "λx": 3}},
+		{doc: "\ufeff\v[1,\u00a0\u2028\f2]\u3000", want: []interface{}{1, 2}},
+		{doc: "[1 // c , 2]", want: []interface{}{1, 2}},
+		{doc: `-NaN`, want: math.NaN()},
+		{doc: `+NaN`, want: math.NaN()},
+		{doc: `-0x7fffffffffffffff`, want: -0x7fffffffffffffff},
+		{doc: `[18446744073709551616, -0x8000000000000001, +0x10000000000000000]`, want: []interface{}{1.8446744073709552e19, -9.223372036854776e18, 1.8446744073709552e19}},
+		{doc: `0x1fffffffffffffffff`, want: 5.902958103587057e20},
+		{doc: `{null: 1, Infinity: 2}`, want: map[string]interface{}{"null": 1, "Infinity": 2}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseWithOptions(strings.NewReader(test.doc), Options{JSON5: true})
+
+		if err != nil || !json5Equal(got, test.want) {
+			t.Errorf("doc: %q, got: %v, want: %v, err: %v", test.doc, got, test.want, err)
+		}
+	}
+
+	// JSON5 stays off unless asked for, and the rest of ECMAScript is not accepted
+	for _, doc := range []string{`{a: 1}`, `'a'`, `0x1`, `+1`, `Infinity`} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("doc: %q, Parse() did not fail", doc)
+		}
+	}
+	for _, doc := range []string{`'\1'`, `'\01'`, `'\x4'`, `0x1g`, `+-1`, `{a b: 1}`, `{"a": 1} {}`, `[undefined]`, `0b1`, `0o7`, `1_000`, `+NaNa`, `'\u{41}'`} {
+		if _, err := ParseWithOptions(strings.NewReader(doc), Options{JSON5: true}); err == nil {
+			t.Errorf("doc: %q, JSON5 parse did not fail", doc)
+		}
+	}
+}
@@ -1,37 +1,175 @@
 package parse
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
+	"sync"
 
 	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/pointer"
 	"github.com/vyevs/gojson/tok"
 )
 
 // Parse reads the bytes in r and returns a JSON doc (if valid)
 // or an error on some JSON syntax error
 func Parse(r io.Reader) (interface{}, error) {
-	l := lex.New(r)
+	return ParseWithOptions(r, Options{})
+}
+
+// PathElem is one step on the path from the root of a document to a value,
+// the key of an object member or the index of an array element
+type PathElem struct {
+	Key   string
+	Index int // -1 for object members
+}
+
+// String returns the key, or the index in decimal
+func (e PathElem) String() string {
+	if e.Index < 0 {
+		return e.Key
+	}
+	return strconv.Itoa(e.Index)
+}
+
+// Reviver is called with every value as soon as it has been parsed, so the
+// members and elements of a value are revived before the value itself, along
+// with the path to the value, which is empty for the whole document
+// the value returned takes the place of value, returning Drop removes it
+// from its object or array, any other error aborts the parse
+// indices in path are positions in the document, they do not account for
+// elements dropped before, and path must not be retained after the call
+type Reviver func(path []PathElem, value interface{}) (interface{}, error)
+
+// Drop is returned by a Reviver to remove a value from the document
+// dropping the whole document makes it null
+var Drop = errors.New("drop value")
+
+// Options configures ParseWithOptions
+type Options struct {
+	// Reviver is called with every value parsed, if set
+	Reviver Reviver
+
+	// Interner, if set, holds the strings of the document, so that strings repeated
+	// within and across the documents it is shared by are allocated once
+	Interner *lex.Interner
+
+	// Sizes, if set, pre-sizes objects and arrays from those of the documents parsed
+	// with it before and records their sizes in this one
+	Sizes *Sizes
+
+	// JSONC accepts the comments and trailing commas of JSONC documents,
+	// e.g. tsconfig.json, comments are skipped
+	JSONC bool
+
+	// JSON5 accepts JSON5 documents, https://spec.json5.org, which extend JSONC
+	// with unquoted keys, single-quoted strings, hexadecimal numbers and more,
+	// duplicate keys are accepted, the last one wins, as in JavaScript
+	JSON5 bool
+
+	// SourceMap, if set, is filled in with the Location of every key and value
+	// of the document, including values a Reviver drops
+	SourceMap SourceMap
+}
+
+// ParseWithOptions is like Parse but calls the hooks set in opts
+// e.g. a Reviver turning timestamps into time.Time or dropping secrets
+func ParseWithOptions(r io.Reader, opts Options) (interface{}, error) {
+	p := parsers.Get().(*parser)
+	defer p.release()
+	p.l = lex.NewPooled(r)
+	p.l.SetInterner(opts.Interner)
+	p.reviver = opts.Reviver
+	if opts.JSONC {
+		p.l.SetMode(lex.JSONC)
+		p.jsonc = true
+	}
+	if opts.JSON5 {
+		p.l.SetMode(lex.JSON5)
+		p.jsonc, p.json5 = true, true
+	}
+	if opts.SourceMap != nil {
+		p.sourceMap = opts.SourceMap
+		p.l.TrackLines()
+	}
+	if opts.Sizes != nil {
+		p.sizes = opts.Sizes
+		p.objectHints, p.arrayHints = opts.Sizes.hints(p.objectHints[:0], p.arrayHints[:0])
+	}
 
-	t := l.ReadToken()
+	t := p.l.ReadToken()
 
+	var v interface{}
+	var err error
 	switch t.TokenType {
 	case tok.OpeningCurlyBrace:
-		return parseValue(l, t)
+		if p.json5 {
+			v, err = p.parseSingleValueDoc(t)
+		} else {
+			v, err = p.parseLocated(t)
+		}
 	case tok.Invalid:
 		return nil, fmt.Errorf("Found invalid token: %s", t.Literal)
 	default:
-		return parseSingleValueDoc(l, t)
+		v, err = p.parseSingleValueDoc(t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	v, _, err = p.revive(v)
+	if err == nil && p.sizes != nil {
+		p.sizes.add(p.objects, p.arrays)
+	}
+	return v, err
+}
+
+type parser struct {
+	l       lex.Lexer
+	reviver Reviver
+	path    []PathElem // to the value being parsed, tracked only with a reviver or source map
+	jsonc   bool       // trailing commas are accepted
+	json5   bool       // so are the keys and numbers of JSON5 documents
+
+	sourceMap SourceMap
+
+	// with Sizes, the hints for objects and arrays by depth,
+	// and the sizes of those in the document
+	sizes                   *Sizes
+	depth                   int
+	objectHints, arrayHints []int
+	objects, arrays         []average
+}
+
+// parsers are reused along with their slices
+var parsers = sync.Pool{New: func() interface{} { return &parser{} }}
+
+func (p *parser) release() {
+	p.l.Release()
+	*p = parser{
+		path:        p.path[:0],
+		objectHints: p.objectHints[:0],
+		arrayHints:  p.arrayHints[:0],
+		objects:     p.objects[:0],
+		arrays:      p.arrays[:0],
+	}
+	parsers.Put(p)
+}
+
+// the hint for a container at the current depth
+func (p *parser) hint(hints []int) int {
+	if p.depth >= len(hints) {
+		return 0
 	}
+	return hints[p.depth]
 }
 
-func parseSingleValueDoc(l lex.Lexer, ct tok.Token) (interface{}, error) {
-	v, err := parseValue(l, ct)
+func (p *parser) parseSingleValueDoc(ct tok.Token) (interface{}, error) {
+	v, err := p.parseLocated(ct)
 	if err != nil {
 		return nil, err
 	}
-	eof := l.ReadToken()
+	eof := p.l.ReadToken()
 	if eof.TokenType != tok.EOF {
 		return nil, fmt.Errorf("Expected end of document, found: %q", eof.Literal)
 	}
@@ -40,55 +178,134 @@ func parseSingleValueDoc(l lex.Lexer, ct tok.Token) (interface{}, error) {
 
 // parsing an object consists of reading a key followed by a colon
 // followed by a value repeatedly until we encounter a closing curly brace
-func parseObject(l lex.Lexer) (map[string]interface{}, error) {
-	out := map[string]interface{}{}
+func (p *parser) parseObject() (map[string]interface{}, error) {
+	out := make(map[string]interface{}, p.hint(p.objectHints))
+	p.depth++
 	var seenValue bool
-	for t := l.ReadToken(); t.TokenType != tok.ClosingCurlyBrace; t = l.ReadToken() {
+	var dropped map[string]bool // the keys of the members the reviver dropped
+	for t := p.l.ReadKeyToken(); t.TokenType != tok.ClosingCurlyBrace; t = p.l.ReadToken() {
 		if seenValue {
 			if t.TokenType != tok.Comma {
 				return nil, fmt.Errorf("Expected comma(%q) got %q", ",", t.Literal)
 			}
-			t = l.ReadToken()
+			t = p.l.ReadKeyToken()
+			if p.jsonc && t.TokenType == tok.ClosingCurlyBrace {
+				break
+			}
 		}
-		if t.TokenType != tok.String {
+		key, ok := p.key(t)
+		if !ok {
 			return nil, fmt.Errorf("Expected key, got: %q", t.Literal)
 		}
-		key := t.Literal
+		keyStart, keyEnd := p.l.TokenOffset(), p.l.InputOffset()
 
-		t = l.ReadToken()
+		t = p.l.ReadToken()
 		if t.TokenType != tok.Colon {
 			return nil, fmt.Errorf("Expected colon (%q): got: %q", ":", t.Literal)
 		}
 
-		v, err := parseValue(l, l.ReadToken())
+		if p.sourceMap != nil {
+			p.locateKey(key, keyStart, keyEnd)
+		}
+
+		v, keep, err := p.parseElem(PathElem{Key: key, Index: -1}, p.l.ReadToken())
 		if err != nil {
 			return nil, err
 		}
 		seenValue = true
 
-		if _, ok := out[key]; ok {
+		if _, ok := out[key]; (ok || dropped[key]) && !p.json5 {
 			return nil, fmt.Errorf("Found duplicate key %q", key)
 		}
-		out[key] = v
+		if keep {
+			out[key] = v
+		} else if !p.json5 {
+			if dropped == nil {
+				dropped = make(map[string]bool)
+			}
+			dropped[key] = true
+		}
+	}
+	p.depth--
+	if p.sizes != nil {
+		p.objects = record(p.objects, p.depth, len(out))
 	}
 	return out, nil
 }
 
+// parses the value of an object member or array element that begins with t
+// and revives it, reporting false if the reviver dropped it
+func (p *parser) parseElem(elem PathElem, t tok.Token) (interface{}, bool, error) {
+	if p.reviver == nil && p.sourceMap == nil {
+		v, err := p.parseValue(t)
+		return v, true, err
+	}
+
+	p.path = append(p.path, elem)
+	v, err := p.parseLocated(t)
+	keep := true
+	if err == nil {
+		v, keep, err = p.revive(v)
+	}
+	p.path = p.path[:len(p.path)-1]
+	return v, keep, err
+}
+
+// calls the reviver with v at the current path, reporting false if v is dropped
+func (p *parser) revive(v interface{}) (interface{}, bool, error) {
+	if p.reviver == nil {
+		return v, true, nil
+	}
+	v, err := p.reviver(p.path, v)
+	if err == Drop {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("Reviver at %q: %v", PathPointer(p.path), err)
+	}
+	return v, true, nil
+}
+
+// PathPointer returns the JSON Pointer to the value at path
+func PathPointer(path []PathElem) pointer.Pointer {
+	ptr := make(pointer.Pointer, len(path))
+	for i, e := range path {
+		ptr[i] = e.String()
+	}
+	return ptr
+}
+
+// ParseValue parses the value that begins with first, which has already
+// been read from l, leaving l positioned after the end of the value
+func ParseValue(l lex.Lexer, first tok.Token) (interface{}, error) {
+	if first.TokenType == tok.Invalid {
+		return nil, fmt.Errorf("Found invalid token: %s", first.Literal)
+	}
+	p := &parser{l: l}
+	return p.parseValue(first)
+}
+
 // parseValue expects ct to contain the first token representing a value
 // e.g.: "[" for array, "{" for object, str for string value
 // the comma before a value (if any) should already be consumed by the calling func
-func parseValue(l lex.Lexer, ct tok.Token) (interface{}, error) {
+func (p *parser) parseValue(ct tok.Token) (interface{}, error) {
 	switch ct.TokenType {
 	case tok.String:
 		return ct.Literal, nil
 	case tok.Integer:
+		if p.json5 {
+			return parseJSON5Integer(ct.Literal)
+		}
 		return parseInteger(ct.Literal)
 	case tok.FloatingPoint:
+		if p.json5 {
+			return parseJSON5FloatingPoint(ct.Literal)
+		}
 		return parseFloatingPoint(ct.Literal)
 	case tok.OpeningCurlyBrace:
-		return parseObject(l)
+		return p.parseObject()
 	case tok.OpeningSquareBracket:
-		return parseArray(l)
+		return p.parseArray()
 	case tok.Boolean:
 		return parseBool(ct.Literal)
 	case tok.Null:
@@ -98,36 +315,50 @@ func parseValue(l lex.Lexer, ct tok.Token) (interface{}, error) {
 }
 
 // parseArray starts parsing AFTER the opening square bracket has already been consumed
-func parseArray(l lex.Lexer) ([]interface{}, error) {
-	out := make([]interface{}, 0)
+func (p *parser) parseArray() ([]interface{}, error) {
+	out := make([]interface{}, 0, p.hint(p.arrayHints))
+	p.depth++
 	var seenValue bool
 
 	// array tokens are read in pairs after the 1st value
 	// 1st token should be a comma followed by a value EXCEPT for the 1st
 	// value in the array
-	for t := l.ReadToken(); t.TokenType != tok.ClosingSquareBracket; t = l.ReadToken() {
+	for i, t := 0, p.l.ReadToken(); t.TokenType != tok.ClosingSquareBracket; i, t = i+1, p.l.ReadToken() {
 		if seenValue {
 			if t.TokenType != tok.Comma {
 				return nil, fmt.Errorf("expected comma(%q), found: %q", ",", t.Literal)
 			}
-			t = l.ReadToken()
+			t = p.l.ReadToken()
+			if p.jsonc && t.TokenType == tok.ClosingSquareBracket {
+				break
+			}
 		}
 		seenValue = true
-		v, err := parseValue(l, t)
+		v, keep, err := p.parseElem(PathElem{Index: i}, t)
 		if err != nil {
 			return nil, err
 		}
-		out = append(out, v)
+		if keep {
+			out = append(out, v)
+		}
+	}
+	p.depth--
+	if p.sizes != nil {
+		p.arrays = record(p.arrays, p.depth, len(out))
 	}
 	return out, nil
 }
 
-func parseInteger(lit string) (int, error) {
+// parses an integer, as a float64 if it does not fit in an int
+func parseInteger(lit string) (interface{}, error) {
 	v, err := strconv.Atoi(lit)
-	if err != nil {
-		return 0, fmt.Errorf("Invalid value found: %q", lit)
+	if err == nil {
+		return v, nil
 	}
-	return v, nil
+	if err.(*strconv.NumError).Err == strconv.ErrRange {
+		return parseFloatingPoint(lit)
+	}
+	return nil, fmt.Errorf("Invalid value found: %q", lit)
 }
 
 func parseFloatingPoint(lit string) (float64, error) {
@@ -11,17 +11,20 @@ import (
 	"testing"
 
 	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/pointer"
 )
 
 func TestParseInteger(t *testing.T) {
 	tests := []struct {
 		literal string
-		want    int
+		want    interface{}
 		wantErr bool
 	}{
 		{literal: "1", want: 1},
 		{literal: "-512", want: -512},
-		{literal: "123455623123123213213213", wantErr: true},
+		{literal: "123455623123123213213213", want: 1.2345562312312322e23},
+		{literal: "-9223372036854775809", want: -9.223372036854775808e18},
+		{literal: "1x", wantErr: true},
 	}
 
 	for _, test := range tests {
@@ -29,7 +32,7 @@ func TestParseInteger(t *testing.T) {
 
 		gotErr := err != nil
 		if gotErr != test.wantErr || got != test.want {
-			t.Errorf("literal: %q, got: %d, want: %d, err: %v, wantErr: %v",
+			t.Errorf("literal: %q, got: %v, want: %v, err: %v, wantErr: %v",
 				test.literal, got, test.want, err, test.wantErr)
 		}
 	}
@@ -114,7 +117,7 @@ func TestParseObject(t *testing.T) {
 
 	for _, test := range tests {
 		l := lex.New(strings.NewReader(test.literal))
-		got, err := parseObject(l)
+		got, err := (&parser{l: l}).parseObject()
 
 		gotErr := err != nil
 
@@ -181,7 +184,7 @@ func TestParseArray(t *testing.T) {
 
 	for _, test := range tests {
 		l := lex.New(strings.NewReader(test.str))
-		got, err := parseArray(l)
+		got, err := (&parser{l: l}).parseArray()
 
 		gotErr := err != nil
 
@@ -298,6 +301,70 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseWithReviver(t *testing.T) {
+	var calls []string
+	reviver := func(path []PathElem, v interface{}) (interface{}, error) {
+		calls = append(calls, PathPointer(path).String())
+
+		if len(path) > 0 && path[len(path)-1].Key == "secret" {
+			return nil, Drop
+		}
+		if n, ok := v.(int); ok && len(path) > 0 && path[len(path)-1].Index >= 0 {
+			if n < 0 {
+				return nil, Drop
+			}
+			return n * 10, nil
+		}
+		if str, ok := v.(string); ok && strings.HasPrefix(str, "date:") {
+			return strings.TrimPrefix(str, "date:"), nil
+		}
+		return v, nil
+	}
+
+	doc := `{"user": {"name": "x", "secret": "hunter2", "born": "date:2000-01-01"}, "scores": [1, -2, 3]}`
+	got, err := ParseWithOptions(strings.NewReader(doc), Options{Reviver: reviver})
+
+	want := map[string]interface{}{
+		"user":   map[string]interface{}{"name": "x", "born": "2000-01-01"},
+		"scores": []interface{}{10, 30},
+	}
+	if err != nil || !equal(got, want) {
+		t.Errorf("got: %v, want: %v, err: %v", got, want, err)
+	}
+	wantCalls := []string{"/user/name", "/user/secret", "/user/born", "/user", "/scores/0", "/scores/1", "/scores/2", "/scores", ""}
+	if strings.Join(calls, " ") != strings.Join(wantCalls, " ") {
+		t.Errorf("got calls: %q, want: %q", calls, wantCalls)
+	}
+}
+
+func TestParseWithReviverErrors(t *testing.T) {
+	fail := func(path []PathElem, v interface{}) (interface{}, error) {
+		if b, ok := v.(bool); ok && b {
+			return nil, fmt.Errorf("no true values")
+		}
+		return v, nil
+	}
+	dropAll := func(path []PathElem, v interface{}) (interface{}, error) {
+		return nil, Drop
+	}
+
+	got, err := ParseWithOptions(strings.NewReader(`{"a": [false, {"b": true}]}`), Options{Reviver: fail})
+	if err == nil || !strings.Contains(err.Error(), `"/a/1/b"`) {
+		t.Errorf("got: %v, err: %v, want error at /a/1/b", got, err)
+	}
+
+	got, err = ParseWithOptions(strings.NewReader(`[1, {"a": 2}]`), Options{Reviver: dropAll})
+	if err != nil || got != nil {
+		t.Errorf("got: %v, err: %v, want: <nil>", got, err)
+	}
+
+	// a member the reviver dropped is still a duplicate
+	got, err = ParseWithOptions(strings.NewReader(`{"a": 1, "a": 2}`), Options{Reviver: dropAll})
+	if err == nil || err.Error() != `Found duplicate key "a"` {
+		t.Errorf("got: %v, err: %v, want a duplicate key error", got, err)
+	}
+}
+
 func slicesEqual(s1, s2 []interface{}) bool {
 	if len(s1) != len(s2) {
 		return false
@@ -363,6 +430,18 @@ func BenchmarkParse(b *testing.B) {
 				r.Reset(fbytes)
 			}
 		})
+		b.Run(fmt.Sprintf("%s%s", path, "SHARED"), func(b *testing.B) {
+			// the interner and sizes are shared by every parse, as by a server
+			// decoding many requests of the same form
+			opts := Options{Interner: lex.NewInterner(1024), Sizes: &Sizes{}}
+			for i := 0; i < b.N; i++ {
+				_, err = ParseWithOptions(r, opts)
+				if err != nil {
+					b.Fatalf("Unexpected ParseWithOptions() failure: %v", err)
+				}
+				r.Reset(fbytes)
+			}
+		})
 		b.Run(fmt.Sprintf("%s%s", path, "STDLIB"), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
 				d := json.NewDecoder(r)
@@ -388,3 +467,128 @@ func getTestFilePaths() ([]string, error) {
 	})
 	return out, err
 }
+
+func TestParseWithSizes(t *testing.T) {
+	sizes := &Sizes{}
+	opts := Options{Interner: lex.NewInterner(100), Sizes: sizes}
+	docs := []string{
+		`[{"a": 1, "b": [1, 2, 3]}, {"a": 2, "b": [1]}]`,
+		`[{"a": 3, "b": [], "c": "x"}]`,
+	}
+	for _, doc := range docs {
+		got, err := ParseWithOptions(strings.NewReader(doc), opts)
+		want, _ := Parse(strings.NewReader(doc))
+		if err != nil || !equal(got, want) {
+			t.Errorf("doc: %q, got: %v, want: %v, err: %v", doc, got, want, err)
+		}
+	}
+
+	// only keys are interned, not "x"
+	if got := opts.Interner.Len(); got != 3 {
+		t.Errorf("got interned: %d, want: 3", got)
+	}
+
+	// 2 arrays at depth 0 with 2, 1 elements, 3 objects at depth 1 with 2, 2, 3 members,
+	// 3 arrays at depth 2 with 3, 1, 0 elements, averages rounded up
+	tests := []struct {
+		depth               int
+		wantObject, wantArr int
+	}{
+		{depth: 0, wantObject: 0, wantArr: 2},
+		{depth: 1, wantObject: 3, wantArr: 0},
+		{depth: 2, wantObject: 0, wantArr: 2},
+		{depth: 3, wantObject: 0, wantArr: 0},
+	}
+	for _, test := range tests {
+		if got := sizes.ObjectHint(test.depth); got != test.wantObject {
+			t.Errorf("depth: %d, got object hint: %d, want: %d", test.depth, got, test.wantObject)
+		}
+		if got := sizes.ArrayHint(test.depth); got != test.wantArr {
+			t.Errorf("depth: %d, got array hint: %d, want: %d", test.depth, got, test.wantArr)
+		}
+	}
+}
+
+func TestParseJSONC(t *testing.T) {
+	doc := `// tsconfig
+{
+	"compilerOptions": {
+		"strict": true, /* for now */
+		"paths": ["a", "b",],
+	},
+}`
+	want := map[string]interface{}{
+		"compilerOptions": map[string]interface{}{
+			"strict": true,
+			"paths":  []interface{}{"a", "b"},
+		},
+	}
+
+	got, err := ParseWithOptions(strings.NewReader(doc), Options{JSONC: true})
+	if err != nil || !equal(got, want) {
+		t.Errorf("got: %v, want: %v, err: %v", got, want, err)
+	}
+
+	// plain JSON has neither comments nor trailing commas
+	for _, doc := range []string{`{"a": 1 /* c */}`, `{"a": 1,}`, `[1,]`} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("doc: %q, Parse() did not fail", doc)
+		}
+	}
+	for _, doc := range []string{`[,]`, `{,}`, `[1,,]`, `{"a": 1 /* c}`} {
+		if _, err := ParseWithOptions(strings.NewReader(doc), Options{JSONC: true}); err == nil {
+			t.Errorf("doc: %q, JSONC parse did not fail", doc)
+		}
+	}
+}
+
+func TestParseWithSourceMap(t *testing.T) {
+	doc := "{\n  \"a\": [1, {\"b\": null}],\n  \"c~/\": \"x\"\n}"
+	tests := []struct {
+		ptr  string
+		want Location
+	}{
+		{ptr: "", want: Location{Value: Span{Start: Position{0, 1, 1}, End: Position{41, 4, 2}}}},
+		{ptr: "/a", want: Location{
+			Key:   Span{Start: Position{4, 2, 3}, End: Position{7, 2, 6}},
+			Value: Span{Start: Position{9, 2, 8}, End: Position{25, 2, 24}},
+		}},
+		{ptr: "/a/0", want: Location{Value: Span{Start: Position{10, 2, 9}, End: Position{11, 2, 10}}}},
+		{ptr: "/a/1/b", want: Location{
+			Key:   Span{Start: Position{14, 2, 13}, End: Position{17, 2, 16}},
+			Value: Span{Start: Position{19, 2, 18}, End: Position{23, 2, 22}},
+		}},
+		{ptr: "/c~0~1", want: Location{
+			Key:   Span{Start: Position{29, 3, 3}, End: Position{34, 3, 8}},
+			Value: Span{Start: Position{36, 3, 10}, End: Position{39, 3, 13}},
+		}},
+	}
+
+	sm := SourceMap{}
+	if _, err := ParseWithOptions(strings.NewReader(doc), Options{SourceMap: sm}); err != nil {
+		t.Fatalf("ParseWithOptions(): %v", err)
+	}
+	if len(sm) != 6 {
+		t.Errorf("got %d locations: %v, want 6", len(sm), sm)
+	}
+	for _, test := range tests {
+		got, ok := sm.Lookup(pointer.MustParse(test.ptr))
+
+		if !ok || got != test.want {
+			t.Errorf("ptr: %q, got: %+v, want: %+v", test.ptr, got, test.want)
+		}
+		if span := got.Value; doc[span.Start.Offset:span.End.Offset] == "" {
+			t.Errorf("ptr: %q, the value span is empty", test.ptr)
+		}
+	}
+
+	// a single value document, whose trailing whitespace is not part of its value
+	sm = SourceMap{}
+	if _, err := ParseWithOptions(strings.NewReader("\n\n  \"s\"\n"), Options{SourceMap: sm}); err != nil {
+		t.Fatalf("ParseWithOptions(): %v", err)
+	}
+	want := Location{Value: Span{Start: Position{4, 3, 3}, End: Position{7, 3, 6}}}
+	if got := sm[""]; got != want {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
@@ -0,0 +1,377 @@
+package parse
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Diagnostic is a syntax error found by ParseRecover
+type Diagnostic struct {
+	Offset  int // of the first byte of the token the error was found at
+	Line    int // 1-based
+	Column  int // 1-based, counted in bytes
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+}
+
+// ParseRecover parses the document in r like Parse, but rather than stop at
+// the first syntax error it reports it and carries on, returning every error
+// found along with a best-effort value of the document, e.g. for editors and linters
+// the error returned is only ever one reading r
+//
+// missing commas and colons are assumed where a value or key follows,
+// a closing bracket of the wrong kind is taken for the right one unless that
+// follows it, after other errors the parser skips ahead to the next comma or
+// closing bracket, values that are not valid are null, strings cut off by the
+// end of the document end at the end of their line, and unclosed objects and
+// arrays are closed
+func ParseRecover(r io.Reader) (interface{}, []Diagnostic, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := &recoverer{data: data}
+	p.next()
+	v := p.parseValue()
+	if p.t.typ != tok.EOF {
+		p.report(p.t.start, "Expected end of document, found: %s", p.found())
+	}
+	p.positions()
+	return v, p.diags, nil
+}
+
+// a token read by a recoverer, with its decoded value for strings,
+// numbers and literals
+type rtoken struct {
+	typ        tok.TokenType
+	start, end int
+	value      interface{}
+}
+
+// recoverer is a recursive descent parser over a whole document,
+// it reads tokens one ahead, t is the next token to be parsed
+type recoverer struct {
+	data  []byte
+	i     int
+	t     rtoken
+	diags []Diagnostic
+}
+
+func (p *recoverer) report(offset int, format string, args ...interface{}) {
+	p.diags = append(p.diags, Diagnostic{Offset: offset, Message: fmt.Sprintf(format, args...)})
+}
+
+// describes the next token for messages
+func (p *recoverer) found() string {
+	if p.t.typ == tok.EOF {
+		return "end of document"
+	}
+	src := p.data[p.t.start:p.t.end]
+	if len(src) > 20 {
+		return fmt.Sprintf("%q...", src[:20])
+	}
+	return fmt.Sprintf("%q", src)
+}
+
+// sets the lines and columns of the diagnostics from their offsets
+func (p *recoverer) positions() {
+	if len(p.diags) == 0 {
+		return
+	}
+	lines := lex.NewLines(p.data)
+	for i := range p.diags {
+		d := &p.diags[i]
+		d.Line, d.Column = lines.Position(d.Offset)
+	}
+}
+
+func (p *recoverer) parseValue() interface{} {
+	switch p.t.typ {
+	case tok.OpeningCurlyBrace:
+		return p.parseObject()
+	case tok.OpeningSquareBracket:
+		return p.parseArray()
+	case tok.String, tok.Integer, tok.FloatingPoint, tok.Boolean, tok.Null:
+		v := p.t.value
+		p.next()
+		return v
+	case tok.Invalid:
+		// reported when it was read
+		p.next()
+		return nil
+	}
+	// a structural token or the end, left to the enclosing object or array
+	p.report(p.t.start, "Expected value, found: %s", p.found())
+	return nil
+}
+
+// reports whether t can begin a value
+func beginsValue(t tok.TokenType) bool {
+	switch t {
+	case tok.OpeningCurlyBrace, tok.OpeningSquareBracket, tok.String, tok.Integer,
+		tok.FloatingPoint, tok.Boolean, tok.Null, tok.Invalid:
+		return true
+	}
+	return false
+}
+
+func (p *recoverer) parseObject() map[string]interface{} {
+	start := p.t.start
+	p.next()
+
+	out := map[string]interface{}{}
+	if p.t.typ == tok.ClosingCurlyBrace {
+		p.next()
+		return out
+	}
+	for {
+		if p.t.typ != tok.String {
+			p.report(p.t.start, "Expected key, found: %s", p.found())
+			p.skip()
+		} else {
+			key, keyStart := p.t.value.(string), p.t.start
+			p.next()
+			var v interface{}
+			if p.t.typ == tok.Colon {
+				p.next()
+				v = p.parseValue()
+			} else {
+				p.report(p.t.start, "Expected colon, found: %s", p.found())
+				if beginsValue(p.t.typ) {
+					v = p.parseValue()
+				}
+			}
+			if _, ok := out[key]; ok {
+				p.report(keyStart, "Found duplicate key %q", key)
+			}
+			out[key] = v
+		}
+
+		if !p.afterElem(tok.ClosingCurlyBrace, start) {
+			return out
+		}
+	}
+}
+
+func (p *recoverer) parseArray() []interface{} {
+	start := p.t.start
+	p.next()
+
+	out := make([]interface{}, 0)
+	if p.t.typ == tok.ClosingSquareBracket {
+		p.next()
+		return out
+	}
+	for {
+		if beginsValue(p.t.typ) {
+			out = append(out, p.parseValue())
+		} else {
+			p.report(p.t.start, "Expected value, found: %s", p.found())
+			p.skip()
+		}
+
+		if !p.afterElem(tok.ClosingSquareBracket, start) {
+			return out
+		}
+	}
+}
+
+// parses what follows a member or element of the object or array
+// opened at start that closes with close, reporting whether another follows
+func (p *recoverer) afterElem(close tok.TokenType, start int) bool {
+	what := "closing curly brace"
+	if close == tok.ClosingSquareBracket {
+		what = "closing square bracket"
+	}
+
+	switch {
+	case p.t.typ == tok.Comma:
+		comma := p.t.start
+		p.next()
+		if p.t.typ == close {
+			p.report(comma, "Found trailing comma")
+			p.next()
+			return false
+		}
+		return true
+	case p.t.typ == close:
+		p.next()
+		return false
+	case p.t.typ == tok.EOF:
+		p.report(p.t.start, "Expected comma or %s, found: end of document, to close %q at offset %d",
+			what, p.data[start], start)
+		return false
+	case beginsValue(p.t.typ):
+		p.report(p.t.start, "Expected comma, found: %s", p.found())
+		return true
+	case p.t.typ == tok.ClosingCurlyBrace || p.t.typ == tok.ClosingSquareBracket:
+		// the wrong bracket, a stray one if the right one follows, otherwise taken for it
+		p.report(p.t.start, "Expected comma or %s, found: %s", what, p.found())
+		p.next()
+		if p.t.typ == close {
+			p.next()
+		}
+		return false
+	}
+	p.report(p.t.start, "Expected comma or %s, found: %s", what, p.found())
+	p.skip()
+	return p.afterElem(close, start)
+}
+
+// skips tokens up to the next comma or closing bracket of the innermost object or array,
+// skipping over any objects and arrays on the way
+func (p *recoverer) skip() {
+	for {
+		switch p.t.typ {
+		case tok.Comma, tok.ClosingCurlyBrace, tok.ClosingSquareBracket, tok.EOF:
+			return
+		case tok.OpeningCurlyBrace, tok.OpeningSquareBracket:
+			p.parseValue()
+		default:
+			p.next()
+		}
+	}
+}
+
+// reads the next token into p.t, reporting tokens that are not valid
+func (p *recoverer) next() {
+	for p.i < len(p.data) && isWhitespace(p.data[p.i]) {
+		p.i++
+	}
+	start := p.i
+	if p.i >= len(p.data) {
+		p.t = rtoken{typ: tok.EOF, start: start, end: start}
+		return
+	}
+
+	c := p.data[p.i]
+	if typ := structural(c); typ != tok.Invalid {
+		p.i++
+		p.t = rtoken{typ: typ, start: start, end: p.i}
+		return
+	}
+	if c == '"' {
+		p.t = p.readString()
+		return
+	}
+
+	for p.i < len(p.data) && !isDelimiter(p.data[p.i]) {
+		p.i++
+	}
+	p.t = rtoken{typ: tok.Invalid, start: start, end: p.i}
+	word := string(p.data[start:p.i])
+	switch {
+	case word == "true" || word == "false":
+		p.t.typ, p.t.value = tok.Boolean, word == "true"
+	case word == "null":
+		p.t.typ = tok.Null
+	case c == '-' || c >= '0' && c <= '9':
+		switch lex.NumericTokenType(word) {
+		case tok.Integer:
+			if v, err := parseInteger(word); err == nil {
+				p.t.typ, p.t.value = tok.Integer, v
+				return
+			}
+		case tok.FloatingPoint:
+			if v, err := parseFloatingPoint(word); err == nil {
+				p.t.typ, p.t.value = tok.FloatingPoint, v
+				return
+			}
+		}
+		p.report(start, "Invalid number: %q", word)
+	default:
+		p.report(start, "Invalid token: %s", p.found())
+	}
+}
+
+// reads the string beginning at p.i, one that is not terminated
+// is taken to end at the end of its line
+func (p *recoverer) readString() rtoken {
+	start := p.i
+	end := -1
+	// a string cannot hold a raw newline, so one that is not terminated ends with its line
+	i := start + 1
+	for ; i < len(p.data) && p.data[i] != '\n'; i++ {
+		if p.data[i] == '"' {
+			end = i
+			break
+		}
+		if p.data[i] == '\\' && i+1 < len(p.data) && p.data[i+1] != '\n' {
+			i++
+		}
+	}
+
+	content := start + 1
+	if end < 0 {
+		p.report(start, "Unterminated string")
+		p.i = i
+		end = i
+		if end > content && p.data[end-1] == '\r' {
+			end--
+		}
+	} else {
+		p.i = end + 1
+	}
+	return rtoken{typ: tok.String, start: start, end: p.i, value: p.unescape(p.data[content:end], content)}
+}
+
+// unescapes the contents of a string beginning at offset off,
+// reporting escape sequences that are not valid and leaving them out
+func (p *recoverer) unescape(str []byte, off int) string {
+	var out []byte
+	from := 0
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\\' {
+			continue
+		}
+		n := lex.EscapeLen(str[i:])
+		if n > 0 {
+			i += n - 1
+			continue
+		}
+		out, _ = lex.AppendUnescaped(out, str[from:i])
+		p.report(off+i, "Invalid escape sequence")
+		from = i + 2
+		if from > len(str) {
+			from = len(str)
+		}
+		i = from - 1
+	}
+	out, _ = lex.AppendUnescaped(out, str[from:])
+	return string(out)
+}
+
+func structural(c byte) tok.TokenType {
+	switch c {
+	case '{':
+		return tok.OpeningCurlyBrace
+	case '}':
+		return tok.ClosingCurlyBrace
+	case '[':
+		return tok.OpeningSquareBracket
+	case ']':
+		return tok.ClosingSquareBracket
+	case ':':
+		return tok.Colon
+	case ',':
+		return tok.Comma
+	}
+	return tok.Invalid
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+// reports whether c ends a number, literal or token that is not valid
+func isDelimiter(c byte) bool {
+	return isWhitespace(c) || c == '"' || structural(c) != tok.Invalid
+}
@@ -0,0 +1,141 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRecover(t *testing.T) {
+	tests := []struct {
+		doc       string
+		want      interface{}
+		wantDiags []string
+	}{
+		{
+			doc:  `{"a": [1, 2], "b": null}`,
+			want: map[string]interface{}{"a": []interface{}{1, 2}, "b": nil},
+		},
+		{
+			doc:       "{\n  \"a\": 1\n  \"b\": 2,\n  \"c\" 3,\n}",
+			want:      map[string]interface{}{"a": 1, "b": 2, "c": 3},
+			wantDiags: []string{`3:3: Expected comma, found: "\"b\""`, `4:7: Expected colon, found: "3"`, `4:8: Found trailing comma`},
+		},
+		{
+			doc:  "{\r\n  \"a\": 1,\r\n  \"b\": 2\r\n}\r\n",
+			want: map[string]interface{}{"a": 1, "b": 2},
+		},
+		{
+			doc:  `[18446744073709551616, -1e2]`,
+			want: []interface{}{1.8446744073709552e19, -100.0},
+		},
+		{
+			doc:       "[\r\n1\r\n2]",
+			want:      []interface{}{1, 2},
+			wantDiags: []string{`3:1: Expected comma, found: "2"`},
+		},
+		{
+			doc:       `[1 2, , 3,]`,
+			want:      []interface{}{1, 2, 3},
+			wantDiags: []string{`1:4: Expected comma, found: "2"`, `1:7: Expected value, found: ","`, `1:10: Found trailing comma`},
+		},
+		{
+			doc:       `[tru, 01, "a\x", 1.5e]`,
+			want:      []interface{}{nil, nil, "a", nil},
+			wantDiags: []string{`1:2: Invalid token: "tru"`, `1:7: Invalid number: "01"`, `1:13: Invalid escape sequence`, `1:18: Invalid number: "1.5e"`},
+		},
+		{
+			doc:       `{"a": [1, 2}, "b": 3}`,
+			want:      map[string]interface{}{"a": []interface{}{1, 2}, "b": 3},
+			wantDiags: []string{`1:12: Expected comma or closing square bracket, found: "}"`},
+		},
+		{
+			doc:       `[1, 2}]`,
+			want:      []interface{}{1, 2},
+			wantDiags: []string{`1:6: Expected comma or closing square bracket, found: "}"`},
+		},
+		{
+			doc:       `{"a": 1, 2: 3, "b": 4}`,
+			want:      map[string]interface{}{"a": 1, "b": 4},
+			wantDiags: []string{`1:10: Expected key, found: "2"`},
+		},
+		{
+			doc:       `{"a": 1 : 2, "b": }`,
+			want:      map[string]interface{}{"a": 1, "b": nil},
+			wantDiags: []string{`1:9: Expected comma or closing curly brace, found: ":"`, `1:19: Expected value, found: "}"`},
+		},
+		{
+			doc:  "{\"a\": [1, {\"b\": 2",
+			want: map[string]interface{}{"a": []interface{}{1, map[string]interface{}{"b": 2}}},
+			wantDiags: []string{
+				`1:18: Expected comma or closing curly brace, found: end of document, to close '{' at offset 10`,
+				`1:18: Expected comma or closing square bracket, found: end of document, to close '[' at offset 6`,
+				`1:18: Expected comma or closing curly brace, found: end of document, to close '{' at offset 0`,
+			},
+		},
+		{
+			doc:       "[\"abc,\n 1]",
+			want:      []interface{}{"abc,", 1},
+			wantDiags: []string{`1:2: Unterminated string`, `2:2: Expected comma, found: "1"`},
+		},
+		{
+			doc:       "{\"a\": \"abc\n, \"b\": 1, \"c\": \"x\\\r\n}",
+			want:      map[string]interface{}{"a": "abc", "b": 1, "c": "x"},
+			wantDiags: []string{`1:7: Unterminated string`, `2:16: Unterminated string`, `2:18: Invalid escape sequence`},
+		},
+		{
+			doc:       `{"a": 1, "a": 2}`,
+			want:      map[string]interface{}{"a": 2},
+			wantDiags: []string{`1:10: Found duplicate key "a"`},
+		},
+		{
+			doc:       `{} []`,
+			want:      map[string]interface{}{},
+			wantDiags: []string{`1:4: Expected end of document, found: "["`},
+		},
+		{
+			doc:       ``,
+			want:      nil,
+			wantDiags: []string{`1:1: Expected value, found: end of document`},
+		},
+	}
+
+	for _, test := range tests {
+		got, diags, err := ParseRecover(strings.NewReader(test.doc))
+
+		var gotDiags []string
+		for _, d := range diags {
+			gotDiags = append(gotDiags, d.Error())
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) || !reflect.DeepEqual(gotDiags, test.wantDiags) {
+			t.Errorf("doc: %q, got: %v, diags: %q, want: %v, diags: %q, err: %v",
+				test.doc, got, gotDiags, test.want, test.wantDiags, err)
+		}
+	}
+}
+
+// documents without errors parse to what Parse returns
+func TestParseRecoverValid(t *testing.T) {
+	paths, err := getTestFilePaths()
+	if err != nil {
+		t.Fatalf("getTestFilePaths(): %v", err)
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile(%q): %v", path, err)
+		}
+		want, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", path, err)
+		}
+
+		got, diags, err := ParseRecover(bytes.NewReader(data))
+
+		if err != nil || len(diags) != 0 || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got diags: %v, err: %v, equal to Parse: %v", path, diags, err, reflect.DeepEqual(got, want))
+		}
+	}
+}
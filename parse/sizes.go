@@ -0,0 +1,107 @@
+package parse
+
+import "sync"
+
+// Sizes records the sizes of the objects and arrays of parsed documents, so that
+// those of later documents are allocated with room for as many members and elements
+//
+// sizes are averaged by nesting depth, which suits documents sharing a schema,
+// such as arrays of records or the lines of NDJSON, older documents count for
+// less as more are parsed
+// Sizes may be shared by parses on several goroutines, the zero value is ready to use
+type Sizes struct {
+	mu      sync.Mutex
+	objects []average // by depth
+	arrays  []average
+}
+
+// the average is halved before it takes in more than maxSamples sizes
+const maxSamples = 1 << 12
+
+type average struct {
+	sum, n int
+}
+
+func (a *average) add(b average) {
+	for a.n+b.n > maxSamples {
+		a.sum, a.n = a.sum/2, a.n/2
+		if a.n == 0 {
+			break
+		}
+	}
+	a.sum += b.sum
+	a.n += b.n
+}
+
+// the average rounded up
+func (a average) value() int {
+	if a.n == 0 {
+		return 0
+	}
+	return (a.sum + a.n - 1) / a.n
+}
+
+// ObjectHint returns the number of members objects at depth have had on average,
+// the whole document being at depth 0
+func (s *Sizes) ObjectHint(depth int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hintAt(s.objects, depth)
+}
+
+// ArrayHint returns the number of elements arrays at depth have had on average
+func (s *Sizes) ArrayHint(depth int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hintAt(s.arrays, depth)
+}
+
+func hintAt(avgs []average, depth int) int {
+	if depth >= len(avgs) {
+		return 0
+	}
+	return avgs[depth].value()
+}
+
+// copies the hints for every depth into objects and arrays
+func (s *Sizes) hints(objects, arrays []int) ([]int, []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.objects {
+		objects = append(objects, a.value())
+	}
+	for _, a := range s.arrays {
+		arrays = append(arrays, a.value())
+	}
+	return objects, arrays
+}
+
+// adds the sizes seen in a parse
+func (s *Sizes) add(objects, arrays []average) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects = addAverages(s.objects, objects)
+	s.arrays = addAverages(s.arrays, arrays)
+}
+
+func addAverages(dst, src []average) []average {
+	for len(dst) < len(src) {
+		dst = append(dst, average{})
+	}
+	for i, a := range src {
+		if a.n > 0 {
+			dst[i].add(a)
+		}
+	}
+	return dst
+}
+
+// records a size seen at depth
+func record(avgs []average, depth, size int) []average {
+	for len(avgs) <= depth {
+		avgs = append(avgs, average{})
+	}
+	avgs[depth].sum += size
+	avgs[depth].n++
+	return avgs
+}
@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"github.com/vyevs/gojson/pointer"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Position is a position in a document
+type Position struct {
+	Offset int
+	Line   int // 1-based
+	Column int // 1-based, counted in bytes
+}
+
+// Span is the source of a key or value in a document,
+// End is the position just past its last byte
+type Span struct {
+	Start, End Position
+}
+
+// Location is where a value, and its key if it is an object member,
+// is found in a document
+type Location struct {
+	Key   Span // the zero Span for array elements and the whole document
+	Value Span
+}
+
+// SourceMap maps the JSON Pointers of the values of a document,
+// in their string form, to their Locations
+// e.g. for validators and linters to report where in a file a value is
+type SourceMap map[string]Location
+
+// Lookup returns the Location of the value at ptr
+func (m SourceMap) Lookup(ptr pointer.Pointer) (Location, bool) {
+	loc, ok := m[ptr.String()]
+	return loc, ok
+}
+
+// the Span from start up to end
+func (p *parser) span(start, end int64) Span {
+	return Span{Start: p.position(start), End: p.position(end)}
+}
+
+func (p *parser) position(offset int64) Position {
+	line, column := p.l.Position(offset)
+	return Position{Offset: int(offset), Line: line, Column: column}
+}
+
+// parses the value beginning with t, recording its Location in the source map
+// if there is one, at the current path
+func (p *parser) parseLocated(t tok.Token) (interface{}, error) {
+	if p.sourceMap == nil {
+		return p.parseValue(t)
+	}
+	start := p.l.TokenOffset()
+	v, err := p.parseValue(t)
+	if err != nil {
+		return nil, err
+	}
+	ptr := PathPointer(p.path).String()
+	loc := p.sourceMap[ptr]
+	loc.Value = p.span(start, p.l.InputOffset())
+	p.sourceMap[ptr] = loc
+	return v, nil
+}
+
+// records the Span of the key of the member named key of the object being parsed
+func (p *parser) locateKey(key string, start, end int64) {
+	ptr := PathPointer(p.path).Append(key).String()
+	p.sourceMap[ptr] = Location{Key: p.span(start, end)}
+}
@@ -0,0 +1,321 @@
+package patch
+
+import (
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// maxCandidates bounds the number of remove/add pairs checked when looking
+// for moves, and of add/source pairs checked when looking for copies,
+// each check applies the whole patch
+const maxCandidates = 64
+
+// Diff returns a Patch that transforms src into dst
+//
+// objects are compared member by member and arrays are diffed
+// using their longest common subsequence, so that inserting into or
+// removing from the middle of an array does not rewrite its tail
+// values that are removed in one place and added in another become
+// move operations, and added values already present in src become copies
+// Apply(src, Diff(src, dst)) always yields a document Equal to dst
+func Diff(src, dst interface{}) Patch {
+	var d differ
+	d.diffValues(pointer.Pointer{}, src, dst)
+
+	p := d.ops
+	p = detectMoves(src, dst, p, d.removed)
+	p = detectCopies(src, dst, p)
+	return p
+}
+
+type differ struct {
+	ops Patch
+	// removed maps the index of every remove operation in ops to the value it removes
+	removed map[int]interface{}
+}
+
+func (d *differ) emit(op Operation) {
+	d.ops = append(d.ops, op)
+}
+
+func (d *differ) emitRemove(path pointer.Pointer, old interface{}) {
+	if d.removed == nil {
+		d.removed = map[int]interface{}{}
+	}
+	d.removed[len(d.ops)] = old
+	d.emit(Operation{Op: Remove, Path: path.String()})
+}
+
+func (d *differ) diffValues(path pointer.Pointer, src, dst interface{}) {
+	if Equal(src, dst) {
+		return
+	}
+
+	switch s := src.(type) {
+	case map[string]interface{}:
+		if t, ok := dst.(map[string]interface{}); ok {
+			d.diffObjects(path, s, t)
+			return
+		}
+	case []interface{}:
+		if t, ok := dst.([]interface{}); ok {
+			d.diffArrays(path, s, t)
+			return
+		}
+	}
+	d.emit(Operation{Op: Replace, Path: path.String(), Value: dst})
+}
+
+// members only in src are removed, members only in dst are added
+// and members in both are diffed recursively
+// keys are visited in sorted order so that Diff is deterministic
+func (d *differ) diffObjects(path pointer.Pointer, src, dst map[string]interface{}) {
+	for _, k := range encode.SortedKeys(src) {
+		if _, ok := dst[k]; !ok {
+			d.emitRemove(path.Append(k), src[k])
+		}
+	}
+	for _, k := range encode.SortedKeys(dst) {
+		if v, ok := src[k]; ok {
+			d.diffValues(path.Append(k), v, dst[k])
+		} else {
+			d.emit(Operation{Op: Add, Path: path.Append(k).String(), Value: dst[k]})
+		}
+	}
+}
+
+// elements in the longest common subsequence of src and dst are kept as is
+// between two kept elements, removed and added elements are paired up
+// and diffed in place, the leftovers become plain removes or adds
+// idx tracks the position in the array as it is being transformed
+func (d *differ) diffArrays(path pointer.Pointer, src, dst []interface{}) {
+	matches := lcs(len(src), len(dst), func(i, j int) bool {
+		return Equal(src[i], dst[j])
+	})
+	matches = append(matches, match{len(src), len(dst)})
+
+	var i, j, idx int
+	for _, m := range matches {
+		removed, added := src[i:m.i], dst[j:m.j]
+
+		paired := len(removed)
+		if len(added) < paired {
+			paired = len(added)
+		}
+		for k := 0; k < paired; k++ {
+			d.diffValues(path.AppendIndex(idx), removed[k], added[k])
+			idx++
+		}
+		for k := paired; k < len(removed); k++ {
+			d.emitRemove(path.AppendIndex(idx), removed[k])
+		}
+		for k := paired; k < len(added); k++ {
+			d.emit(Operation{Op: Add, Path: path.AppendIndex(idx).String(), Value: added[k]})
+			idx++
+		}
+
+		// step over the matched element itself
+		i, j = m.i+1, m.j+1
+		idx++
+	}
+}
+
+// match is a pair of indices of equal elements in two sequences
+type match struct {
+	i, j int
+}
+
+// lcs returns the index pairs of a longest common subsequence of two
+// sequences of lengths n and m, in increasing order
+// eq reports whether element i of the 1st sequence equals element j of the 2nd
+func lcs(n, m int, eq func(i, j int) bool) []match {
+	// common prefixes and suffixes are matched directly,
+	// which keeps the quadratic table small for typical edits
+	var prefix int
+	for prefix < n && prefix < m && eq(prefix, prefix) {
+		prefix++
+	}
+	var suffix int
+	for suffix < n-prefix && suffix < m-prefix && eq(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+
+	rows, cols := n-prefix-suffix, m-prefix-suffix
+	// lengths[i][j] is the LCS length of the sequences starting at prefix+i and prefix+j
+	lengths := make([][]int, rows+1)
+	for i := range lengths {
+		lengths[i] = make([]int, cols+1)
+	}
+	for i := rows - 1; i >= 0; i-- {
+		for j := cols - 1; j >= 0; j-- {
+			if eq(prefix+i, prefix+j) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	out := make([]match, 0, prefix+lengths[0][0]+suffix)
+	for k := 0; k < prefix; k++ {
+		out = append(out, match{k, k})
+	}
+	for i, j := 0, 0; i < rows && j < cols; {
+		switch {
+		case eq(prefix+i, prefix+j):
+			out = append(out, match{prefix + i, prefix + j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	for k := suffix; k > 0; k-- {
+		out = append(out, match{n - k, m - k})
+	}
+	return out
+}
+
+// detectMoves replaces pairs of remove and add operations of equal values
+// with a single move operation
+// moving a value changes the positions seen by the operations in between,
+// so a candidate is only kept if the resulting patch still transforms src into dst
+func detectMoves(src, dst interface{}, p Patch, removed map[int]interface{}) Patch {
+	var candidates int
+	for r := 0; r < len(p) && candidates < maxCandidates; r++ {
+		old, ok := removed[r]
+		if !ok || p[r].Op != Remove {
+			continue
+		}
+		for a := range p {
+			if p[a].Op != Add || !Equal(old, p[a].Value) {
+				continue
+			}
+			candidates++
+			if moved, gone, ok := tryMove(src, dst, p, r, a); ok {
+				p = moved
+				removed = shiftRemoved(removed, r, gone)
+				r--
+				break
+			}
+			if candidates >= maxCandidates {
+				break
+			}
+		}
+	}
+	return p
+}
+
+// tryMove attempts to merge the remove operation at r and the add operation at a
+// the move is placed at the position of either of the two operations,
+// the index of the other, which no longer exists, is returned along with the new patch
+func tryMove(src, dst interface{}, p Patch, r, a int) (Patch, int, bool) {
+	move := Operation{Op: Move, From: p[r].Path, Path: p[a].Path}
+	for _, at := range []int{a, r} {
+		candidate := make(Patch, 0, len(p)-1)
+		for k, op := range p {
+			switch k {
+			case at:
+				candidate = append(candidate, move)
+			case r, a:
+			default:
+				candidate = append(candidate, op)
+			}
+		}
+		if yields(src, dst, candidate) {
+			gone := r
+			if at == r {
+				gone = a
+			}
+			return candidate, gone, true
+		}
+	}
+	return nil, 0, false
+}
+
+// shiftRemoved updates the operation indices of removed after the remove
+// operation at r has been merged into a move and the operation at gone dropped
+func shiftRemoved(removed map[int]interface{}, r, gone int) map[int]interface{} {
+	out := make(map[int]interface{}, len(removed))
+	for k, v := range removed {
+		switch {
+		case k == r:
+		case k > gone:
+			out[k-1] = v
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// detectCopies replaces add operations whose value can be found in src
+// with a copy operation, when that makes the operation shorter
+func detectCopies(src, dst interface{}, p Patch) Patch {
+	var sources []located
+	collectContainers(pointer.Pointer{}, src, &sources)
+
+	var candidates int
+	for a := 0; a < len(p) && candidates < maxCandidates; a++ {
+		if p[a].Op != Add {
+			continue
+		}
+		for _, s := range sources {
+			if !Equal(s.value, p[a].Value) || !shorterAsCopy(s.path, p[a].Value) {
+				continue
+			}
+			candidates++
+			candidate := append(Patch{}, p...)
+			candidate[a] = Operation{Op: Copy, From: s.path, Path: p[a].Path}
+			if yields(src, dst, candidate) {
+				p = candidate
+				break
+			}
+			if candidates >= maxCandidates {
+				break
+			}
+		}
+	}
+	return p
+}
+
+// located is a value along with the JSON Pointer it was found at
+type located struct {
+	path  string
+	value interface{}
+}
+
+// collects every non-empty object and array in v, along with its location
+func collectContainers(path pointer.Pointer, v interface{}, out *[]located) {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		if len(c) > 0 {
+			*out = append(*out, located{path.String(), c})
+		}
+		for _, k := range encode.SortedKeys(c) {
+			collectContainers(path.Append(k), c[k], out)
+		}
+	case []interface{}:
+		if len(c) > 0 {
+			*out = append(*out, located{path.String(), c})
+		}
+		for i, v := range c {
+			collectContainers(path.AppendIndex(i), v, out)
+		}
+	}
+}
+
+func shorterAsCopy(from string, v interface{}) bool {
+	encoded, err := encode.Marshal(v)
+	return err == nil && len(from) < len(encoded)
+}
+
+// yields reports whether applying p to src results in dst
+func yields(src, dst interface{}, p Patch) bool {
+	got, err := Apply(src, p)
+	return err == nil && Equal(got, dst)
+}
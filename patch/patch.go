@@ -0,0 +1,386 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// the operations defined by RFC 6902
+const (
+	Add     = "add"
+	Remove  = "remove"
+	Replace = "replace"
+	Move    = "move"
+	Copy    = "copy"
+	Test    = "test"
+)
+
+// Operation is a single JSON Patch operation
+// From is only used by move and copy, Value only by add, replace and test
+type Operation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// Patch is an RFC 6902 JSON Patch, a sequence of operations applied in order
+type Patch []Operation
+
+// Parse reads a JSON Patch document from r
+func Parse(r io.Reader) (Patch, error) {
+	doc, err := parse.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return FromValue(doc)
+}
+
+// FromValue converts an already parsed JSON Patch document into a Patch
+func FromValue(doc interface{}) (Patch, error) {
+	arr, ok := doc.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON Patch must be an array, got: %T", doc)
+	}
+
+	out := make(Patch, 0, len(arr))
+	for i, v := range arr {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Operation %d must be an object, got: %T", i, v)
+		}
+		op, err := operationFromObject(obj)
+		if err != nil {
+			return nil, fmt.Errorf("Operation %d: %v", i, err)
+		}
+		out = append(out, op)
+	}
+	return out, nil
+}
+
+func operationFromObject(obj map[string]interface{}) (Operation, error) {
+	var op Operation
+	var err error
+	if op.Op, err = stringMember(obj, "op"); err != nil {
+		return op, err
+	}
+	if op.Path, err = stringMember(obj, "path"); err != nil {
+		return op, err
+	}
+
+	switch op.Op {
+	case Add, Replace, Test:
+		v, ok := obj["value"]
+		if !ok {
+			return op, fmt.Errorf("Missing %q member for %q operation", "value", op.Op)
+		}
+		op.Value = v
+	case Move, Copy:
+		if op.From, err = stringMember(obj, "from"); err != nil {
+			return op, err
+		}
+	case Remove:
+	default:
+		return op, fmt.Errorf("Unknown operation %q", op.Op)
+	}
+	return op, nil
+}
+
+func stringMember(obj map[string]interface{}, key string) (string, error) {
+	v, ok := obj[key]
+	if !ok {
+		return "", fmt.Errorf("Missing %q member", key)
+	}
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("Member %q must be a string, got: %T", key, v)
+	}
+	return str, nil
+}
+
+// MarshalJSON encodes p as a JSON Patch document
+// members of each operation are written in the order used by RFC 6902
+func (p Patch) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, op := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"op":`)
+		encode.WriteString(&buf, op.Op)
+		if op.Op == Move || op.Op == Copy {
+			buf.WriteString(`,"from":`)
+			encode.WriteString(&buf, op.From)
+		}
+		buf.WriteString(`,"path":`)
+		encode.WriteString(&buf, op.Path)
+		if op.Op == Add || op.Op == Replace || op.Op == Test {
+			v, err := encode.Marshal(op.Value)
+			if err != nil {
+				return nil, err
+			}
+			buf.WriteString(`,"value":`)
+			buf.Write(v)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// Apply applies p to doc and returns the resulting document
+// doc is not modified, the operations are applied to a deep copy of it
+// if any operation fails the whole patch fails and an error is returned
+func Apply(doc interface{}, p Patch) (interface{}, error) {
+	doc = DeepCopy(doc)
+	for i, op := range p {
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("Operation %d (%s %q): %v", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	path, err := pointer.Parse(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case Add:
+		return add(doc, path, DeepCopy(op.Value))
+	case Remove:
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case Replace:
+		if _, err := pointer.Get(doc, path); err != nil {
+			return nil, err
+		}
+		return replace(doc, path, DeepCopy(op.Value))
+	case Move:
+		from, err := pointer.Parse(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) > len(from) && path.HasPrefix(from) {
+			return nil, fmt.Errorf("Cannot move %q into one of its children", op.From)
+		}
+		doc, v, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case Copy:
+		from, err := pointer.Parse(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := pointer.Get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, DeepCopy(v))
+	case Test:
+		v, err := pointer.Get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !Equal(v, op.Value) {
+			return nil, fmt.Errorf("Test failed, value at %q differs", op.Path)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("Unknown operation %q", op.Op)
+}
+
+// add returns doc with v added at path
+// adding to an existing object member replaces it,
+// adding to an array inserts before the given index, "-" appends
+func add(doc interface{}, path pointer.Pointer, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return modifyParent(doc, path, func(parent interface{}, tok string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			c[tok] = v
+			return c, nil
+		case []interface{}:
+			idx := len(c)
+			if tok != "-" {
+				var err error
+				if idx, err = pointer.Index(tok, len(c)+1); err != nil {
+					return nil, err
+				}
+			}
+			c = append(c, nil)
+			copy(c[idx+1:], c[idx:])
+			c[idx] = v
+			return c, nil
+		}
+		return nil, fmt.Errorf("Cannot add to %T", parent)
+	})
+}
+
+// remove returns doc with the value at path removed, along with the removed value
+func remove(doc interface{}, path pointer.Pointer) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("Cannot remove the whole document")
+	}
+	var removed interface{}
+	doc, err := modifyParent(doc, path, func(parent interface{}, tok string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			v, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("Key %q not found", tok)
+			}
+			removed = v
+			delete(c, tok)
+			return c, nil
+		case []interface{}:
+			idx, err := pointer.Index(tok, len(c))
+			if err != nil {
+				return nil, err
+			}
+			removed = c[idx]
+			return append(c[:idx], c[idx+1:]...), nil
+		}
+		return nil, fmt.Errorf("Cannot remove from %T", parent)
+	})
+	return doc, removed, err
+}
+
+// replace returns doc with the existing value at path replaced by v
+func replace(doc interface{}, path pointer.Pointer, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return modifyParent(doc, path, func(parent interface{}, tok string) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			c[tok] = v
+			return c, nil
+		case []interface{}:
+			idx, err := pointer.Index(tok, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c[idx] = v
+			return c, nil
+		}
+		return nil, fmt.Errorf("Cannot replace in %T", parent)
+	})
+}
+
+// modifyParent walks doc down to the parent of the value at path and calls f
+// with that parent and the last token of path
+// the container returned by f takes the place of the parent, which is
+// necessary since arrays may be reallocated when they grow
+func modifyParent(doc interface{}, path pointer.Pointer, f func(interface{}, string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		child, ok := c[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("Key %q not found", path[0])
+		}
+		newChild, err := modifyParent(child, path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		c[path[0]] = newChild
+		return c, nil
+	case []interface{}:
+		idx, err := pointer.Index(path[0], len(c))
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := modifyParent(c[idx], path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		c[idx] = newChild
+		return c, nil
+	}
+	return nil, fmt.Errorf("Cannot index into %T", doc)
+}
+
+// DeepCopy returns a copy of v that shares no objects or arrays with it
+func DeepCopy(v interface{}) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(c))
+		for k, v := range c {
+			out[k] = DeepCopy(v)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(c))
+		for i, v := range c {
+			out[i] = DeepCopy(v)
+		}
+		return out
+	}
+	return v
+}
+
+// Equal reports whether v1 and v2 represent the same JSON value
+// numbers are compared by value, so 1 and 1.0 are equal
+func Equal(v1, v2 interface{}) bool {
+	switch actualV1 := v1.(type) {
+	case map[string]interface{}:
+		actualV2, ok := v2.(map[string]interface{})
+		if !ok || len(actualV1) != len(actualV2) {
+			return false
+		}
+		for k, v := range actualV1 {
+			other, ok := actualV2[k]
+			if !ok || !Equal(v, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		actualV2, ok := v2.([]interface{})
+		if !ok || len(actualV1) != len(actualV2) {
+			return false
+		}
+		for i, v := range actualV1 {
+			if !Equal(v, actualV2[i]) {
+				return false
+			}
+		}
+		return true
+	case int:
+		f, ok := toFloat(v2)
+		return ok && float64(actualV1) == f
+	case float64:
+		f, ok := toFloat(v2)
+		return ok && actualV1 == f
+	default:
+		return v1 == v2
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,463 @@
+package patch
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+func mustParse(t *testing.T, str string) interface{} {
+	v, err := parse.Parse(strings.NewReader(str))
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", str, err)
+	}
+	return v
+}
+
+// cases from Appendix A of RFC 6902
+func TestApply(t *testing.T) {
+	tests := []struct {
+		doc     string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			doc:   `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			want:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			doc:     `{"baz": "qux"}`,
+			patch:   `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			wantErr: true,
+		},
+		{
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			doc:     `{"foo": "bar"}`,
+			patch:   `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			wantErr: true,
+		},
+		{
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		{
+			doc:   `{"foo": {"bar": 1}}`,
+			patch: `[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "replace", "path": "/baz/bar", "value": 2}]`,
+			want:  `{"foo": {"bar": 1}, "baz": {"bar": 2}}`,
+		},
+		{
+			doc:     `{"foo": {"bar": 1}}`,
+			patch:   `[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`,
+			wantErr: true,
+		},
+		{
+			doc:     `{"foo": [1]}`,
+			patch:   `[{"op": "add", "path": "/foo/2", "value": 2}]`,
+			wantErr: true,
+		},
+		{
+			doc:     `{"foo": 1}`,
+			patch:   `[{"op": "replace", "path": "/bar", "value": 2}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		doc := mustParse(t, test.doc)
+		p, err := Parse(strings.NewReader(test.patch))
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", test.patch, err)
+		}
+
+		got, err := Apply(doc, p)
+
+		gotErr := err != nil
+		if gotErr != test.wantErr || (!gotErr && !Equal(got, mustParse(t, test.want))) {
+			t.Errorf("doc: %s, patch: %s, got: %v, want: %s, err: %v, wantErr: %v",
+				test.doc, test.patch, got, test.want, err, test.wantErr)
+		}
+	}
+}
+
+func TestApplyDoesNotModifyDoc(t *testing.T) {
+	doc := mustParse(t, `{"a": [1, 2, 3], "b": {"c": 1}}`)
+	p := Patch{
+		{Op: Remove, Path: "/a/0"},
+		{Op: Add, Path: "/b/d", Value: 2},
+	}
+
+	if _, err := Apply(doc, p); err != nil {
+		t.Fatalf("Apply(): %v", err)
+	}
+	if want := mustParse(t, `{"a": [1, 2, 3], "b": {"c": 1}}`); !Equal(doc, want) {
+		t.Errorf("doc was modified to: %v", doc)
+	}
+}
+
+func TestParseInvalidPatch(t *testing.T) {
+	patches := []string{
+		`{"op": "add", "path": "/a", "value": 1}`,
+		`[1]`,
+		`[{"path": "/a"}]`,
+		`[{"op": "add", "path": "/a"}]`,
+		`[{"op": "move", "path": "/a"}]`,
+		`[{"op": "frobnicate", "path": "/a"}]`,
+		`[{"op": "remove", "path": 1}]`,
+	}
+
+	for _, str := range patches {
+		if p, err := Parse(strings.NewReader(str)); err == nil {
+			t.Errorf("patch: %s, expected error, got: %v", str, p)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		src, dst string
+		want     Patch
+	}{
+		{src: `{"a": 1}`, dst: `{"a": 1}`, want: nil},
+		{src: `1`, dst: `1.0`, want: nil},
+		{
+			src:  `{"a": 1, "b": 2}`,
+			dst:  `{"a": 1, "b": 3, "c": 4}`,
+			want: Patch{{Op: Replace, Path: "/b", Value: 3}, {Op: Add, Path: "/c", Value: 4}},
+		},
+		{
+			src:  `[1, 2, 3, 4]`,
+			dst:  `[1, 5, 2, 3, 4]`,
+			want: Patch{{Op: Add, Path: "/1", Value: 5}},
+		},
+		{
+			src:  `[1, 2, 3, 4]`,
+			dst:  `[1, 3, 4]`,
+			want: Patch{{Op: Remove, Path: "/1"}},
+		},
+		{
+			src:  `{"a": [{"x": 1, "y": 2}, 7]}`,
+			dst:  `{"a": [{"x": 1, "y": 3}, 7]}`,
+			want: Patch{{Op: Replace, Path: "/a/0/y", Value: 3}},
+		},
+		{
+			src:  `{"old": {"deep": [1, 2, 3]}}`,
+			dst:  `{"new": {"deep": [1, 2, 3]}}`,
+			want: Patch{{Op: Move, From: "/old", Path: "/new"}},
+		},
+		{
+			src:  `["all", "grass", "cows", "eat"]`,
+			dst:  `["all", "cows", "eat", "grass"]`,
+			want: Patch{{Op: Move, From: "/1", Path: "/3"}},
+		},
+		{
+			src:  `{"template": {"name": "default", "ports": [80, 443]}}`,
+			dst:  `{"template": {"name": "default", "ports": [80, 443]}, "web": {"name": "default", "ports": [80, 443]}}`,
+			want: Patch{{Op: Copy, From: "/template", Path: "/web"}},
+		},
+		{
+			src:  `{"a": 1}`,
+			dst:  `[1]`,
+			want: Patch{{Op: Replace, Path: "", Value: []interface{}{1}}},
+		},
+	}
+
+	for _, test := range tests {
+		src, dst := mustParse(t, test.src), mustParse(t, test.dst)
+
+		got := Diff(src, dst)
+
+		if !patchesEqual(got, test.want) {
+			t.Errorf("src: %s, dst: %s, got: %v, want: %v", test.src, test.dst, got, test.want)
+		}
+		if applied, err := Apply(src, got); err != nil || !Equal(applied, dst) {
+			t.Errorf("src: %s, dst: %s, applying %v got: %v, err: %v", test.src, test.dst, got, applied, err)
+		}
+	}
+}
+
+func TestDiffBoundsCopyCandidates(t *testing.T) {
+	template := map[string]interface{}{"name": "default", "ports": []interface{}{80, 443}}
+	src := map[string]interface{}{"template": template}
+	dst := map[string]interface{}{"template": template}
+	for i := 0; i < 2*maxCandidates; i++ {
+		dst[fmt.Sprintf("web%d", i)] = DeepCopy(template)
+	}
+
+	got := Diff(src, dst)
+
+	var copies int
+	for _, op := range got {
+		if op.Op == Copy {
+			copies++
+		}
+	}
+	if copies != maxCandidates {
+		t.Errorf("got: %d copies, want: %d", copies, maxCandidates)
+	}
+	if applied, err := Apply(src, got); err != nil || !Equal(applied, dst) {
+		t.Errorf("applying %v got: %v, err: %v", got, applied, err)
+	}
+}
+
+func patchesEqual(p1, p2 Patch) bool {
+	if len(p1) != len(p2) {
+		return false
+	}
+	for i, op := range p1 {
+		other := p2[i]
+		if op.Op != other.Op || op.Path != other.Path || op.From != other.From || !Equal(op.Value, other.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMarshalJSON(t *testing.T) {
+	p := Patch{
+		{Op: Add, Path: "/a~1b", Value: map[string]interface{}{"c": []interface{}{1, nil}}},
+		{Op: Move, From: "/x", Path: "/y"},
+		{Op: Remove, Path: "/z"},
+	}
+	want := `[{"op":"add","path":"/a~1b","value":{"c":[1,null]}},{"op":"move","from":"/x","path":"/y"},{"op":"remove","path":"/z"}]`
+
+	got, err := p.MarshalJSON()
+	if err != nil || string(got) != want {
+		t.Errorf("got: %s, want: %s, err: %v", got, want, err)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	src := mustParse(t, `{"a": 1, "b": [1, 2], "c": "same", "d": "same", "e": "same", "f": "same", "g": true}`)
+	dst := mustParse(t, `{"a": 2, "b": [1, 2], "c": "same", "d": "same", "e": "same", "f": "same", "g": false}`)
+	want := `--- src
++++ dst
+@@ -1,5 +1,5 @@
+ {
+-  "a": 1,
++  "a": 2,
+   "b": [
+     1,
+     2
+@@ -8,5 +8,5 @@
+   "d": "same",
+   "e": "same",
+   "f": "same",
+-  "g": true
++  "g": false
+ }
+`
+
+	got, err := Unified(src, dst)
+	if err != nil || got != want {
+		t.Errorf("got:\n%s\nwant:\n%s\nerr: %v", got, want, err)
+	}
+
+	if got, _ := Unified(src, src); got != "" {
+		t.Errorf("Unified() of equal documents got: %q, want empty", got)
+	}
+}
+
+// docPair is a randomly generated document along with a random modification of it
+type docPair struct {
+	src, dst interface{}
+}
+
+func (docPair) Generate(r *rand.Rand, size int) reflect.Value {
+	src := randomValue(r, 3)
+	dst := mutate(r, DeepCopy(src), 3)
+	return reflect.ValueOf(docPair{src: src, dst: dst})
+}
+
+func randomValue(r *rand.Rand, depth int) interface{} {
+	kind := r.Intn(8)
+	if depth == 0 {
+		kind = r.Intn(5)
+	}
+	switch kind {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return r.Intn(20) - 10
+	case 3:
+		return float64(r.Intn(2000)-1000) / 8
+	case 4:
+		return randomKey(r)
+	case 5, 6:
+		obj := map[string]interface{}{}
+		for i := r.Intn(5); i > 0; i-- {
+			obj[randomKey(r)] = randomValue(r, depth-1)
+		}
+		return obj
+	default:
+		arr := make([]interface{}, r.Intn(6))
+		for i := range arr {
+			arr[i] = randomValue(r, depth-1)
+		}
+		return arr
+	}
+}
+
+func randomKey(r *rand.Rand) string {
+	return string(rune('a' + r.Intn(6)))
+}
+
+// mutate randomly inserts, removes, replaces, moves and duplicates
+// values within v, recursing into containers
+func mutate(r *rand.Rand, v interface{}, depth int) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		for _, k := range keys(c) {
+			switch r.Intn(6) {
+			case 0:
+				delete(c, k)
+			case 1:
+				c[k] = randomValue(r, depth)
+			case 2:
+				// rename, which should be detected as a move
+				c[randomKey(r)+k] = c[k]
+				delete(c, k)
+			case 3:
+				c[k] = mutate(r, c[k], depth-1)
+			}
+		}
+		if r.Intn(3) == 0 {
+			c[randomKey(r)] = randomValue(r, depth)
+		}
+		return c
+	case []interface{}:
+		out := make([]interface{}, 0, len(c))
+		for _, v := range c {
+			switch r.Intn(6) {
+			case 0:
+			case 1:
+				out = append(out, randomValue(r, depth), v)
+			case 2:
+				out = append(out, mutate(r, v, depth-1))
+			default:
+				out = append(out, v)
+			}
+		}
+		if len(out) > 1 && r.Intn(3) == 0 {
+			i, j := r.Intn(len(out)), r.Intn(len(out))
+			out[i], out[j] = out[j], out[i]
+		}
+		return out
+	}
+	if r.Intn(3) == 0 {
+		return randomValue(r, depth)
+	}
+	return v
+}
+
+func keys(m map[string]interface{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func TestDiffApplyProperty(t *testing.T) {
+	f := func(pair docPair) bool {
+		p := Diff(pair.src, pair.dst)
+		got, err := Apply(pair.src, p)
+		if err != nil {
+			t.Logf("Apply(%v, %v): %v", pair.src, p, err)
+			return false
+		}
+		return Equal(got, pair.dst)
+	}
+
+	if err := quick.Check(f, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+// the patch must still apply correctly after going through its JSON encoding
+func TestDiffEncodeApplyProperty(t *testing.T) {
+	f := func(pair docPair) bool {
+		encoded, err := Diff(pair.src, pair.dst).MarshalJSON()
+		if err != nil {
+			return false
+		}
+		p, err := Parse(strings.NewReader(string(encoded)))
+		if err != nil {
+			t.Logf("Parse(%s): %v", encoded, err)
+			return false
+		}
+		got, err := Apply(pair.src, p)
+		return err == nil && Equal(got, pair.dst)
+	}
+
+	if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDiffEmptyOnEqualProperty(t *testing.T) {
+	f := func(pair docPair) bool {
+		return len(Diff(pair.src, DeepCopy(pair.src))) == 0
+	}
+
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func ExampleDiff() {
+	src, _ := parse.Parse(strings.NewReader(`{"name": "svc", "replicas": 2, "ports": [80]}`))
+	dst, _ := parse.Parse(strings.NewReader(`{"name": "svc", "replicas": 3, "ports": [80, 443]}`))
+
+	encoded, _ := Diff(src, dst).MarshalJSON()
+	fmt.Println(string(encoded))
+	// Output: [{"op":"add","path":"/ports/1","value":443},{"op":"replace","path":"/replicas","value":3}]
+}
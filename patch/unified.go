@@ -0,0 +1,136 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vyevs/gojson/encode"
+)
+
+// number of unchanged lines shown around every change by Unified
+const contextLines = 3
+
+// Unified renders the difference between src and dst as a unified diff
+// of their indented encodings, the format produced by diff -u
+// it is meant for people reviewing a change, use Diff for a machine readable one
+func Unified(src, dst interface{}) (string, error) {
+	srcBytes, err := encode.MarshalIndent(src, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	dstBytes, err := encode.MarshalIndent(dst, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	a := strings.Split(string(srcBytes), "\n")
+	b := strings.Split(string(dstBytes), "\n")
+	matches := lcs(len(a), len(b), func(i, j int) bool {
+		return a[i] == b[j]
+	})
+	lines := diffLines(a, b, matches)
+
+	var builder strings.Builder
+	for _, h := range hunks(lines) {
+		if builder.Len() == 0 {
+			builder.WriteString("--- src\n+++ dst\n")
+		}
+		h.writeTo(&builder)
+	}
+	return builder.String(), nil
+}
+
+// line is a single line of a unified diff
+// op is one of ' ', '-' or '+', a and b are the line's numbers in each side
+type line struct {
+	op   byte
+	text string
+	a, b int
+}
+
+// merges a and b into a single sequence of kept, removed and added lines
+func diffLines(a, b []string, matches []match) []line {
+	matches = append(matches, match{len(a), len(b)})
+
+	var out []line
+	var i, j int
+	for _, m := range matches {
+		for ; i < m.i; i++ {
+			out = append(out, line{op: '-', text: a[i], a: i, b: j})
+		}
+		for ; j < m.j; j++ {
+			out = append(out, line{op: '+', text: b[j], a: i, b: j})
+		}
+		if m.i < len(a) {
+			out = append(out, line{op: ' ', text: a[i], a: i, b: j})
+		}
+		i, j = m.i+1, m.j+1
+	}
+	return out
+}
+
+type hunk struct {
+	lines []line
+}
+
+// groups the changed lines into hunks, each surrounded by up to
+// contextLines unchanged lines, hunks whose context overlaps are merged
+func hunks(lines []line) []hunk {
+	var out []hunk
+	for i := 0; i < len(lines); i++ {
+		if lines[i].op == ' ' {
+			continue
+		}
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// extend the hunk while the next change is within reach of its context
+		end := i
+		for k := i; k < len(lines) && k <= end+2*contextLines; k++ {
+			if lines[k].op != ' ' {
+				end = k
+			}
+		}
+		stop := end + contextLines + 1
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		out = append(out, hunk{lines: lines[start:stop]})
+		i = stop - 1
+	}
+	return out
+}
+
+func (h hunk) writeTo(builder *strings.Builder) {
+	var aLen, bLen int
+	for _, l := range h.lines {
+		if l.op != '+' {
+			aLen++
+		}
+		if l.op != '-' {
+			bLen++
+		}
+	}
+	first := h.lines[0]
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n", hunkRange(first.a, aLen), hunkRange(first.b, bLen))
+	for _, l := range h.lines {
+		builder.WriteByte(l.op)
+		builder.WriteString(l.text)
+		builder.WriteByte('\n')
+	}
+}
+
+// formats a hunk's line range as diff -u does, line numbers begin at 1
+// and an empty range refers to the line before it
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
@@ -0,0 +1,155 @@
+package pointer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pointer is a JSON Pointer (RFC 6901) split into its reference tokens
+// the empty Pointer refers to the whole document
+type Pointer []string
+
+// Parse parses the string representation of a JSON Pointer
+// e.g.: "/a/0/b~1c" parses into ["a", "0", "b/c"]
+func Parse(str string) (Pointer, error) {
+	if str == "" {
+		return Pointer{}, nil
+	}
+	if str[0] != '/' {
+		return nil, fmt.Errorf("JSON Pointer must begin with %q, got: %q", "/", str)
+	}
+
+	toks := strings.Split(str[1:], "/")
+	for i, t := range toks {
+		unescaped, err := unescape(t)
+		if err != nil {
+			return nil, err
+		}
+		toks[i] = unescaped
+	}
+	return Pointer(toks), nil
+}
+
+// MustParse is like Parse but panics if str is not a valid JSON Pointer
+// intended for pointers that are known to be valid, e.g. constants
+func MustParse(str string) Pointer {
+	p, err := Parse(str)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the RFC 6901 string representation of p
+func (p Pointer) String() string {
+	var builder strings.Builder
+	for _, t := range p {
+		builder.WriteByte('/')
+		builder.WriteString(escape(t))
+	}
+	return builder.String()
+}
+
+// Append returns a new Pointer with tok added to the end of p
+// p itself is never modified
+func (p Pointer) Append(tok string) Pointer {
+	out := make(Pointer, len(p), len(p)+1)
+	copy(out, p)
+	return append(out, tok)
+}
+
+// AppendIndex returns a new Pointer with the array index i added to the end of p
+func (p Pointer) AppendIndex(i int) Pointer {
+	return p.Append(strconv.Itoa(i))
+}
+
+// HasPrefix reports whether prefix refers to p or to one of its ancestors
+func (p Pointer) HasPrefix(prefix Pointer) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+	for i, t := range prefix {
+		if p[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the value that p refers to within doc
+// doc is expected to be made up of the values produced by the parser
+func Get(doc interface{}, p Pointer) (interface{}, error) {
+	cur := doc
+	for i, t := range p {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			child, ok := v[t]
+			if !ok {
+				return nil, fmt.Errorf("Key %q not found at %q", t, p[:i].String())
+			}
+			cur = child
+		case []interface{}:
+			idx, err := Index(t, len(v))
+			if err != nil {
+				return nil, err
+			}
+			cur = v[idx]
+		default:
+			return nil, fmt.Errorf("Cannot index into %T at %q", cur, p[:i].String())
+		}
+	}
+	return cur, nil
+}
+
+// Index converts the reference token tok into an index of an array of length n
+// the "-" token and indices with leading zeros are rejected, as are indices out of range
+func Index(tok string, n int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("Invalid array index: %q", tok)
+	}
+	for i := 0; i < len(tok); i++ {
+		if tok[i] < '0' || tok[i] > '9' {
+			return 0, fmt.Errorf("Invalid array index: %q", tok)
+		}
+	}
+	idx, err := strconv.Atoi(tok)
+	if err != nil || idx >= n {
+		return 0, fmt.Errorf("Array index %q out of range, array length is %d", tok, n)
+	}
+	return idx, nil
+}
+
+func unescape(tok string) (string, error) {
+	if !strings.Contains(tok, "~") {
+		return tok, nil
+	}
+	var builder strings.Builder
+	for i := 0; i < len(tok); i++ {
+		if tok[i] != '~' {
+			builder.WriteByte(tok[i])
+			continue
+		}
+		if i+1 == len(tok) {
+			return "", fmt.Errorf("Invalid escape sequence in %q", tok)
+		}
+		i++
+		switch tok[i] {
+		case '0':
+			builder.WriteByte('~')
+		case '1':
+			builder.WriteByte('/')
+		default:
+			return "", fmt.Errorf("Invalid escape sequence in %q", tok)
+		}
+	}
+	return builder.String(), nil
+}
+
+func escape(tok string) string {
+	if !strings.ContainsAny(tok, "~/") {
+		return tok
+	}
+	tok = strings.Replace(tok, "~", "~0", -1)
+	return strings.Replace(tok, "/", "~1", -1)
+}
@@ -0,0 +1,81 @@
+package pointer
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		str     string
+		want    Pointer
+		wantErr bool
+	}{
+		{str: "", want: Pointer{}},
+		{str: "/", want: Pointer{""}},
+		{str: "/a/0/b", want: Pointer{"a", "0", "b"}},
+		{str: "/a~1b/m~0n", want: Pointer{"a/b", "m~n"}},
+		{str: "/~01", want: Pointer{"~1"}},
+		{str: "a", wantErr: true},
+		{str: "/a~2", wantErr: true},
+		{str: "/a~", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.str)
+
+		gotErr := err != nil
+		if gotErr != test.wantErr || !pointersEqual(got, test.want) {
+			t.Errorf("str: %q, got: %q, want: %q, err: %v, wantErr: %v",
+				test.str, got, test.want, err, test.wantErr)
+		}
+		if err == nil && got.String() != test.str {
+			t.Errorf("str: %q, String() round trip got: %q", test.str, got.String())
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	doc := map[string]interface{}{
+		"foo": []interface{}{"bar", "baz"},
+		"":    0,
+		"a/b": 1,
+		"m~n": 8,
+	}
+
+	tests := []struct {
+		str     string
+		want    interface{}
+		wantErr bool
+	}{
+		{str: "/foo/0", want: "bar"},
+		{str: "/foo/1", want: "baz"},
+		{str: "/", want: 0},
+		{str: "/a~1b", want: 1},
+		{str: "/m~0n", want: 8},
+		{str: "/foo/2", wantErr: true},
+		{str: "/foo/01", wantErr: true},
+		{str: "/foo/-", wantErr: true},
+		{str: "/bar", wantErr: true},
+		{str: "/a~1b/c", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := Get(doc, MustParse(test.str))
+
+		gotErr := err != nil
+		if gotErr != test.wantErr || (!gotErr && got != test.want) {
+			t.Errorf("str: %q, got: %v, want: %v, err: %v, wantErr: %v",
+				test.str, got, test.want, err, test.wantErr)
+		}
+	}
+}
+
+func pointersEqual(p1, p2 Pointer) bool {
+	if len(p1) != len(p2) {
+		return false
+	}
+	for i, t := range p1 {
+		if t != p2[i] {
+			return false
+		}
+	}
+	return true
+}
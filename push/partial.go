@@ -0,0 +1,186 @@
+package push
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/pointer"
+	"github.com/vyevs/gojson/tok"
+)
+
+var errTrailing = errors.New("Expected end of document, found another value")
+
+// ParsePartial reads r to its end and returns the best-effort value of the
+// document in it, which may be cut off, as streamed output or logs can be
+// it returns an error only if the input is not the beginning of a single document
+//
+// open strings, arrays and objects are closed, incomplete escape sequences
+// and literals, members without a value and numbers that are not valid yet
+// are dropped, incomplete lists the pointers to the values that were cut off,
+// outermost first, and is empty if the document is complete
+// a cut-off number is kept but reported, as more digits may have followed
+// an input with no value at all returns nil, with the document reported
+func ParsePartial(r io.Reader) (interface{}, []pointer.Pointer, error) {
+	var doc interface{}
+	var done bool
+	p := New(func(e Event) error {
+		if done {
+			return errTrailing
+		}
+		if e.Type == Value && len(e.Path) == 0 {
+			doc, done = e.Value, true
+		}
+		return nil
+	})
+	if _, err := io.Copy(p, r); err != nil {
+		return nil, nil, err
+	}
+
+	if !p.inProgress() {
+		if !done {
+			return nil, []pointer.Pointer{{}}, nil
+		}
+		return doc, nil, nil
+	}
+	if done {
+		return nil, nil, errTrailing
+	}
+	v, incomplete := p.Partial()
+	return v, incomplete, nil
+}
+
+// reports whether the Parser is within a document
+func (p *Parser) inProgress() bool {
+	switch p.state {
+	case stateString, stateNumber, stateLiteral:
+		return true
+	}
+	return len(p.stack) > 0
+}
+
+// Partial returns the best-effort value of the document the Parser is within,
+// as if the input ended here, and the pointers to the values that are incomplete,
+// as ParsePartial does, it returns nil and no pointers between documents
+// the objects and arrays that are still open are copied, the values within them
+// are shared with the Parser and must not be modified
+func (p *Parser) Partial() (interface{}, []pointer.Pointer) {
+	if !p.inProgress() {
+		return nil, nil
+	}
+
+	var incomplete []pointer.Pointer // innermost first
+	v, ok, cut := p.pending()
+	if cut {
+		incomplete = append(incomplete, parse.PathPointer(p.path))
+	}
+
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		c := p.stack[i]
+		if c.obj != nil {
+			obj := make(map[string]interface{}, len(c.obj)+1)
+			for k, member := range c.obj {
+				obj[k] = member
+			}
+			if ok {
+				obj[p.path[i].Key] = v
+			}
+			v = obj
+		} else {
+			arr := make([]interface{}, len(c.arr), len(c.arr)+1)
+			copy(arr, c.arr)
+			if ok {
+				arr = append(arr, v)
+			}
+			v = arr
+		}
+		ok = true
+		incomplete = append(incomplete, parse.PathPointer(p.path[:i]))
+	}
+
+	if !ok {
+		// a top-level literal or number that could not be completed
+		incomplete = append(incomplete, pointer.Pointer{})
+	}
+	for i, j := 0, len(incomplete)-1; i < j; i, j = i+1, j-1 {
+		incomplete[i], incomplete[j] = incomplete[j], incomplete[i]
+	}
+	return v, incomplete
+}
+
+// the value being read at the end of the input, if it can be completed,
+// and whether it was cut off
+func (p *Parser) pending() (v interface{}, ok, cut bool) {
+	switch p.state {
+	case stateString:
+		if p.key {
+			return nil, false, false
+		}
+		str, _ := lex.AppendUnescaped(nil, trimEscape(p.lit))
+		return string(str), true, true
+
+	case stateNumber:
+		literal := string(p.lit)
+		switch lex.NumericTokenType(literal) {
+		case tok.Integer:
+			if i, err := strconv.Atoi(literal); err == nil {
+				return i, true, true
+			}
+		case tok.FloatingPoint:
+			if f, err := strconv.ParseFloat(literal, 64); err == nil {
+				return f, true, true
+			}
+		}
+
+	case stateLiteral:
+		switch string(p.lit) {
+		case "true":
+			return true, true, false
+		case "false":
+			return false, true, false
+		case "null":
+			return nil, true, false
+		}
+	}
+	return nil, false, false
+}
+
+// trims an escape sequence cut off at the end of str, including
+// a high surrogate whose low surrogate may have followed
+func trimEscape(str []byte) []byte {
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\\' {
+			continue
+		}
+		if i+1 == len(str) {
+			return str[:i]
+		}
+		if str[i+1] != 'u' {
+			i++
+			continue
+		}
+		if i+6 > len(str) {
+			return str[:i]
+		}
+		if rest := str[i+6:]; isHighSurrogate(str[i+2:i+6]) && len(rest) < 6 && isPrefix(rest, `\u`) {
+			return str[:i]
+		}
+		i += 5
+	}
+	return str
+}
+
+// reports whether b and prefix agree as far as both go
+func isPrefix(b []byte, prefix string) bool {
+	if len(b) > len(prefix) {
+		b = b[:len(prefix)]
+	}
+	return string(b) == prefix[:len(b)]
+}
+
+// reports whether the 4 hex digits of a \u escape are a high surrogate, D800 to DBFF
+func isHighSurrogate(hex []byte) bool {
+	return (hex[0] == 'd' || hex[0] == 'D') && (hex[1] >= '8' && hex[1] <= '9' || hex[1] >= 'a' && hex[1] <= 'b' || hex[1] >= 'A' && hex[1] <= 'B')
+}
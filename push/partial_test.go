@@ -0,0 +1,110 @@
+package push
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePartial(t *testing.T) {
+	tests := []struct {
+		doc            string
+		want           interface{}
+		wantIncomplete []string
+	}{
+		{
+			doc: `{"items":[{"a":1},{"b":"hel`,
+			want: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"a": 1},
+				map[string]interface{}{"b": "hel"},
+			}},
+			wantIncomplete: []string{"", "/items", "/items/1", "/items/1/b"},
+		},
+		{doc: `{"a": [1, 2]}`, want: map[string]interface{}{"a": []interface{}{1, 2}}},
+		{doc: ``, want: nil, wantIncomplete: []string{""}},
+		{doc: `  `, want: nil, wantIncomplete: []string{""}},
+		{doc: `[1, 2`, want: []interface{}{1, 2}, wantIncomplete: []string{"", "/1"}},
+		{doc: `[1, 2.`, want: []interface{}{1}, wantIncomplete: []string{""}},
+		{doc: `[1, -`, want: []interface{}{1}, wantIncomplete: []string{""}},
+		{doc: `[1, tr`, want: []interface{}{1}, wantIncomplete: []string{""}},
+		{doc: `[1, true`, want: []interface{}{1, true}, wantIncomplete: []string{""}},
+		{doc: `[1, null,`, want: []interface{}{1, nil}, wantIncomplete: []string{""}},
+		{doc: `{"a": 1, "b`, want: map[string]interface{}{"a": 1}, wantIncomplete: []string{""}},
+		{doc: `{"a": 1, "b"`, want: map[string]interface{}{"a": 1}, wantIncomplete: []string{""}},
+		{doc: `{"a": 1, "b":`, want: map[string]interface{}{"a": 1}, wantIncomplete: []string{""}},
+		{doc: `{"a": {"b": [[`, want: map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{[]interface{}{}}}},
+			wantIncomplete: []string{"", "/a", "/a/b", "/a/b/0"}},
+		{doc: `"abc`, want: "abc", wantIncomplete: []string{""}},
+		{doc: `"ab\`, want: "ab", wantIncomplete: []string{""}},
+		{doc: `"ab\u00e`, want: "ab", wantIncomplete: []string{""}},
+		{doc: `"abé`, want: "abé", wantIncomplete: []string{""}},
+		{doc: `"ab\ud83d`, want: "ab", wantIncomplete: []string{""}},
+		{doc: `"ab\ud83d\ude`, want: "ab", wantIncomplete: []string{""}},
+		{doc: `"ab😀`, want: "ab😀", wantIncomplete: []string{""}},
+		{doc: `"ab\ud83dx`, want: "ab�x", wantIncomplete: []string{""}},
+		{doc: `12`, want: 12, wantIncomplete: []string{""}},
+		{doc: `nul`, want: nil, wantIncomplete: []string{""}},
+	}
+
+	for _, test := range tests {
+		got, incomplete, err := ParsePartial(strings.NewReader(test.doc))
+
+		var gotIncomplete []string
+		for _, p := range incomplete {
+			gotIncomplete = append(gotIncomplete, p.String())
+		}
+		if err != nil || !reflect.DeepEqual(got, test.want) || !reflect.DeepEqual(gotIncomplete, test.wantIncomplete) {
+			t.Errorf("doc: %q, got: %#v, incomplete: %q, want: %#v, incomplete: %q, err: %v",
+				test.doc, got, gotIncomplete, test.want, test.wantIncomplete, err)
+		}
+	}
+}
+
+func TestParsePartialErrors(t *testing.T) {
+	tests := []struct {
+		doc     string
+		wantErr string
+	}{
+		{doc: `{"a" 1`, wantErr: `Expected colon at offset 5, found: '1'`},
+		{doc: `[1, ]`, wantErr: `Expected value at offset 4, found: ']'`},
+		{doc: `{} {"a"`, wantErr: errTrailing.Error()},
+		{doc: `1 2`, wantErr: errTrailing.Error()},
+		{doc: `[] []`, wantErr: errTrailing.Error()},
+	}
+
+	for _, test := range tests {
+		_, _, err := ParsePartial(strings.NewReader(test.doc))
+
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("doc: %q, got err: %v, want: %s", test.doc, err, test.wantErr)
+		}
+	}
+}
+
+// Partial can be called after every write to follow a document as it streams in,
+// and does not disturb the Parser
+func TestPartialWhileWriting(t *testing.T) {
+	p := New(func(e Event) error { return nil })
+
+	steps := []struct {
+		chunk string
+		want  interface{}
+	}{
+		{chunk: `{"msg": "he`, want: map[string]interface{}{"msg": "he"}},
+		{chunk: `llo", "n": [1`, want: map[string]interface{}{"msg": "hello", "n": []interface{}{1}}},
+		{chunk: `0, 2`, want: map[string]interface{}{"msg": "hello", "n": []interface{}{10, 2}}},
+		{chunk: `]}`, want: nil},
+	}
+	for _, step := range steps {
+		if _, err := p.Write([]byte(step.chunk)); err != nil {
+			t.Fatalf("Write(%q): %v", step.chunk, err)
+		}
+		got, _ := p.Partial()
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("chunk: %q, got: %v, want: %v", step.chunk, got, step.want)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Close(): %v", err)
+	}
+}
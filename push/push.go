@@ -0,0 +1,425 @@
+// Package push parses JSON that is pushed to it in chunks of any size
+//
+// unlike parse.Parse, which pulls bytes from an io.Reader and blocks until
+// they arrive, a Parser is handed bytes with Write as they come in, e.g. from
+// websocket frames or HTTP chunks, and keeps its state between writes, even in
+// the middle of a string, an escape sequence or a number
+// the input is a stream of JSON documents, and the Parser reports events to its
+// Handler as soon as they are known, however the input is split
+package push
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/tok"
+)
+
+// EventType is the type of an Event
+type EventType uint8
+
+// the types of events
+const (
+	BeginObject EventType = iota // an object begins, at Path
+	BeginArray                   // an array begins, at Path
+	Key                          // the key of an object member has been read, it ends Path
+	Value                        // a value at Path is complete, including objects and arrays
+)
+
+var eventNames = [...]string{"begin object", "begin array", "key", "value"}
+
+func (t EventType) String() string {
+	return eventNames[t]
+}
+
+// Event is reported by a Parser
+type Event struct {
+	Type EventType
+
+	// Path leads from the document to the value or key, it is empty
+	// for the document itself and must not be retained after the Handler returns
+	Path []parse.PathElem
+
+	// Value holds the value of Value events, in the form parse.Parse returns it
+	Value interface{}
+}
+
+// Handler is called by a Parser with every event, in the order they occur
+// in the input, a Value event with an empty Path is a whole document
+//
+// returning parse.Drop from a Value event leaves the value out of its object or
+// array, so that the elements of a long array can be handled one at a time
+// without being kept, it is ignored for other events
+// any other error stops the Parser and is returned by Write
+type Handler func(e Event) error
+
+// ErrClosed is returned by Write after Close
+var ErrClosed = errors.New("write to closed Parser")
+
+// the state of a Parser is what it expects the next byte to be part of
+type state uint8
+
+const (
+	stateValue      state = iota // a value, at the top level or after a colon or comma
+	stateFirstElem               // a value or the end of an array
+	stateFirstKey                // a key or the end of an object
+	stateKey                     // a key after a comma
+	stateColon                   // the colon after a key
+	stateAfterValue              // a comma or the end of an object or array
+	stateString                  // a string, the rest of it or its closing quote
+	stateNumber                  // a number, the rest of it or the byte after it
+	stateLiteral                 // true, false or null, the rest of it or the byte after it
+)
+
+// Parser parses a stream of JSON documents written to it in chunks
+//
+// documents may follow each other directly, and need only be separated by
+// whitespace where they would otherwise run together, as numbers would
+// the documents accepted are those parse.Parse accepts
+type Parser struct {
+	handler Handler
+	state   state
+	stack   []container      // the objects and arrays being read
+	path    []parse.PathElem // to the value being read
+
+	// the string, number or literal being read, without the quotes of a string
+	lit       []byte
+	litStart  int64 // offset of the first byte of lit
+	key       bool  // the string being read is a key
+	escaped   bool  // the string being read holds an escape sequence
+	backslash bool  // the last byte of the string being read was a backslash
+
+	off    int64 // offset of the next byte in the stream
+	err    error // once set, returned by every Write
+	closed bool
+}
+
+type container struct {
+	obj     map[string]interface{} // nil for arrays
+	dropped map[string]bool        // the keys of the members of obj the Handler dropped
+	arr     []interface{}
+	index   int // of the next element of an array
+}
+
+// New returns a Parser that reports events to handler
+func New(handler Handler) *Parser {
+	return &Parser{handler: handler}
+}
+
+// Reset discards the state of the Parser and any error it has stopped with,
+// so that it can parse a new stream, reusing its buffers
+func (p *Parser) Reset() {
+	*p = Parser{
+		handler: p.handler,
+		stack:   p.stack[:0],
+		path:    p.path[:0],
+		lit:     p.lit[:0],
+	}
+}
+
+// Write parses data, calling the Handler with every event it completes
+// it returns the number of bytes parsed and the error, if any,
+// that stopped the Parser, which every later Write returns too
+func (p *Parser) Write(data []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	for i := 0; i < len(data); i++ {
+		// strings make up most of most documents, their bytes are copied in runs
+		if p.state == stateString && !p.backslash {
+			j := i
+			for j < len(data) && data[j] != '"' && data[j] != '\\' {
+				j++
+			}
+			p.lit = append(p.lit, data[i:j]...)
+			p.off += int64(j - i)
+			if i = j; i == len(data) {
+				break
+			}
+		}
+		if err := p.step(data[i]); err != nil {
+			p.err = err
+			return i, err
+		}
+		p.off++
+	}
+	return len(data), nil
+}
+
+// Close reports the end of the stream, completing a number or literal
+// at its end, and returns an error if it ends within a document
+func (p *Parser) Close() error {
+	if p.closed {
+		return nil
+	}
+	if p.err != nil {
+		return p.err
+	}
+	if p.state == stateNumber || p.state == stateLiteral {
+		if err := p.endScalar(); err != nil {
+			p.err = err
+			return err
+		}
+	}
+	if p.state != stateValue || len(p.stack) > 0 {
+		p.err = fmt.Errorf("Unexpected end of input at offset %d", p.off)
+		return p.err
+	}
+	p.closed = true
+	p.err = ErrClosed
+	return nil
+}
+
+func (p *Parser) expected(what string, c byte) error {
+	return fmt.Errorf("Expected %s at offset %d, found: %q", what, p.off, c)
+}
+
+// parses the byte c at offset p.off
+func (p *Parser) step(c byte) error {
+	switch p.state {
+	case stateString:
+		if p.backslash {
+			p.backslash = false
+			p.lit = append(p.lit, c)
+			return nil
+		}
+		switch c {
+		case '"':
+			return p.endString()
+		case '\\':
+			p.backslash = true
+			p.escaped = true
+		}
+		p.lit = append(p.lit, c)
+		return nil
+
+	case stateNumber, stateLiteral:
+		if p.state == stateNumber && isNumberByte(c) || p.state == stateLiteral && c >= 'a' && c <= 'z' {
+			p.lit = append(p.lit, c)
+			return nil
+		}
+		// c follows the number or literal, it is parsed in the state after it
+		if err := p.endScalar(); err != nil {
+			return err
+		}
+	}
+
+	if isWhitespace(c) {
+		return nil
+	}
+	switch p.state {
+	case stateFirstElem:
+		if c == ']' {
+			return p.endContainer()
+		}
+		return p.beginValue(c)
+
+	case stateValue:
+		return p.beginValue(c)
+
+	case stateFirstKey, stateKey:
+		if c == '}' && p.state == stateFirstKey {
+			return p.endContainer()
+		}
+		if c != '"' {
+			return p.expected("key", c)
+		}
+		p.beginLiteral(stateString)
+		p.key = true
+
+	case stateColon:
+		if c != ':' {
+			return p.expected("colon", c)
+		}
+		p.state = stateValue
+
+	case stateAfterValue:
+		object := p.top().obj != nil
+		switch {
+		case c == ',' && object:
+			p.state = stateKey
+		case c == ',':
+			p.state = stateValue
+		case c == '}' && object, c == ']' && !object:
+			return p.endContainer()
+		case object:
+			return p.expected("comma or closing curly brace", c)
+		default:
+			return p.expected("comma or closing square bracket", c)
+		}
+	}
+	return nil
+}
+
+func (p *Parser) top() *container {
+	return &p.stack[len(p.stack)-1]
+}
+
+// begins the value whose first byte is c
+func (p *Parser) beginValue(c byte) error {
+	if len(p.stack) > 0 && p.top().obj == nil {
+		p.path[len(p.path)-1] = parse.PathElem{Index: p.top().index}
+	}
+
+	switch {
+	case c == '{':
+		if err := p.emit(Event{Type: BeginObject, Path: p.path}); err != nil {
+			return err
+		}
+		p.stack = append(p.stack, container{obj: map[string]interface{}{}})
+		p.path = append(p.path, parse.PathElem{Index: -1})
+		p.state = stateFirstKey
+	case c == '[':
+		if err := p.emit(Event{Type: BeginArray, Path: p.path}); err != nil {
+			return err
+		}
+		p.stack = append(p.stack, container{arr: make([]interface{}, 0)})
+		p.path = append(p.path, parse.PathElem{})
+		p.state = stateFirstElem
+	case c == '"':
+		p.beginLiteral(stateString)
+	case c == '-' || isDigit(c):
+		p.beginLiteral(stateNumber)
+		p.lit = append(p.lit, c)
+	case c >= 'a' && c <= 'z':
+		p.beginLiteral(stateLiteral)
+		p.lit = append(p.lit, c)
+	default:
+		return p.expected("value", c)
+	}
+	return nil
+}
+
+func (p *Parser) beginLiteral(s state) {
+	p.state = s
+	p.lit = p.lit[:0]
+	p.litStart = p.off
+	p.key = false
+	p.escaped = false
+}
+
+func (p *Parser) endString() error {
+	str := string(p.lit)
+	if p.escaped {
+		unescaped, ok := lex.AppendUnescaped(p.lit[len(p.lit):], p.lit)
+		if !ok {
+			return fmt.Errorf("Invalid escape sequence in string beginning at offset %d", p.litStart)
+		}
+		str = string(unescaped)
+	}
+	if !p.key {
+		return p.complete(str)
+	}
+
+	top := p.top()
+	if _, ok := top.obj[str]; ok || top.dropped[str] {
+		return fmt.Errorf("Found duplicate key %q", str)
+	}
+	p.path[len(p.path)-1] = parse.PathElem{Key: str, Index: -1}
+	p.state = stateColon
+	return p.emit(Event{Type: Key, Path: p.path})
+}
+
+// completes the number or literal in p.lit
+func (p *Parser) endScalar() error {
+	literal := string(p.lit)
+	if p.state == stateLiteral {
+		switch literal {
+		case "true":
+			return p.complete(true)
+		case "false":
+			return p.complete(false)
+		case "null":
+			return p.complete(nil)
+		}
+		return fmt.Errorf("Invalid literal at offset %d: %q", p.litStart, literal)
+	}
+
+	switch lex.NumericTokenType(literal) {
+	case tok.Integer:
+		v, err := strconv.Atoi(literal)
+		if err == nil {
+			return p.complete(v)
+		}
+		if err.(*strconv.NumError).Err != strconv.ErrRange {
+			return fmt.Errorf("Invalid value found: %q", literal)
+		}
+		// too big for an int, as a float64 like any other number
+		fallthrough
+	case tok.FloatingPoint:
+		v, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid value found: %q", literal)
+		}
+		return p.complete(v)
+	}
+	return fmt.Errorf("Invalid number at offset %d: %q", p.litStart, literal)
+}
+
+func (p *Parser) endContainer() error {
+	c := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	p.path = p.path[:len(p.path)-1]
+	if c.obj != nil {
+		return p.complete(c.obj)
+	}
+	return p.complete(c.arr)
+}
+
+// reports the value at the current path and adds it to its object or array,
+// unless the Handler dropped it
+func (p *Parser) complete(v interface{}) error {
+	keep := true
+	if err := p.handler(Event{Type: Value, Path: p.path, Value: v}); err == parse.Drop {
+		keep = false
+	} else if err != nil {
+		return err
+	}
+
+	if len(p.stack) == 0 {
+		p.state = stateValue
+		return nil
+	}
+	top := p.top()
+	if top.obj != nil {
+		key := p.path[len(p.path)-1].Key
+		if keep {
+			top.obj[key] = v
+		} else {
+			if top.dropped == nil {
+				top.dropped = make(map[string]bool)
+			}
+			top.dropped[key] = true
+		}
+	} else {
+		if keep {
+			top.arr = append(top.arr, v)
+		}
+		top.index++
+	}
+	p.state = stateAfterValue
+	return nil
+}
+
+func (p *Parser) emit(e Event) error {
+	if err := p.handler(e); err != nil && err != parse.Drop {
+		return err
+	}
+	return nil
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// the bytes a number may hold, whether in a valid order is checked at its end
+func isNumberByte(c byte) bool {
+	return isDigit(c) || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
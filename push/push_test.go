@@ -0,0 +1,281 @@
+package push
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+// records the events of a Parser as strings, and the documents it completes
+type recorder struct {
+	events []string
+	docs   []interface{}
+}
+
+func (r *recorder) handle(e Event) error {
+	r.events = append(r.events, fmt.Sprintf("%s %q %v", e.Type, parse.PathPointer(e.Path).String(), e.Value))
+	if e.Type == Value && len(e.Path) == 0 {
+		r.docs = append(r.docs, e.Value)
+	}
+	return nil
+}
+
+// parses data written in the given chunks, returning the recorder and the first error
+func parseChunks(chunks [][]byte) (*recorder, error) {
+	r := &recorder{}
+	p := New(r.handle)
+	for _, chunk := range chunks {
+		if _, err := p.Write(chunk); err != nil {
+			return r, err
+		}
+	}
+	return r, p.Close()
+}
+
+// splits data at the given offsets
+func split(data []byte, at ...int) [][]byte {
+	var chunks [][]byte
+	prev := 0
+	for _, i := range at {
+		chunks = append(chunks, data[prev:i])
+		prev = i
+	}
+	return append(chunks, data[prev:])
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want []interface{}
+	}{
+		{doc: ``, want: nil},
+		{doc: `{}`, want: []interface{}{map[string]interface{}{}}},
+		{doc: `{"a": [1, 2.5, "x"], "b": {"c": null}}`, want: []interface{}{
+			map[string]interface{}{"a": []interface{}{1, 2.5, "x"}, "b": map[string]interface{}{"c": nil}},
+		}},
+		{doc: ` 1 -2e3 "s" true false null [] `, want: []interface{}{1, -2e3, "s", true, false, nil, []interface{}{}}},
+		{doc: `{"a":1}{"a":2}[3]"x"4`, want: []interface{}{
+			map[string]interface{}{"a": 1}, map[string]interface{}{"a": 2}, []interface{}{3}, "x", 4,
+		}},
+		{doc: `"\"\\\/\b\f\n\r\té😀\ud83d"`, want: []interface{}{"\"\\/\b\f\n\r\té\U0001F600�"}},
+		{doc: "[\n\t1 ,\n2 ]", want: []interface{}{[]interface{}{1, 2}}},
+		{doc: "{\"a\":1}\r\n{\"a\":2}\r\n", want: []interface{}{
+			map[string]interface{}{"a": 1}, map[string]interface{}{"a": 2},
+		}},
+		{doc: `18446744073709551616`, want: []interface{}{1.8446744073709552e19}},
+	}
+
+	for _, test := range tests {
+		r, err := parseChunks([][]byte{[]byte(test.doc)})
+
+		if err != nil || !reflect.DeepEqual(r.docs, test.want) {
+			t.Errorf("doc: %q, got: %v, want: %v, err: %v", test.doc, r.docs, test.want, err)
+		}
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		doc     string
+		wantErr string
+	}{
+		{doc: `{`, wantErr: "Unexpected end of input at offset 1"},
+		{doc: `["abc`, wantErr: "Unexpected end of input at offset 5"},
+		{doc: `{"a" 1}`, wantErr: `Expected colon at offset 5, found: '1'`},
+		{doc: `{1: 1}`, wantErr: `Expected key at offset 1, found: '1'`},
+		{doc: `{"a": 1,}`, wantErr: `Expected key at offset 8, found: '}'`},
+		{doc: `[1,]`, wantErr: `Expected value at offset 3, found: ']'`},
+		{doc: `[1 2]`, wantErr: `Expected comma or closing square bracket at offset 3, found: '2'`},
+		{doc: `{"a": 1]`, wantErr: `Expected comma or closing curly brace at offset 7, found: ']'`},
+		{doc: `]`, wantErr: `Expected value at offset 0, found: ']'`},
+		{doc: `[tru]`, wantErr: `Invalid literal at offset 1: "tru"`},
+		{doc: `nul`, wantErr: `Invalid literal at offset 0: "nul"`},
+		{doc: `[01]`, wantErr: `Invalid number at offset 1: "01"`},
+		{doc: `1.`, wantErr: `Invalid number at offset 0: "1."`},
+		{doc: `"\x"`, wantErr: "Invalid escape sequence in string beginning at offset 0"},
+		{doc: `{"a": 1, "a": 2}`, wantErr: `Found duplicate key "a"`},
+	}
+
+	for _, test := range tests {
+		_, err := parseChunks([][]byte{[]byte(test.doc)})
+
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("doc: %q, got err: %v, want: %s", test.doc, err, test.wantErr)
+		}
+	}
+}
+
+// documents that exercise state carried across chunks
+var splitDocs = []string{
+	`{"key": "value", "nested": {"arr": [1, -2.5e+3, true, false, null, "x"]}}`,
+	`["\"\\\/\b\f\n\r\t", "é€", "😀", "\ud83dx", "\ude00\ud83d"]`,
+	`[0, -0, 12345, -12.5e-3, 1E5, 3.14159]`,
+	"  \n\t[ { } , [ ] , { \"a\" : [ [ ] ] } ]  ",
+	`1 2 3 "a""b"{}[]true null false`,
+	`{"a": 1,}`,
+	`["abc\q"]`,
+	`[1, 2`,
+	`[truex]`,
+}
+
+// the events, documents and error must not depend on how the input is split,
+// tried for every single split point and for every byte written alone
+func TestEverySplit(t *testing.T) {
+	for _, doc := range splitDocs {
+		data := []byte(doc)
+		want, wantErr := parseChunks([][]byte{data})
+
+		var splits [][][]byte
+		for i := 0; i <= len(data); i++ {
+			splits = append(splits, split(data, i))
+		}
+		var bytewise [][]byte
+		for i := range data {
+			bytewise = append(bytewise, data[i:i+1])
+		}
+		splits = append(splits, bytewise)
+
+		for _, chunks := range splits {
+			got, err := parseChunks(chunks)
+
+			if fmt.Sprint(err) != fmt.Sprint(wantErr) || !reflect.DeepEqual(got, want) {
+				t.Errorf("doc: %q, chunks: %q, got: %v, %v, want: %v, %v", doc, chunks, got.events, err, want.events, wantErr)
+				break
+			}
+		}
+	}
+}
+
+// random splits of the documents in testdata parse to what parse.Parse returns
+func TestRandomSplits(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, f := range []string{"colors1.json", "colors2.json", "colors3.json", "gdp.json", "meteorites.json"} {
+		data, err := ioutil.ReadFile("../parse/testdata/" + f)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", f, err)
+		}
+		want, err := parse.Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", f, err)
+		}
+
+		for i := 0; i < 20; i++ {
+			var at []int
+			for off := rng.Intn(64); off < len(data); off += 1 + rng.Intn(4096) {
+				at = append(at, off)
+			}
+			r, err := parseChunks(split(data, at...))
+
+			if err != nil || len(r.docs) != 1 || !reflect.DeepEqual(r.docs[0], want) {
+				t.Errorf("%s split at %v: got %d docs, err: %v", f, at, len(r.docs), err)
+				break
+			}
+		}
+	}
+}
+
+// dropping the elements of a long array handles them one at a time
+func TestDrop(t *testing.T) {
+	var ids []interface{}
+	var root interface{}
+	p := New(func(e Event) error {
+		switch {
+		case e.Type == Value && len(e.Path) == 1:
+			ids = append(ids, e.Value.(map[string]interface{})["id"])
+			return parse.Drop
+		case e.Type == Value && len(e.Path) == 0:
+			root = e.Value
+		}
+		return nil
+	})
+
+	for _, chunk := range []string{`[{"id": 1}, {"i`, `d": 2}, {"id"`, `: 3}]`} {
+		if _, err := p.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write(%q): %v", chunk, err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	wantIDs := []interface{}{1, 2, 3}
+	if !reflect.DeepEqual(ids, wantIDs) || !reflect.DeepEqual(root, []interface{}{}) {
+		t.Errorf("got ids: %v, root: %v, want ids: %v and an empty root", ids, root, wantIDs)
+	}
+}
+
+// a member the Handler dropped is still a duplicate
+func TestDropDuplicateKey(t *testing.T) {
+	p := New(func(e Event) error {
+		if e.Type == Value && len(e.Path) == 1 {
+			return parse.Drop
+		}
+		return nil
+	})
+
+	_, err := p.Write([]byte(`{"a": 1, "a": 2}`))
+	if err == nil || err.Error() != `Found duplicate key "a"` {
+		t.Errorf("got: %v, want a duplicate key error", err)
+	}
+}
+
+// events are reported as soon as they are known, before the rest of the input is written
+func TestEventsAreTimely(t *testing.T) {
+	r := &recorder{}
+	p := New(r.handle)
+
+	steps := []struct {
+		chunk string
+		want  []string
+	}{
+		{chunk: `{"a`, want: []string{`begin object "" <nil>`}},
+		{chunk: `": [`, want: []string{`key "/a" <nil>`, `begin array "/a" <nil>`}},
+		{chunk: `"x", 1`, want: []string{`value "/a/0" x`}},
+		{chunk: `2 `, want: []string{`value "/a/1" 12`}},
+		{chunk: `]}`, want: []string{`value "/a" [x 12]`, `value "" map[a:[x 12]]`}},
+	}
+	for _, step := range steps {
+		r.events = nil
+		if _, err := p.Write([]byte(step.chunk)); err != nil {
+			t.Fatalf("Write(%q): %v", step.chunk, err)
+		}
+		if strings.Join(r.events, "; ") != strings.Join(step.want, "; ") {
+			t.Errorf("chunk: %q, got events: %q, want: %q", step.chunk, r.events, step.want)
+		}
+	}
+}
+
+func TestHandlerError(t *testing.T) {
+	stop := fmt.Errorf("stop")
+	p := New(func(e Event) error {
+		if e.Type == Key {
+			return stop
+		}
+		return nil
+	})
+
+	n, err := p.Write([]byte(`{"a": 1}`))
+	if err != stop || n != 3 {
+		t.Errorf("got n: %d, err: %v, want n: 3, err: %v", n, err, stop)
+	}
+	if _, err := p.Write([]byte(`{}`)); err != stop {
+		t.Errorf("got err: %v after the handler failed, want: %v", err, stop)
+	}
+
+	p.Reset()
+	if _, err := p.Write([]byte(`[]`)); err != nil {
+		t.Errorf("got err: %v after Reset", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Close(): %v", err)
+	}
+	if _, err := p.Write([]byte(`[]`)); err != ErrClosed {
+		t.Errorf("got err: %v after Close, want: %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,506 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vyevs/gojson/encode"
+)
+
+// a function of the filter language, called with the nodes of its arguments
+type builtin func(args []node, v interface{}) ([]interface{}, error)
+
+// the functions by name and number of arguments, e.g. map/1
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"empty/0":          func([]node, interface{}) ([]interface{}, error) { return nil, nil },
+		"not/0":            unary(func(v interface{}) (interface{}, error) { return !truthy(v), nil }),
+		"type/0":           unary(func(v interface{}) (interface{}, error) { return typeName(v), nil }),
+		"length/0":         unary(length),
+		"keys/0":           unary(keys),
+		"values/0":         values,
+		"add/0":            unary(add),
+		"any/0":            unary(func(v interface{}) (interface{}, error) { return anyAll(v, true) }),
+		"all/0":            unary(func(v interface{}) (interface{}, error) { return anyAll(v, false) }),
+		"sort/0":           unary(sortArray),
+		"unique/0":         unary(unique),
+		"reverse/0":        unary(reverse),
+		"min/0":            unary(func(v interface{}) (interface{}, error) { return extreme(v, -1) }),
+		"max/0":            unary(func(v interface{}) (interface{}, error) { return extreme(v, 1) }),
+		"first/0":          unary(func(v interface{}) (interface{}, error) { return indexValue(v, 0) }),
+		"last/0":           unary(func(v interface{}) (interface{}, error) { return indexValue(v, -1) }),
+		"tostring/0":       unary(tostring),
+		"tojson/0":         unary(tojson),
+		"tonumber/0":       unary(tonumber),
+		"to_entries/0":     unary(toEntries),
+		"from_entries/0":   unary(fromEntries),
+		"ascii_downcase/0": unary(func(v interface{}) (interface{}, error) { return asciiCase(v, 'A', 'a') }),
+		"ascii_upcase/0":   unary(func(v interface{}) (interface{}, error) { return asciiCase(v, 'a', 'A') }),
+
+		"select/1":       selectValues,
+		"map/1":          mapValues,
+		"map_values/1":   mapObjectValues,
+		"with_entries/1": withEntries,
+		"sort_by/1":      sortBy,
+		"any/1":          func(args []node, v interface{}) ([]interface{}, error) { return anyAllBy(args, v, true) },
+		"all/1":          func(args []node, v interface{}) ([]interface{}, error) { return anyAllBy(args, v, false) },
+		"has/1":          binaryFunc(has),
+		"startswith/1":   binaryFunc(strFunc("startswith", func(s, arg string) interface{} { return strings.HasPrefix(s, arg) })),
+		"endswith/1":     binaryFunc(strFunc("endswith", func(s, arg string) interface{} { return strings.HasSuffix(s, arg) })),
+		"split/1":        binaryFunc(strFunc("split", func(s, arg string) interface{} { return split(s, arg) })),
+		"test/1":         binaryFunc(test),
+		"join/1":         binaryFunc(join),
+	}
+}
+
+// a function of no arguments that maps its input to one output
+func unary(f func(v interface{}) (interface{}, error)) builtin {
+	return func(_ []node, v interface{}) ([]interface{}, error) {
+		out, err := f(v)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{out}, nil
+	}
+}
+
+// a function of one argument that maps its input and every output of the argument to an output
+func binaryFunc(f func(v, arg interface{}) (interface{}, error)) builtin {
+	return func(args []node, v interface{}) ([]interface{}, error) {
+		argValues, err := args[0].eval(v)
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, arg := range argValues {
+			result, err := f(v, arg)
+			if err != nil {
+				return out, err
+			}
+			out = append(out, result)
+		}
+		return out, nil
+	}
+}
+
+// a function of a string input and a string argument
+func strFunc(name string, f func(s, arg string) interface{}) func(v, arg interface{}) (interface{}, error) {
+	return func(v, arg interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		a, argOk := arg.(string)
+		if !ok || !argOk {
+			return nil, fmt.Errorf("%s() requires string inputs", name)
+		}
+		return f(s, a), nil
+	}
+}
+
+func length(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	case float64:
+		return math.Abs(v), nil
+	case string:
+		return utf8.RuneCountInString(v), nil
+	case []interface{}:
+		return len(v), nil
+	case map[string]interface{}:
+		return len(v), nil
+	}
+	return nil, fmt.Errorf("%s has no length", describe(v))
+}
+
+func keys(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return stringsToValues(encode.SortedKeys(v)), nil
+	case []interface{}:
+		indices := make([]interface{}, len(v))
+		for i := range v {
+			indices[i] = i
+		}
+		return indices, nil
+	}
+	return nil, fmt.Errorf("%s has no keys", describe(v))
+}
+
+// values is select(. != null)
+func values(_ []node, v interface{}) ([]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return []interface{}{v}, nil
+}
+
+func add(v interface{}) (interface{}, error) {
+	elems, err := iterateValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var sum interface{}
+	for _, e := range elems {
+		if sum, err = arithmetic("+", sum, e); err != nil {
+			return nil, err
+		}
+	}
+	return sum, nil
+}
+
+// any if isAny, otherwise all, of the elements of an array
+func anyAll(v interface{}, isAny bool) (interface{}, error) {
+	elems, err := iterateValue(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range elems {
+		if truthy(e) == isAny {
+			return isAny, nil
+		}
+	}
+	return !isAny, nil
+}
+
+// any(f) if isAny, otherwise all(f), of the outputs of f for the elements of an array
+func anyAllBy(args []node, v interface{}, isAny bool) ([]interface{}, error) {
+	elems, err := iterateValue(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range elems {
+		conds, err := args[0].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range conds {
+			if truthy(c) == isAny {
+				return []interface{}{isAny}, nil
+			}
+		}
+	}
+	return []interface{}{!isAny}, nil
+}
+
+func array(name string, v interface{}) ([]interface{}, error) {
+	a, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be %s, as it is not an array", describe(v), name)
+	}
+	return a, nil
+}
+
+func sortArray(v interface{}) (interface{}, error) {
+	a, err := array("sorted", v)
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]interface{}{}, a...)
+	sortValues(sorted)
+	return sorted, nil
+}
+
+func unique(v interface{}) (interface{}, error) {
+	sorted, err := sortArray(v)
+	if err != nil {
+		return nil, err
+	}
+	out := []interface{}{}
+	for _, e := range sorted.([]interface{}) {
+		if len(out) == 0 || compare(out[len(out)-1], e) != 0 {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func reverse(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case string:
+		runes := []rune(v)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	}
+	a, err := array("reversed", v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(a))
+	for i, e := range a {
+		out[len(a)-1-i] = e
+	}
+	return out, nil
+}
+
+// the least element of an array for want -1, the greatest for 1, null if it is empty
+func extreme(v interface{}, want int) (interface{}, error) {
+	a, err := array("searched", v)
+	if err != nil {
+		return nil, err
+	}
+	var best interface{}
+	for i, e := range a {
+		if i == 0 || compare(e, best) == want || want > 0 && compare(e, best) == 0 {
+			best = e
+		}
+	}
+	return best, nil
+}
+
+func tostring(v interface{}) (interface{}, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return tojson(v)
+}
+
+func tojson(v interface{}) (interface{}, error) {
+	b, err := encode.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func tonumber(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case int, float64:
+		return v, nil
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("Cannot parse %q as a number", v)
+	}
+	return nil, fmt.Errorf("%s cannot be parsed as a number", describe(v))
+}
+
+func toEntries(v interface{}) (interface{}, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s has no entries, as it is not an object", describe(v))
+	}
+	entries := make([]interface{}, 0, len(obj))
+	for _, k := range encode.SortedKeys(obj) {
+		entries = append(entries, map[string]interface{}{"key": k, "value": obj[k]})
+	}
+	return entries, nil
+}
+
+// takes the key from key, k or name, and the value from value or v
+func fromEntries(v interface{}) (interface{}, error) {
+	entries, err := array("turned into an object", v)
+	if err != nil {
+		return nil, err
+	}
+	obj := make(map[string]interface{}, len(entries))
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Cannot use %s as an entry", describe(e))
+		}
+		var key interface{}
+		for _, name := range []string{"key", "k", "name"} {
+			if key = entry[name]; key != nil {
+				break
+			}
+		}
+		value, ok := entry["value"]
+		if !ok {
+			value = entry["v"]
+		}
+		switch k := key.(type) {
+		case string:
+			obj[k] = value
+		case int, float64, bool:
+			s, _ := tojson(k)
+			obj[s.(string)] = value
+		default:
+			return nil, fmt.Errorf("Cannot use %s as object key", describe(key))
+		}
+	}
+	return obj, nil
+}
+
+// maps the ASCII letters from from to to, e.g. 'A' to 'a' for lower case
+func asciiCase(v interface{}, from, to byte) (interface{}, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be case converted, as it is not a string", describe(v))
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c >= from && c < from+26 {
+			b[i] = c - from + to
+		}
+	}
+	return string(b), nil
+}
+
+// select(f), the input for every output of f that is neither false nor null
+func selectValues(args []node, v interface{}) ([]interface{}, error) {
+	conds, err := args[0].eval(v)
+	var out []interface{}
+	for _, c := range conds {
+		if truthy(c) {
+			out = append(out, v)
+		}
+	}
+	return out, err
+}
+
+// map(f), [.[] | f]
+func mapValues(args []node, v interface{}) ([]interface{}, error) {
+	return arrayCons{pipe{iterate{identity{}}, args[0]}}.eval(v)
+}
+
+// map_values(f), the first output of f for every value of an object or array,
+// which is left out if there is none
+func mapObjectValues(args []node, v interface{}) ([]interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			values, err := args[0].eval(e)
+			if err != nil {
+				return nil, err
+			}
+			if len(values) > 0 {
+				out[k] = values[0]
+			}
+		}
+		return []interface{}{out}, nil
+	case []interface{}:
+		out := []interface{}{}
+		for _, e := range v {
+			values, err := args[0].eval(e)
+			if err != nil {
+				return nil, err
+			}
+			if len(values) > 0 {
+				out = append(out, values[0])
+			}
+		}
+		return []interface{}{out}, nil
+	}
+	return nil, fmt.Errorf("Cannot iterate over %s", describe(v))
+}
+
+// with_entries(f), to_entries | map(f) | from_entries
+func withEntries(args []node, v interface{}) ([]interface{}, error) {
+	entries, err := toEntries(v)
+	if err != nil {
+		return nil, err
+	}
+	mapped, err := mapValues(args, entries)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := fromEntries(mapped[0])
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{obj}, nil
+}
+
+// sort_by(f), the elements of an array sorted by [f]
+func sortBy(args []node, v interface{}) ([]interface{}, error) {
+	a, err := array("sorted", v)
+	if err != nil {
+		return nil, err
+	}
+	type keyed struct {
+		key  interface{}
+		elem interface{}
+	}
+	elems := make([]keyed, len(a))
+	for i, e := range a {
+		key, err := args[0].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = keyed{key: key, elem: e}
+	}
+	sort.SliceStable(elems, func(i, j int) bool { return compare(elems[i].key, elems[j].key) < 0 })
+	out := make([]interface{}, len(elems))
+	for i, e := range elems {
+		out[i] = e.elem
+	}
+	return []interface{}{out}, nil
+}
+
+func has(v, key interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if k, ok := key.(string); ok {
+			_, found := v[k]
+			return found, nil
+		}
+	case []interface{}:
+		if f, ok := toFloat(key); ok {
+			return f >= 0 && f < float64(len(v)), nil
+		}
+	}
+	return nil, fmt.Errorf("Cannot check whether %s has a key of %s", typeName(v), describe(key))
+}
+
+func test(v, re interface{}) (interface{}, error) {
+	s, ok := v.(string)
+	pattern, patternOk := re.(string)
+	if !ok || !patternOk {
+		return nil, fmt.Errorf("test() requires string inputs")
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid regular expression: %v", pattern, err)
+	}
+	return compiled.MatchString(s), nil
+}
+
+// joins the strings, numbers and booleans of an array with sep, null joins as ""
+func join(v, sep interface{}) (interface{}, error) {
+	s, ok := sep.(string)
+	if !ok {
+		return nil, fmt.Errorf("join() requires a string separator")
+	}
+	elems, err := iterateValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i, e := range elems {
+		if i > 0 {
+			buf.WriteString(s)
+		}
+		switch e := e.(type) {
+		case nil:
+		case string:
+			buf.WriteString(e)
+		case int, float64, bool:
+			str, _ := tojson(e)
+			buf.WriteString(str.(string))
+		default:
+			return nil, fmt.Errorf("Cannot join with %s", describe(e))
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,614 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+// Parse parses a filter, e.g.: .items[] | select(.price > 10) | {name, price}
+func Parse(filter string) (*Query, error) {
+	p := &parser{src: filter}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	root, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if p.t.kind != tkEOF {
+		return nil, p.unexpected("end of filter")
+	}
+	return &Query{root: root}, nil
+}
+
+type tokenKind uint8
+
+const (
+	tkEOF    tokenKind = iota
+	tkDot              // .
+	tkDotDot           // ..
+	tkField            // .name
+	tkIdent            // name, including the keywords
+	tkString
+	tkNumber
+	tkOp // punctuation and operators
+)
+
+type token struct {
+	kind  tokenKind
+	text  string      // the name of a field or identifier, or the operator
+	value interface{} // of a string or number
+	pos   int
+}
+
+type parser struct {
+	src string
+	i   int   // offset of the byte after the current token
+	t   token // the current token
+}
+
+// the operators, two byte ones first
+var operators = []string{"==", "!=", "<=", ">=", "//", "|", ",", "(", ")", "[", "]", "{", "}", ":", ";", "?", "<", ">", "+", "-", "*", "/", "%"}
+
+// reads the next token into p.t
+func (p *parser) next() error {
+	for p.i < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.i]) >= 0 {
+		p.i++
+	}
+	start := p.i
+	p.t = token{pos: start}
+	if p.i == len(p.src) {
+		p.t.kind = tkEOF
+		return nil
+	}
+
+	rest := p.src[p.i:]
+	switch c := rest[0]; {
+	case c == '.':
+		p.i++
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			p.i++
+			p.t.kind = tkDotDot
+		case len(rest) > 1 && isIdentStart(rest[1]):
+			p.t.kind, p.t.text = tkField, p.ident()
+		default:
+			p.t.kind = tkDot
+		}
+	case isIdentStart(c):
+		p.t.kind, p.t.text = tkIdent, p.ident()
+	case c == '"':
+		return p.str()
+	case c >= '0' && c <= '9':
+		return p.number()
+	default:
+		for _, op := range operators {
+			if strings.HasPrefix(rest, op) {
+				p.i += len(op)
+				p.t.kind, p.t.text = tkOp, op
+				return nil
+			}
+		}
+		return fmt.Errorf("Invalid character %q at offset %d", c, start)
+	}
+	return nil
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+// reads an identifier beginning at p.i
+func (p *parser) ident() string {
+	start := p.i
+	for p.i < len(p.src) && (isIdentStart(p.src[p.i]) || p.src[p.i] >= '0' && p.src[p.i] <= '9') {
+		p.i++
+	}
+	return p.src[start:p.i]
+}
+
+// reads a string literal, decoded as JSON strings are
+func (p *parser) str() error {
+	start := p.i
+	for p.i++; p.i < len(p.src) && p.src[p.i] != '"'; p.i++ {
+		if p.src[p.i] == '\\' {
+			p.i++
+		}
+	}
+	if p.i >= len(p.src) {
+		return fmt.Errorf("Unterminated string beginning at offset %d", start)
+	}
+	p.i++
+	v, err := parse.Parse(strings.NewReader(p.src[start:p.i]))
+	if err != nil {
+		return fmt.Errorf("Invalid string at offset %d: %v", start, err)
+	}
+	p.t.kind, p.t.value = tkString, v
+	return nil
+}
+
+func (p *parser) number() error {
+	start := p.i
+	digits := func() {
+		for p.i < len(p.src) && p.src[p.i] >= '0' && p.src[p.i] <= '9' {
+			p.i++
+		}
+	}
+	digits()
+	float := false
+	if p.i < len(p.src) && p.src[p.i] == '.' {
+		float = true
+		p.i++
+		digits()
+	}
+	if p.i < len(p.src) && (p.src[p.i] == 'e' || p.src[p.i] == 'E') {
+		float = true
+		p.i++
+		if p.i < len(p.src) && (p.src[p.i] == '+' || p.src[p.i] == '-') {
+			p.i++
+		}
+		digits()
+	}
+	lit := p.src[start:p.i]
+	p.t.kind = tkNumber
+	if !float {
+		if i, err := strconv.Atoi(lit); err == nil {
+			p.t.value = i
+			return nil
+		}
+	}
+	f, err := strconv.ParseFloat(lit, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid number at offset %d: %q", start, lit)
+	}
+	p.t.value = f
+	return nil
+}
+
+func (p *parser) unexpected(what string) error {
+	if p.t.kind == tkEOF {
+		return fmt.Errorf("Unexpected end of filter, expected %s", what)
+	}
+	return fmt.Errorf("Expected %s at offset %d, found: %q", what, p.t.pos, p.src[p.t.pos:p.i])
+}
+
+func (p *parser) isOp(op string) bool {
+	return p.t.kind == tkOp && p.t.text == op
+}
+
+func (p *parser) isKeyword(word string) bool {
+	return p.t.kind == tkIdent && p.t.text == word
+}
+
+// consumes the operator op, which must be the current token
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		return p.unexpected(strconv.Quote(op))
+	}
+	return p.next()
+}
+
+// consumes the keyword word, which must be the current token
+func (p *parser) expectKeyword(word string) error {
+	if !p.isKeyword(word) {
+		return p.unexpected(word)
+	}
+	return p.next()
+}
+
+// the levels of precedence, from the lowest
+
+// l | r, right associative
+func (p *parser) pipe() (node, error) {
+	l, err := p.comma()
+	if err != nil || !p.isOp("|") {
+		return l, err
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	r, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	return pipe{l, r}, nil
+}
+
+func (p *parser) comma() (node, error) {
+	l, err := p.alternative()
+	for err == nil && p.isOp(",") {
+		if err = p.next(); err != nil {
+			break
+		}
+		var r node
+		if r, err = p.alternative(); err == nil {
+			l = comma{l, r}
+		}
+	}
+	return l, err
+}
+
+// l // r, right associative
+func (p *parser) alternative() (node, error) {
+	l, err := p.or()
+	if err != nil || !p.isOp("//") {
+		return l, err
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	r, err := p.alternative()
+	if err != nil {
+		return nil, err
+	}
+	return alternative{l, r}, nil
+}
+
+func (p *parser) or() (node, error) {
+	l, err := p.and()
+	for err == nil && p.isKeyword("or") {
+		if err = p.next(); err != nil {
+			break
+		}
+		var r node
+		if r, err = p.and(); err == nil {
+			l = logical{and: false, l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (p *parser) and() (node, error) {
+	l, err := p.comparison()
+	for err == nil && p.isKeyword("and") {
+		if err = p.next(); err != nil {
+			break
+		}
+		var r node
+		if r, err = p.comparison(); err == nil {
+			l = logical{and: true, l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+// comparisons do not associate, a < b < c is an error
+func (p *parser) comparison() (node, error) {
+	l, err := p.additive()
+	if err != nil || p.t.kind != tkOp {
+		return l, err
+	}
+	switch op := p.t.text; op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		r, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return binary{op, l, r}, nil
+	}
+	return l, nil
+}
+
+func (p *parser) additive() (node, error) {
+	return p.binaryLevel(p.multiplicative, "+", "-")
+}
+
+func (p *parser) multiplicative() (node, error) {
+	return p.binaryLevel(p.unary, "*", "/", "%")
+}
+
+// parses operands joined by the left associative operators ops
+func (p *parser) binaryLevel(operand func() (node, error), ops ...string) (node, error) {
+	l, err := operand()
+outer:
+	for err == nil && p.t.kind == tkOp {
+		for _, op := range ops {
+			if p.t.text != op {
+				continue
+			}
+			if err = p.next(); err != nil {
+				break outer
+			}
+			var r node
+			if r, err = operand(); err == nil {
+				l = binary{op, l, r}
+			}
+			continue outer
+		}
+		break
+	}
+	return l, err
+}
+
+func (p *parser) unary() (node, error) {
+	if !p.isOp("-") {
+		return p.postfix()
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return negate{x}, nil
+}
+
+// a term followed by any number of .name, ."name", [...], .[...] and ?
+func (p *parser) postfix() (node, error) {
+	x, err := p.term()
+	for err == nil {
+		switch {
+		case p.t.kind == tkField:
+			x = index{x, literal{p.t.text}}
+			err = p.next()
+		case p.t.kind == tkDot:
+			if err = p.next(); err != nil {
+				break
+			}
+			if p.t.kind == tkString {
+				x = index{x, literal{p.t.value}}
+				err = p.next()
+			} else if p.isOp("[") {
+				x, err = p.brackets(x)
+			} else {
+				err = p.unexpected("field name or \"[\"")
+			}
+		case p.isOp("["):
+			x, err = p.brackets(x)
+		case p.isOp("?"):
+			x = optional{x}
+			err = p.next()
+		default:
+			return x, nil
+		}
+	}
+	return nil, err
+}
+
+// target[], target[e], target[from:to]
+func (p *parser) brackets(target node) (node, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	if p.isOp("]") {
+		return iterate{target}, p.next()
+	}
+
+	var from node
+	if !p.isOp(":") {
+		var err error
+		if from, err = p.pipe(); err != nil {
+			return nil, err
+		}
+		if p.isOp("]") {
+			return index{target, from}, p.next()
+		}
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	var to node
+	if !p.isOp("]") {
+		var err error
+		if to, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	if from == nil && to == nil {
+		return nil, p.unexpected("end of slice")
+	}
+	return slice{target, from, to}, p.expect("]")
+}
+
+var keywords = map[string]bool{
+	"and": true, "or": true, "if": true, "then": true, "elif": true, "else": true, "end": true,
+}
+
+func (p *parser) term() (node, error) {
+	t := p.t
+	switch t.kind {
+	case tkDot:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.t.kind == tkString {
+			key := p.t.value
+			return index{identity{}, literal{key}}, p.next()
+		}
+		return identity{}, nil
+	case tkDotDot:
+		return recurse{}, p.next()
+	case tkField:
+		return index{identity{}, literal{t.text}}, p.next()
+	case tkNumber, tkString:
+		return literal{t.value}, p.next()
+	case tkIdent:
+		return p.identTerm()
+	case tkOp:
+		switch t.text {
+		case "(":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			x, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.isOp("]") {
+				return arrayCons{}, p.next()
+			}
+			body, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return arrayCons{body}, p.expect("]")
+		case "{":
+			return p.object()
+		}
+	}
+	return nil, p.unexpected("value")
+}
+
+// true, false, null, if ... end, or a function call
+func (p *parser) identTerm() (node, error) {
+	name := p.t.text
+	switch name {
+	case "true", "false":
+		return literal{name == "true"}, p.next()
+	case "null":
+		return literal{nil}, p.next()
+	case "if":
+		return p.ifThen()
+	}
+	if keywords[name] {
+		return nil, p.unexpected("value")
+	}
+
+	pos := p.t.pos
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	var args []node
+	if p.isOp("(") {
+		for {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			arg, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !p.isOp(";") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	f, ok := builtins[fmt.Sprintf("%s/%d", name, len(args))]
+	if !ok {
+		return nil, fmt.Errorf("Unknown function %s/%d at offset %d", name, len(args), pos)
+	}
+	return call{f, args}, nil
+}
+
+// a call of a builtin function
+type call struct {
+	f    builtin
+	args []node
+}
+
+func (n call) eval(v interface{}) ([]interface{}, error) {
+	return n.f(n.args, v)
+}
+
+// if c then a elif c then b else d end, the else branch is optional
+func (p *parser) ifThen() (node, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	cond, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("then"); err != nil {
+		return nil, err
+	}
+	then, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	var els node = identity{}
+	switch {
+	case p.isKeyword("elif"):
+		// the rest is parsed as an if nested in the else branch, ending with the same end
+		if els, err = p.ifThen(); err != nil {
+			return nil, err
+		}
+		return ifNode{cond, then, els}, nil
+	case p.isKeyword("else"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if els, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	return ifNode{cond, then, els}, p.expectKeyword("end")
+}
+
+// {a, b: e, "c": e, (e): e}, values extend up to the next comma
+func (p *parser) object() (node, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	var entries []entry
+	for !p.isOp("}") {
+		var e entry
+		switch {
+		case p.t.kind == tkIdent:
+			e.key = literal{p.t.text}
+			e.value = index{identity{}, e.key}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		case p.t.kind == tkString:
+			e.key = literal{p.t.value}
+			e.value = index{identity{}, e.key}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		case p.isOp("("):
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			key, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			e.key = key
+		default:
+			return nil, p.unexpected("key")
+		}
+
+		if p.isOp(":") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			value, err := p.alternative()
+			if err != nil {
+				return nil, err
+			}
+			e.value = value
+		} else if e.value == nil {
+			return nil, p.unexpected("\":\"")
+		}
+		entries = append(entries, e)
+
+		if !p.isOp(",") {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return objectCons{entries}, p.expect("}")
+}
@@ -0,0 +1,497 @@
+// Package query runs filters written in a subset of the jq language,
+// https://jqlang.github.io/jq/manual, on the values the parser produces
+//
+// the subset covers:
+//
+//	.  ..  .foo  ."foo"  .[e]  .[from:to]  .[]  e?
+//	e | e  e, e  e // e  (e)
+//	[e]  {a, b: e, "c": e, (e): e}
+//	== != < <= > >=  and or  + - * / %  -e
+//	if e then e elif e then e else e end
+//	numbers, strings, true, false and null
+//
+// and the functions listed in the README, e.g. select(f), map(f), keys and length
+// objects are iterated in the order of their keys, as the parser does not keep
+// the order in which they are written
+package query
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/vyevs/gojson/encode"
+)
+
+// Query is a parsed filter
+type Query struct {
+	root node
+}
+
+// Run returns the outputs of the filter for the input v, which is expected
+// to be made up of the values the parser produces:
+// map[string]interface{}, []interface{}, string, int, float64, bool or nil
+// the outputs are made up of the same, and may share parts of v
+func (q *Query) Run(v interface{}) ([]interface{}, error) {
+	return q.root.eval(v)
+}
+
+// a node of a parsed filter
+type node interface {
+	// returns the outputs of the node for the input v
+	eval(v interface{}) ([]interface{}, error)
+}
+
+// .
+type identity struct{}
+
+func (identity) eval(v interface{}) ([]interface{}, error) {
+	return []interface{}{v}, nil
+}
+
+// ..
+type recurse struct{}
+
+func (recurse) eval(v interface{}) ([]interface{}, error) {
+	out := []interface{}{v}
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			more, _ := recurse{}.eval(e)
+			out = append(out, more...)
+		}
+	case map[string]interface{}:
+		for _, k := range encode.SortedKeys(v) {
+			more, _ := recurse{}.eval(v[k])
+			out = append(out, more...)
+		}
+	}
+	return out, nil
+}
+
+// a number, string, boolean or null
+type literal struct {
+	v interface{}
+}
+
+func (l literal) eval(interface{}) ([]interface{}, error) {
+	return []interface{}{l.v}, nil
+}
+
+// target[key], which is target.key for a key that is a literal string
+type index struct {
+	target, key node
+}
+
+func (n index) eval(v interface{}) ([]interface{}, error) {
+	return cross(n.target, n.key, v, func(target, key interface{}) ([]interface{}, error) {
+		value, err := indexValue(target, key)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	})
+}
+
+func indexValue(target, key interface{}) (interface{}, error) {
+	switch t := target.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		if k, ok := key.(string); ok {
+			return t[k], nil
+		}
+	case []interface{}:
+		if f, ok := toFloat(key); ok {
+			i := int(math.Floor(f))
+			if i < 0 {
+				i += len(t)
+			}
+			if i < 0 || i >= len(t) {
+				return nil, nil
+			}
+			return t[i], nil
+		}
+	}
+	if k, ok := key.(string); ok {
+		return nil, fmt.Errorf("Cannot index %s with %q", typeName(target), k)
+	}
+	return nil, fmt.Errorf("Cannot index %s with %s", typeName(target), typeName(key))
+}
+
+// target[from:to], either of which may be nil
+type slice struct {
+	target, from, to node
+}
+
+func (n slice) eval(v interface{}) ([]interface{}, error) {
+	from, to := n.from, n.to
+	if from == nil {
+		from = literal{nil}
+	}
+	if to == nil {
+		to = literal{nil}
+	}
+	bounds := pair{from, to}
+	return cross(n.target, bounds, v, func(target, b interface{}) ([]interface{}, error) {
+		bounds := b.([2]interface{})
+		var length int
+		switch t := target.(type) {
+		case nil:
+			return []interface{}{nil}, nil
+		case []interface{}:
+			length = len(t)
+		case string:
+			length = utf8.RuneCountInString(t)
+		default:
+			return nil, fmt.Errorf("Cannot index %s with object", typeName(target))
+		}
+		start, err := sliceIndex(bounds[0], 0, length)
+		if err != nil {
+			return nil, err
+		}
+		end, err := sliceIndex(bounds[1], length, length)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			end = start
+		}
+		if t, ok := target.([]interface{}); ok {
+			return []interface{}{t[start:end:end]}, nil
+		}
+		runes := []rune(target.(string))
+		return []interface{}{string(runes[start:end])}, nil
+	})
+}
+
+// the index a bound of a slice stands for, def if it is null
+func sliceIndex(bound interface{}, def, length int) (int, error) {
+	if bound == nil {
+		return def, nil
+	}
+	f, ok := toFloat(bound)
+	if !ok {
+		return 0, fmt.Errorf("Start and end indices of a slice must be numbers")
+	}
+	i := int(math.Floor(f))
+	if i < 0 {
+		i += length
+	}
+	if i < 0 {
+		i = 0
+	} else if i > length {
+		i = length
+	}
+	return i, nil
+}
+
+// the outputs of two nodes paired up, for the bounds of slices
+type pair [2]node
+
+func (n pair) eval(v interface{}) ([]interface{}, error) {
+	return cross(n[0], n[1], v, func(a, b interface{}) ([]interface{}, error) {
+		return []interface{}{[2]interface{}{a, b}}, nil
+	})
+}
+
+// target[]
+type iterate struct {
+	target node
+}
+
+func (n iterate) eval(v interface{}) ([]interface{}, error) {
+	targets, err := n.target.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, t := range targets {
+		values, err := iterateValue(t)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, values...)
+	}
+	return out, nil
+}
+
+func iterateValue(v interface{}) ([]interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, k := range encode.SortedKeys(v) {
+			values = append(values, v[k])
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("Cannot iterate over %s", describe(v))
+}
+
+// e?, the outputs of e up to its first error, which is dropped
+type optional struct {
+	n node
+}
+
+func (n optional) eval(v interface{}) ([]interface{}, error) {
+	out, _ := n.n.eval(v)
+	return out, nil
+}
+
+// l | r
+type pipe struct {
+	l, r node
+}
+
+func (n pipe) eval(v interface{}) ([]interface{}, error) {
+	left, err := n.l.eval(v)
+	var out []interface{}
+	for _, lv := range left {
+		right, rerr := n.r.eval(lv)
+		out = append(out, right...)
+		if rerr != nil {
+			return out, rerr
+		}
+	}
+	return out, err
+}
+
+// l, r
+type comma struct {
+	l, r node
+}
+
+func (n comma) eval(v interface{}) ([]interface{}, error) {
+	out, err := n.l.eval(v)
+	if err != nil {
+		return out, err
+	}
+	right, err := n.r.eval(v)
+	return append(out, right...), err
+}
+
+// l // r, the outputs of l that are neither false nor null, or else those of r
+type alternative struct {
+	l, r node
+}
+
+func (n alternative) eval(v interface{}) ([]interface{}, error) {
+	left, _ := n.l.eval(v)
+	var out []interface{}
+	for _, lv := range left {
+		if truthy(lv) {
+			out = append(out, lv)
+		}
+	}
+	if len(out) > 0 {
+		return out, nil
+	}
+	return n.r.eval(v)
+}
+
+// l and r, l or r
+type logical struct {
+	and  bool
+	l, r node
+}
+
+func (n logical) eval(v interface{}) ([]interface{}, error) {
+	left, err := n.l.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, lv := range left {
+		if truthy(lv) != n.and {
+			// decided by l alone
+			out = append(out, !n.and)
+			continue
+		}
+		right, err := n.r.eval(v)
+		if err != nil {
+			return out, err
+		}
+		for _, rv := range right {
+			out = append(out, truthy(rv))
+		}
+	}
+	return out, nil
+}
+
+// l op r, for comparisons and arithmetic
+type binary struct {
+	op   string
+	l, r node
+}
+
+func (n binary) eval(v interface{}) ([]interface{}, error) {
+	return cross(n.l, n.r, v, func(a, b interface{}) ([]interface{}, error) {
+		var result interface{}
+		switch n.op {
+		case "==":
+			result = compare(a, b) == 0
+		case "!=":
+			result = compare(a, b) != 0
+		case "<":
+			result = compare(a, b) < 0
+		case "<=":
+			result = compare(a, b) <= 0
+		case ">":
+			result = compare(a, b) > 0
+		case ">=":
+			result = compare(a, b) >= 0
+		default:
+			var err error
+			if result, err = arithmetic(n.op, a, b); err != nil {
+				return nil, err
+			}
+		}
+		return []interface{}{result}, nil
+	})
+}
+
+// -x
+type negate struct {
+	x node
+}
+
+func (n negate) eval(v interface{}) ([]interface{}, error) {
+	values, err := n.x.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(values))
+	for i, x := range values {
+		switch x := x.(type) {
+		case int:
+			if isMinInt(x) {
+				out[i] = -float64(x)
+				break
+			}
+			out[i] = -x
+		case float64:
+			out[i] = -x
+		default:
+			return out[:i], fmt.Errorf("%s cannot be negated", describe(x))
+		}
+	}
+	return out, nil
+}
+
+// [body]
+type arrayCons struct {
+	body node // nil for []
+}
+
+func (n arrayCons) eval(v interface{}) ([]interface{}, error) {
+	if n.body == nil {
+		return []interface{}{[]interface{}{}}, nil
+	}
+	elems, err := n.body.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	if elems == nil {
+		elems = []interface{}{}
+	}
+	return []interface{}{elems}, nil
+}
+
+// {key: value, ...}
+type objectCons struct {
+	entries []entry
+}
+
+type entry struct {
+	key, value node
+}
+
+func (n objectCons) eval(v interface{}) ([]interface{}, error) {
+	objects := []map[string]interface{}{{}}
+	// every combination of the outputs of the keys and values makes an object
+	for _, e := range n.entries {
+		keys, err := e.key.eval(v)
+		if err != nil {
+			return nil, err
+		}
+		values, err := e.value.eval(v)
+		if err != nil {
+			return nil, err
+		}
+		var next []map[string]interface{}
+		for _, obj := range objects {
+			for _, k := range keys {
+				key, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("Cannot use %s as object key", describe(k))
+				}
+				for _, value := range values {
+					extended := make(map[string]interface{}, len(obj)+1)
+					for k, v := range obj {
+						extended[k] = v
+					}
+					extended[key] = value
+					next = append(next, extended)
+				}
+			}
+		}
+		objects = next
+	}
+
+	out := make([]interface{}, len(objects))
+	for i, obj := range objects {
+		out[i] = obj
+	}
+	return out, nil
+}
+
+// if cond then then else els end, with elif as an if in els
+type ifNode struct {
+	cond, then, els node
+}
+
+func (n ifNode) eval(v interface{}) ([]interface{}, error) {
+	conds, err := n.cond.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, c := range conds {
+		branch := n.els
+		if truthy(c) {
+			branch = n.then
+		}
+		values, err := branch.eval(v)
+		out = append(out, values...)
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// calls f with every pair of the outputs of l and r for the input v,
+// the outputs of r in the outer loop, as jq does
+func cross(l, r node, v interface{}, f func(a, b interface{}) ([]interface{}, error)) ([]interface{}, error) {
+	right, err := r.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	left, err := l.eval(v)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, b := range right {
+		for _, a := range left {
+			values, err := f(a, b)
+			out = append(out, values...)
+			if err != nil {
+				return out, err
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,171 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/parse"
+)
+
+const store = `{
+	"name": "corner store",
+	"open": true,
+	"owner": null,
+	"items": [
+		{"name": "apple", "price": 0.5, "tags": ["fruit", "red"], "stock": 120},
+		{"name": "bread", "price": 2.25, "tags": ["bakery"], "stock": 0},
+		{"name": "cheese", "price": 7, "tags": [], "stock": 12}
+	]
+}`
+
+func TestRun(t *testing.T) {
+	doc, err := parse.Parse(strings.NewReader(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		filter string
+		want   string // the outputs written compactly, one per line
+	}{
+		{filter: `.`, want: `{"items":[{"name":"apple","price":0.5,"stock":120,"tags":["fruit","red"]},{"name":"bread","price":2.25,"stock":0,"tags":["bakery"]},{"name":"cheese","price":7,"stock":12,"tags":[]}],"name":"corner store","open":true,"owner":null}`},
+		{filter: `.name`, want: `"corner store"`},
+		{filter: `."name"`, want: `"corner store"`},
+		{filter: `.["name"]`, want: `"corner store"`},
+		{filter: `.missing`, want: `null`},
+		{filter: `.owner.name`, want: `null`},
+		{filter: `.items[0].name`, want: `"apple"`},
+		{filter: `.items[-1].name`, want: `"cheese"`},
+		{filter: `.items[5]`, want: `null`},
+		{filter: `.items[].name`, want: "\"apple\"\n\"bread\"\n\"cheese\""},
+		{filter: `.items | .[1:] | map(.name)`, want: `["bread","cheese"]`},
+		{filter: `.items[:-2] | length`, want: `1`},
+		{filter: `.name[0:6]`, want: `"corner"`},
+		{filter: `.items[] | select(.price > 1) | .name`, want: "\"bread\"\n\"cheese\""},
+		{filter: `.items[] | select(.stock == 0 or .price < 1).name`, want: "\"apple\"\n\"bread\""},
+		{filter: `.items[] | select(.tags | length > 0 and .[0] != "bakery") | .name`, want: `"apple"`},
+		{filter: `[.items[] | .price * .stock] | add`, want: `144.0`},
+		{filter: `.items | map(.price) | add / length`, want: `3.25`},
+		{filter: `.items[0] | {name, cost: .price, "in stock": (.stock > 0)}`, want: `{"cost":0.5,"in stock":true,"name":"apple"}`},
+		{filter: `{(.items[].name): .open}`, want: "{\"apple\":true}\n{\"bread\":true}\n{\"cheese\":true}"},
+		{filter: `.items[1] | keys`, want: `["name","price","stock","tags"]`},
+		{filter: `.items | keys`, want: `[0,1,2]`},
+		{filter: `[.items[].tags[]] | length`, want: `3`},
+		{filter: `.items[0].tags | join(", ")`, want: `"fruit, red"`},
+		{filter: `.items | sort_by(-.price) | map(.name)`, want: `["cheese","bread","apple"]`},
+		{filter: `.items | map(.stock) | sort, min, max`, want: "[0,12,120]\n0\n120"},
+		{filter: `.owner // "nobody"`, want: `"nobody"`},
+		{filter: `.items[] | if .stock == 0 then "sold out" elif .stock < 50 then "low" else "plenty" end`, want: "\"plenty\"\n\"sold out\"\n\"low\""},
+		{filter: `.items[0] | has("tags"), has("color")`, want: "true\nfalse"},
+		{filter: `.items[0] | to_entries | map(.key)`, want: `["name","price","stock","tags"]`},
+		{filter: `.items[0] | with_entries(select(.key | startswith("n")))`, want: `{"name":"apple"}`},
+		{filter: `.items[0] | map_values(tostring)`, want: `{"name":"apple","price":"0.5","stock":"120","tags":"[\"fruit\",\"red\"]"}`},
+		{filter: `.name | ascii_upcase | split(" ")`, want: `["CORNER","STORE"]`},
+		{filter: `.items[] | select(.name | test("^[ab]")) | .name`, want: "\"apple\"\n\"bread\""},
+		{filter: `[..] | length`, want: `23`},
+		{filter: `.items[0].tags + ["sale"] - ["red"]`, want: `["fruit","sale"]`},
+		{filter: `{a: 1} + {b: 2} | keys`, want: `["a","b"]`},
+		{filter: `{a: {b: 1}} * {a: {c: 2}}`, want: `{"a":{"b":1,"c":2}}`},
+		{filter: `7 % 3, -(1 + 2) * 2, 1 / 4, 10 / 5`, want: "1\n-6\n0.25\n2"},
+		{filter: `(1, 2) + (10, 20)`, want: "11\n12\n21\n22"},
+		// integers that would overflow are computed with floats
+		{filter: `9223372036854775807 + 1, -9223372036854775807 - 2`, want: "9223372036854776000.0\n-9223372036854776000.0"},
+		{filter: `4611686018427387904 * 2, 4611686018427387904 * -2`, want: "9223372036854776000.0\n-9223372036854775808"},
+		{filter: `-9223372036854775807 - 1 | -., . / -1, . * -1`, want: "9223372036854776000.0\n9223372036854776000.0\n9223372036854776000.0"},
+		{filter: `[1, null, "a", false, [1], {}, true, 0.5] | sort`, want: `[null,false,true,0.5,1,"a",[1],{}]`},
+		{filter: `[1, 1.0, 2] | unique`, want: `[1,2]`},
+		{filter: `.open | not`, want: `false`},
+		{filter: `[.name, .open, .owner, .items] | map(type)`, want: `["string","boolean","null","array"]`},
+		{filter: `"42", "4.5" | tonumber`, want: "42\n4.5"},
+		{filter: `.items | first.name, last.name`, want: "\"apple\"\n\"cheese\""},
+		{filter: `[.items[].stock > 10] | any, all`, want: "true\nfalse"},
+		{filter: `.items | any(.stock > 10), all(.stock > 10), all(.price > 0)`, want: "true\nfalse\ntrue"},
+		{filter: `[] | any(.), all(.)`, want: "false\ntrue"},
+		{filter: `[1, 2] | any(empty, . > 1), all(. > 0, . > 1)`, want: "true\nfalse"},
+		{filter: `[.owner, .name] | map(values)`, want: `["corner store"]`},
+		{filter: `.name | reverse`, want: `"erots renroc"`},
+		{filter: `.items[] | .name?, (.price | keys)?`, want: "\"apple\"\n\"bread\"\n\"cheese\""},
+		{filter: `[.items[] | empty]`, want: `[]`},
+		{filter: `{"a": "x\ty"} | tojson`, want: `"{\"a\":\"x\\ty\"}"`},
+		{filter: `[.items[].tags] | map(length) | add`, want: `3`},
+	}
+
+	for _, test := range tests {
+		q, err := Parse(test.filter)
+		if err != nil {
+			t.Errorf("filter: %s, unexpected parse error: %v", test.filter, err)
+			continue
+		}
+		out, err := q.Run(doc)
+		if err != nil {
+			t.Errorf("filter: %s, unexpected error: %v", test.filter, err)
+			continue
+		}
+		var lines []string
+		for _, v := range out {
+			b, err := encode.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, string(b))
+		}
+		if got := strings.Join(lines, "\n"); got != test.want {
+			t.Errorf("filter: %s, got: %s, want: %s", test.filter, got, test.want)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		input  interface{}
+		err    string
+	}{
+		{filter: `.a`, input: 1, err: `Cannot index number with "a"`},
+		{filter: `.[0]`, input: map[string]interface{}{}, err: `Cannot index object with number`},
+		{filter: `.[]`, input: true, err: `Cannot iterate over boolean (true)`},
+		{filter: `. + 1`, input: "a", err: `string ("a") and number (1) cannot be added`},
+		{filter: `. / 0`, input: 1, err: `number (1) and number (0) cannot be divided because the divisor is zero`},
+		{filter: `length`, input: true, err: `boolean (true) has no length`},
+		{filter: `keys`, input: "a", err: `string ("a") has no keys`},
+		{filter: `{(.): 1}`, input: 1, err: `Cannot use number (1) as object key`},
+		{filter: `sort`, input: "abcdefghijklmnop", err: `string ("abcdefghi...) cannot be sorted, as it is not an array`},
+	}
+
+	for _, test := range tests {
+		q, err := Parse(test.filter)
+		if err != nil {
+			t.Errorf("filter: %s, unexpected parse error: %v", test.filter, err)
+			continue
+		}
+		if _, err := q.Run(test.input); err == nil || err.Error() != test.err {
+			t.Errorf("filter: %s, got: %v, want: %s", test.filter, err, test.err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		err    string
+	}{
+		{filter: `.a |`, err: `Unexpected end of filter, expected value`},
+		{filter: `.a]`, err: `Expected end of filter at offset 2, found: "]"`},
+		{filter: `map(.a`, err: `Unexpected end of filter, expected ")"`},
+		{filter: `foo(1)`, err: `Unknown function foo/1 at offset 0`},
+		{filter: `{a: 1`, err: `Unexpected end of filter, expected "}"`},
+		{filter: `{1: 2}`, err: `Expected key at offset 1, found: "1"`},
+		{filter: `if . then 1`, err: `Unexpected end of filter, expected end`},
+		{filter: `"abc`, err: `Unterminated string beginning at offset 0`},
+		{filter: `.a $x`, err: `Invalid character '$' at offset 3`},
+		{filter: `.[:]`, err: `Expected end of slice at offset 3, found: "]"`},
+		{filter: `. then`, err: `Expected end of filter at offset 2, found: "then"`},
+	}
+
+	for _, test := range tests {
+		if _, err := Parse(test.filter); err == nil || err.Error() != test.err {
+			t.Errorf("filter: %s, got: %v, want: %s", test.filter, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/vyevs/gojson/encode"
+)
+
+// typeName returns the jq name of the type of v
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// describes v in an error, e.g.: number (1)
+func describe(v interface{}) string {
+	b, err := encode.Marshal(v)
+	if err != nil {
+		return typeName(v)
+	}
+	s := string(b)
+	if len(s) > 11 {
+		s = s[:10] + "..."
+	}
+	return fmt.Sprintf("%s (%s)", typeName(v), s)
+}
+
+func truthy(v interface{}) bool {
+	return v != nil && v != false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// the order of the types of values, by which values of different types compare
+func typeOrder(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 2
+		}
+		return 1
+	case int, float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	}
+	return 6
+}
+
+// compare returns -1, 0 or 1 as a sorts before, with or after b, in the order
+// of jq: null, false, true, numbers, strings, arrays, objects
+// arrays compare element by element, and objects by their sorted keys first,
+// then by their values key by key
+func compare(a, b interface{}) int {
+	if oa, ob := typeOrder(a), typeOrder(b); oa != ob {
+		return sign(oa - ob)
+	}
+	switch a := a.(type) {
+	case int, float64:
+		if ai, ok := a.(int); ok {
+			if bi, ok := b.(int); ok {
+				return sign(ai - bi)
+			}
+		}
+		af, _ := toFloat(a)
+		bf, _ := toFloat(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return sign(len(a) - len(b))
+	case map[string]interface{}:
+		b := b.(map[string]interface{})
+		aKeys, bKeys := encode.SortedKeys(a), encode.SortedKeys(b)
+		if c := compare(stringsToValues(aKeys), stringsToValues(bKeys)); c != 0 {
+			return c
+		}
+		for _, k := range aKeys {
+			if c := compare(a[k], b[k]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func stringsToValues(strs []string) []interface{} {
+	values := make([]interface{}, len(strs))
+	for i, s := range strs {
+		values[i] = s
+	}
+	return values
+}
+
+// sortValues sorts values in the order of compare
+func sortValues(values []interface{}) {
+	sort.SliceStable(values, func(i, j int) bool { return compare(values[i], values[j]) < 0 })
+}
+
+// arithmetic applies the operator op, one of + - * / %, to a and b
+// ints give an int, or a float64 if the result does not fit in one
+func arithmetic(op string, a, b interface{}) (interface{}, error) {
+	ai, aInt := a.(int)
+	bi, bInt := b.(int)
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+
+	switch op {
+	case "+":
+		switch {
+		case a == nil:
+			return b, nil
+		case b == nil:
+			return a, nil
+		case aInt && bInt && (ai+bi > ai) == (bi > 0):
+			return ai + bi, nil
+		case aNum && bNum:
+			return af + bf, nil
+		}
+		switch a := a.(type) {
+		case string:
+			if b, ok := b.(string); ok {
+				return a + b, nil
+			}
+		case []interface{}:
+			if b, ok := b.([]interface{}); ok {
+				return append(append([]interface{}{}, a...), b...), nil
+			}
+		case map[string]interface{}:
+			if b, ok := b.(map[string]interface{}); ok {
+				merged := make(map[string]interface{}, len(a)+len(b))
+				for k, v := range a {
+					merged[k] = v
+				}
+				for k, v := range b {
+					merged[k] = v
+				}
+				return merged, nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be added", describe(a), describe(b))
+
+	case "-":
+		switch {
+		case aInt && bInt && (ai-bi < ai) == (bi > 0):
+			return ai - bi, nil
+		case aNum && bNum:
+			return af - bf, nil
+		}
+		if a, ok := a.([]interface{}); ok {
+			if b, ok := b.([]interface{}); ok {
+				return subtract(a, b), nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be subtracted", describe(a), describe(b))
+
+	case "*":
+		switch {
+		case aInt && bInt && !multiplyOverflows(ai, bi):
+			return ai * bi, nil
+		case aNum && bNum:
+			return af * bf, nil
+		}
+		if a, ok := a.(map[string]interface{}); ok {
+			if b, ok := b.(map[string]interface{}); ok {
+				return deepMerge(a, b), nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be multiplied", describe(a), describe(b))
+
+	case "/":
+		if aNum && bNum {
+			if bf == 0 {
+				return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", describe(a), describe(b))
+			}
+			if aInt && bInt && ai%bi == 0 && !(bi == -1 && isMinInt(ai)) {
+				return ai / bi, nil
+			}
+			return af / bf, nil
+		}
+		if a, ok := a.(string); ok {
+			if b, ok := b.(string); ok {
+				return split(a, b), nil
+			}
+		}
+		return nil, fmt.Errorf("%s and %s cannot be divided", describe(a), describe(b))
+
+	case "%":
+		if aNum && bNum {
+			ai, bi := truncate(af), truncate(bf)
+			if bi == 0 {
+				return nil, fmt.Errorf("%s and %s cannot be divided because the divisor is zero", describe(a), describe(b))
+			}
+			return ai % bi, nil
+		}
+		return nil, fmt.Errorf("%s and %s cannot be divided", describe(a), describe(b))
+	}
+	return nil, fmt.Errorf("Unknown operator %s", op)
+}
+
+// reports whether a * b does not fit in an int
+func multiplyOverflows(a, b int) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+	product := a * b
+	return product/b != a || b == -1 && isMinInt(a)
+}
+
+// reports whether x is the smallest int, the one whose negation does not fit
+func isMinInt(x int) bool {
+	return x < 0 && -x < 0
+}
+
+func truncate(f float64) int {
+	if math.IsNaN(f) {
+		return 0
+	}
+	return int(f)
+}
+
+// the elements of a that are not in b
+func subtract(a, b []interface{}) []interface{} {
+	out := []interface{}{}
+outer:
+	for _, v := range a {
+		for _, w := range b {
+			if compare(v, w) == 0 {
+				continue outer
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// merges b into a, merging the objects both have under a key
+func deepMerge(a, b map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		if bObj, ok := v.(map[string]interface{}); ok {
+			if aObj, ok := merged[k].(map[string]interface{}); ok {
+				v = deepMerge(aObj, bObj)
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+func split(s, sep string) []interface{} {
+	if s == "" {
+		return []interface{}{}
+	}
+	return stringsToValues(strings.Split(s, sep))
+}
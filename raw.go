@@ -0,0 +1,35 @@
+package gojson
+
+import (
+	"bytes"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// RawValue holds the source of a JSON value exactly as it appears in the
+// document, including its whitespace and escape sequences
+//
+// decoding into a RawValue checks the value and defers decoding it,
+// it can be decoded later with Unmarshal
+// a RawValue is encoded without its insignificant whitespace, a nil RawValue as null
+type RawValue []byte
+
+// DecodeJSON captures the source of the value that begins with first
+func (r *RawValue) DecodeJSON(l lex.Lexer, first tok.Token) error {
+	data, err := captureValue(l, first)
+	if err != nil {
+		return err
+	}
+	*r = data
+	return nil
+}
+
+// EncodeJSON writes the value r holds to buf
+func (r RawValue) EncodeJSON(buf *bytes.Buffer) error {
+	if r == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	return compact(buf, r)
+}
@@ -0,0 +1,338 @@
+// Package repair fixes the common mistakes of hand-edited and scraped JSON
+//
+// Repair rewrites a document token by token, keeping its formatting, and
+// fixes single-quoted strings, unquoted keys, trailing commas, the Python
+// literals True, False and None, and control characters such as newlines
+// left unescaped in strings, along with escape sequences that are not valid
+// anything else that is not valid JSON is an error
+package repair
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// Fix is a repair made to a document, at the position in the input it applies to
+type Fix struct {
+	Offset      int
+	Line        int // 1-based
+	Column      int // 1-based, counted in bytes
+	Description string
+}
+
+func (f Fix) String() string {
+	return fmt.Sprintf("%d:%d: %s", f.Line, f.Column, f.Description)
+}
+
+// Repair reads the document in r and returns it as valid JSON,
+// along with the fixes made to it, in the order they apply
+// the error returned is for input that cannot be repaired, or from reading r
+func Repair(r io.Reader) ([]byte, []Fix, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := &repairer{data: data, out: make([]byte, 0, len(data)+len(data)/16), comma: -1}
+	for p.whitespace(); p.i < len(p.data); p.whitespace() {
+		if err := p.step(p.data[p.i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	if p.state != stateEnd {
+		return nil, nil, fmt.Errorf("Unexpected end of document at offset %d", p.i)
+	}
+	p.positions()
+	return p.out, p.fixes, nil
+}
+
+// what the repairer expects the next token to be
+type state uint8
+
+const (
+	stateValue      state = iota // a value, at the top level or after a colon or comma
+	stateFirstElem               // a value or the end of an array
+	stateFirstKey                // a key or the end of an object
+	stateKey                     // a key after a comma
+	stateColon                   // the colon after a key
+	stateAfterValue              // a comma or the end of an object or array
+	stateEnd                     // nothing but whitespace, the document is complete
+)
+
+type repairer struct {
+	data  []byte
+	i     int
+	out   []byte
+	fixes []Fix
+	state state
+	open  []byte // the opening brackets of the objects and arrays being read
+	comma int    // the offset in out of the comma just written, -1 if a token has followed it
+}
+
+func (p *repairer) fix(offset int, format string, args ...interface{}) {
+	p.fixes = append(p.fixes, Fix{Offset: offset, Description: fmt.Sprintf(format, args...)})
+}
+
+func (p *repairer) expected(what string) error {
+	return fmt.Errorf("Expected %s at offset %d, found: %q", what, p.i, p.data[p.i])
+}
+
+// copies the whitespace at p.i
+func (p *repairer) whitespace() {
+	for p.i < len(p.data) {
+		switch c := p.data[p.i]; c {
+		case ' ', '\n', '\t', '\r':
+			p.out = append(p.out, c)
+			p.i++
+		default:
+			return
+		}
+	}
+}
+
+// rewrites the token beginning with c
+func (p *repairer) step(c byte) error {
+	switch p.state {
+	case stateEnd:
+		return p.expected("end of document")
+
+	case stateFirstElem, stateValue:
+		if c == ']' && len(p.open) > 0 && p.open[len(p.open)-1] == '[' && (p.state == stateFirstElem || p.comma >= 0) {
+			return p.close(c)
+		}
+		return p.value(c)
+
+	case stateFirstKey, stateKey:
+		if c == '}' && (p.state == stateFirstKey || p.comma >= 0) {
+			return p.close(c)
+		}
+		if c == '"' || c == '\'' {
+			if err := p.str(c); err != nil {
+				return err
+			}
+		} else if isIdentStart(c) {
+			start := p.i
+			word := p.word()
+			p.fix(start, "Quoted key %s", word)
+			p.out = append(p.out, '"')
+			p.out = append(p.out, word...)
+			p.out = append(p.out, '"')
+		} else {
+			return p.expected("key")
+		}
+		p.state = stateColon
+
+	case stateColon:
+		if c != ':' {
+			return p.expected("colon")
+		}
+		p.write(c)
+		p.state = stateValue
+
+	case stateAfterValue:
+		top := p.open[len(p.open)-1]
+		switch {
+		case c == ',':
+			p.write(c)
+			p.comma = len(p.out) - 1
+			p.state = stateValue
+			if top == '{' {
+				p.state = stateKey
+			}
+		case c == '}' && top == '{', c == ']' && top == '[':
+			return p.close(c)
+		case top == '{':
+			return p.expected("comma or closing curly brace")
+		default:
+			return p.expected("comma or closing square bracket")
+		}
+	}
+	return nil
+}
+
+// writes the single byte token c
+func (p *repairer) write(c byte) {
+	p.out = append(p.out, c)
+	p.i++
+}
+
+// closes the innermost object or array, dropping a trailing comma before c
+func (p *repairer) close(c byte) error {
+	if p.comma >= 0 {
+		source := p.i - (len(p.out) - p.comma)
+		p.fix(source, "Removed trailing comma")
+		p.out = append(p.out[:p.comma], p.out[p.comma+1:]...)
+		p.comma = -1
+	}
+	p.open = p.open[:len(p.open)-1]
+	p.write(c)
+	p.afterValue()
+	return nil
+}
+
+func (p *repairer) afterValue() {
+	p.comma = -1
+	p.state = stateAfterValue
+	if len(p.open) == 0 {
+		p.state = stateEnd
+	}
+}
+
+// rewrites the value beginning with c
+func (p *repairer) value(c byte) error {
+	p.comma = -1
+	switch {
+	case c == '{' || c == '[':
+		p.open = append(p.open, c)
+		p.write(c)
+		p.state = stateFirstKey
+		if c == '[' {
+			p.state = stateFirstElem
+		}
+		return nil
+	case c == '"' || c == '\'':
+		if err := p.str(c); err != nil {
+			return err
+		}
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.i
+		for p.i < len(p.data) && isNumberByte(p.data[p.i]) {
+			p.i++
+		}
+		if lex.NumericTokenType(string(p.data[start:p.i])) == tok.Invalid {
+			return fmt.Errorf("Invalid number at offset %d: %q", start, p.data[start:p.i])
+		}
+		p.out = append(p.out, p.data[start:p.i]...)
+	case isIdentStart(c):
+		start := p.i
+		word := p.word()
+		literal, ok := literals[word]
+		if !ok {
+			return fmt.Errorf("Invalid value at offset %d: %q", start, word)
+		}
+		if literal != word {
+			p.fix(start, "Replaced %s with %s", word, literal)
+		}
+		p.out = append(p.out, literal...)
+	default:
+		return p.expected("value")
+	}
+	p.afterValue()
+	return nil
+}
+
+// the literals accepted, and those they are written as
+var literals = map[string]string{
+	"true":  "true",
+	"false": "false",
+	"null":  "null",
+	"True":  "true",
+	"False": "false",
+	"None":  "null",
+}
+
+// reads the identifier at p.i
+func (p *repairer) word() string {
+	start := p.i
+	for p.i < len(p.data) && isIdentByte(p.data[p.i]) {
+		p.i++
+	}
+	return string(p.data[start:p.i])
+}
+
+var controlNames = map[byte]string{
+	'\n': "newline",
+	'\r': "carriage return",
+	'\t': "tab",
+}
+
+var controlEscapes = map[byte]string{
+	'\b': `\b`,
+	'\f': `\f`,
+	'\n': `\n`,
+	'\r': `\r`,
+	'\t': `\t`,
+}
+
+// rewrites the string at p.i, quoted with quote, in double quotes
+func (p *repairer) str(quote byte) error {
+	start := p.i
+	if quote == '\'' {
+		p.fix(start, "Replaced single quotes with double quotes")
+	}
+	p.out = append(p.out, '"')
+	for p.i++; p.i < len(p.data); p.i++ {
+		c := p.data[p.i]
+		switch {
+		case c == quote:
+			p.write('"')
+			return nil
+
+		case c == '"':
+			// within single quotes
+			p.out = append(p.out, '\\', '"')
+
+		case c == '\\':
+			n := lex.EscapeLen(p.data[p.i:])
+			switch {
+			case n > 0:
+				p.out = append(p.out, p.data[p.i:p.i+n]...)
+				p.i += n - 1
+			case p.i+1 < len(p.data) && p.data[p.i+1] == '\'':
+				if quote == '"' {
+					p.fix(p.i, "Replaced escaped single quote with single quote")
+				}
+				p.out = append(p.out, '\'')
+				p.i++
+			default:
+				p.fix(p.i, "Escaped backslash of invalid escape sequence")
+				p.out = append(p.out, '\\', '\\')
+			}
+
+		case c < 0x20:
+			name, ok := controlNames[c]
+			if !ok {
+				name = fmt.Sprintf("control character %U", c)
+			}
+			p.fix(p.i, "Escaped %s in string", name)
+			if esc, ok := controlEscapes[c]; ok {
+				p.out = append(p.out, esc...)
+			} else {
+				p.out = append(p.out, fmt.Sprintf(`\u%04x`, c)...)
+			}
+
+		default:
+			p.out = append(p.out, c)
+		}
+	}
+	return fmt.Errorf("Unterminated string beginning at offset %d", start)
+}
+
+// sets the lines and columns of the fixes from their offsets
+func (p *repairer) positions() {
+	if len(p.fixes) == 0 {
+		return
+	}
+	lines := lex.NewLines(p.data)
+	for i := range p.fixes {
+		f := &p.fixes[i]
+		f.Line, f.Column = lines.Position(f.Offset)
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// the bytes a number may hold, whether in a valid order is checked at its end
+func isNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E'
+}
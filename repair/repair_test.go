@@ -0,0 +1,98 @@
+package repair
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		doc       string
+		want      string
+		wantFixes []string
+	}{
+		{doc: `{"a": [1, 2.5, true, null]}`, want: `{"a": [1, 2.5, true, null]}`},
+		{
+			doc:       `{'a': 'it\'s "x"'}`,
+			want:      `{"a": "it's \"x\""}`,
+			wantFixes: []string{"1:2: Replaced single quotes with double quotes", "1:7: Replaced single quotes with double quotes"},
+		},
+		{
+			doc:       "{name: \"x\", $id: 1, _k2: 2}",
+			want:      `{"name": "x", "$id": 1, "_k2": 2}`,
+			wantFixes: []string{"1:2: Quoted key name", "1:13: Quoted key $id", "1:21: Quoted key _k2"},
+		},
+		{
+			doc:       "[1, 2,\n]",
+			want:      "[1, 2\n]",
+			wantFixes: []string{"1:6: Removed trailing comma"},
+		},
+		{
+			doc:       `{"a": {"b": 1,}, "c": [[],],}`,
+			want:      `{"a": {"b": 1}, "c": [[]]}`,
+			wantFixes: []string{"1:14: Removed trailing comma", "1:26: Removed trailing comma", "1:28: Removed trailing comma"},
+		},
+		{
+			doc:       `[True, False, None]`,
+			want:      `[true, false, null]`,
+			wantFixes: []string{"1:2: Replaced True with true", "1:8: Replaced False with false", "1:15: Replaced None with null"},
+		},
+		{
+			doc:       "{\"text\": \"line 1\nline 2\tend\x01\"}",
+			want:      `{"text": "line 1\nline 2\tend\u0001"}`,
+			wantFixes: []string{"1:17: Escaped newline in string", "2:7: Escaped tab in string", "2:11: Escaped control character U+0001 in string"},
+		},
+		{
+			doc:       `["a\'b", "c:\dir", "\u00e9\n"]`,
+			want:      `["a'b", "c:\\dir", "\u00e9\n"]`,
+			wantFixes: []string{"1:4: Replaced escaped single quote with single quote", "1:13: Escaped backslash of invalid escape sequence"},
+		},
+		{doc: "  \"x\"  ", want: "  \"x\"  "},
+	}
+
+	for _, test := range tests {
+		got, fixes, err := Repair(strings.NewReader(test.doc))
+
+		var gotFixes []string
+		for _, f := range fixes {
+			gotFixes = append(gotFixes, f.String())
+		}
+		if err != nil || string(got) != test.want || !reflect.DeepEqual(gotFixes, test.wantFixes) {
+			t.Errorf("doc: %q, got: %s, fixes: %q, want: %s, fixes: %q, err: %v",
+				test.doc, got, gotFixes, test.want, test.wantFixes, err)
+			continue
+		}
+		if _, err := parse.Parse(bytes.NewReader(got)); err != nil {
+			t.Errorf("doc: %q, repaired: %s, not valid: %v", test.doc, got, err)
+		}
+	}
+}
+
+func TestRepairErrors(t *testing.T) {
+	tests := []struct {
+		doc     string
+		wantErr string
+	}{
+		{doc: ``, wantErr: "Unexpected end of document at offset 0"},
+		{doc: `[1, 2`, wantErr: "Unexpected end of document at offset 5"},
+		{doc: `{"a" 1}`, wantErr: `Expected colon at offset 5, found: '1'`},
+		{doc: `[1 2]`, wantErr: `Expected comma or closing square bracket at offset 3, found: '2'`},
+		{doc: `[undefined]`, wantErr: `Invalid value at offset 1: "undefined"`},
+		{doc: `[01]`, wantErr: `Invalid number at offset 1: "01"`},
+		{doc: `['abc]`, wantErr: "Unterminated string beginning at offset 1"},
+		{doc: `[,]`, wantErr: `Expected value at offset 1, found: ','`},
+		{doc: `{} {}`, wantErr: `Expected end of document at offset 3, found: '{'`},
+	}
+
+	for _, test := range tests {
+		_, _, err := Repair(strings.NewReader(test.doc))
+
+		if err == nil || err.Error() != test.wantErr {
+			t.Errorf("doc: %q, got err: %v, want: %s", test.doc, err, test.wantErr)
+		}
+	}
+}
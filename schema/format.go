@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vyevs/gojson/pointer"
+)
+
+// formats maps the format names that are asserted when Compiler.AssertFormat
+// is set to their checks, unknown formats are never asserted
+var formats = map[string]func(string) bool{
+	"date-time":             isDateTime,
+	"date":                  isDate,
+	"time":                  isTime,
+	"duration":              durationRegexp.MatchString,
+	"email":                 isEmail,
+	"hostname":              isHostname,
+	"ipv4":                  isIPv4,
+	"ipv6":                  isIPv6,
+	"uri":                   isURI,
+	"uri-reference":         isURIReference,
+	"uuid":                  uuidRegexp.MatchString,
+	"regex":                 isRegex,
+	"json-pointer":          isJSONPointer,
+	"relative-json-pointer": relativePointerRegexp.MatchString,
+}
+
+// CheckFormat reports whether str is valid for the named format,
+// known is false for formats this package does not check
+func CheckFormat(format, str string) (valid, known bool) {
+	check, ok := formats[format]
+	if !ok {
+		return true, false
+	}
+	return check(str), true
+}
+
+var (
+	uuidRegexp            = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	durationRegexp        = regexp.MustCompile(`^P(?:\d+W|(?:\d+Y(?:\d+M)?(?:\d+D)?|\d+M(?:\d+D)?|\d+D)(?:T(?:\d+H(?:\d+M)?(?:\d+S)?|\d+M(?:\d+S)?|\d+S))?|T(?:\d+H(?:\d+M)?(?:\d+S)?|\d+M(?:\d+S)?|\d+S))$`)
+	relativePointerRegexp = regexp.MustCompile(`^(?:0|[1-9][0-9]*)(?:#|(?:/(?:[^~/]|~[01])*)*)$`)
+	hostnameLabelRegexp   = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+)
+
+// RFC 3339 date-time, the separator and zone letters may be lowercase
+func isDateTime(str string) bool {
+	_, err := time.Parse(time.RFC3339Nano, strings.ToUpper(str))
+	return err == nil
+}
+
+func isDate(str string) bool {
+	_, err := time.Parse("2006-01-02", str)
+	return err == nil
+}
+
+// RFC 3339 full-time, which requires a time zone offset
+func isTime(str string) bool {
+	_, err := time.Parse("15:04:05.999999999Z07:00", strings.ToUpper(str))
+	return err == nil
+}
+
+func isEmail(str string) bool {
+	addr, err := mail.ParseAddress(str)
+	return err == nil && addr.Address == str && addr.Name == ""
+}
+
+func isHostname(str string) bool {
+	str = strings.TrimSuffix(str, ".")
+	if str == "" || len(str) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(str, ".") {
+		if !hostnameLabelRegexp.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// dotted quads only, leading zeros are rejected as they are ambiguous
+func isIPv4(str string) bool {
+	parts := strings.Split(str, ".")
+	if len(parts) != 4 {
+		return false
+	}
+	for _, p := range parts {
+		if len(p) > 1 && p[0] == '0' {
+			return false
+		}
+	}
+	ip := net.ParseIP(str)
+	return ip != nil && ip.To4() != nil
+}
+
+func isIPv6(str string) bool {
+	return strings.Contains(str, ":") && !strings.Contains(str, "%") && net.ParseIP(str) != nil
+}
+
+func isURI(str string) bool {
+	u, err := url.Parse(str)
+	return err == nil && u.IsAbs()
+}
+
+func isURIReference(str string) bool {
+	_, err := url.Parse(str)
+	return err == nil
+}
+
+func isRegex(str string) bool {
+	_, err := regexp.Compile(str)
+	return err == nil
+}
+
+func isJSONPointer(str string) bool {
+	_, err := pointer.Parse(str)
+	return err == nil
+}
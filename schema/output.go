@@ -0,0 +1,85 @@
+package schema
+
+// OutputUnit is a single unit of the output formats defined by the spec
+// the locations are JSON Pointers, AbsoluteKeywordLocation is a full URI
+// with a JSON Pointer fragment, which is the location of the keyword after
+// following any $ref
+type OutputUnit struct {
+	Valid                   bool
+	KeywordLocation         string
+	AbsoluteKeywordLocation string
+	InstanceLocation        string
+	Error                   string
+	Errors                  []OutputUnit
+}
+
+// Value converts u into its JSON representation, e.g. for encode.Marshal
+// members that are empty are left out, as in the examples of the spec
+func (u OutputUnit) Value() map[string]interface{} {
+	out := map[string]interface{}{"valid": u.Valid}
+	if u.KeywordLocation != "" || u.InstanceLocation != "" || u.AbsoluteKeywordLocation != "" {
+		out["keywordLocation"] = u.KeywordLocation
+		out["instanceLocation"] = u.InstanceLocation
+	}
+	if u.AbsoluteKeywordLocation != "" {
+		out["absoluteKeywordLocation"] = u.AbsoluteKeywordLocation
+	}
+	if u.Error != "" {
+		out["error"] = u.Error
+	}
+	if len(u.Errors) > 0 {
+		errors := make([]interface{}, 0, len(u.Errors))
+		for _, e := range u.Errors {
+			errors = append(errors, e.Value())
+		}
+		out["errors"] = errors
+	}
+	return out
+}
+
+func (u *unit) outputUnit() OutputUnit {
+	return OutputUnit{
+		Valid:                   u.valid,
+		KeywordLocation:         u.keyword.String(),
+		AbsoluteKeywordLocation: u.absolute,
+		InstanceLocation:        u.instance.String(),
+		Error:                   u.err,
+	}
+}
+
+// flatten appends every failed unit below u that has an error message
+// to out, depth first, which is the list of errors of the basic format
+func (u *unit) flatten(out []OutputUnit) []OutputUnit {
+	if u.valid {
+		return out
+	}
+	if u.err != "" {
+		out = append(out, u.outputUnit())
+	}
+	for _, child := range u.children {
+		out = child.flatten(out)
+	}
+	return out
+}
+
+// detailed converts u into the detailed format, keeping only failed units
+// units without a message of their own, i.e. those of subschemas,
+// are replaced by their children
+func (u *unit) detailed() OutputUnit {
+	var children []OutputUnit
+	for _, child := range u.children {
+		if child.valid {
+			continue
+		}
+		d := child.detailed()
+		if d.Error == "" {
+			children = append(children, d.Errors...)
+		} else {
+			children = append(children, d)
+		}
+	}
+
+	out := u.outputUnit()
+	out.Errors = children
+	return out
+}
@@ -0,0 +1,781 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vyevs/gojson/parse"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// the URI schemas compiled by Compile are registered under
+// a hierarchical in-memory URI, so relative references still resolve against it
+const defaultURI = "mem:///schema.json"
+
+// Compile reads a JSON Schema (draft 2020-12) document from r and compiles it
+// the schema may only refer to itself, use a Compiler to make other documents
+// available to $ref or to enable format assertions
+func Compile(r io.Reader) (*Schema, error) {
+	c := NewCompiler()
+	if err := c.AddResource(defaultURI, r); err != nil {
+		return nil, err
+	}
+	return c.Compile(defaultURI)
+}
+
+// Compiler compiles schemas that may refer to each other through $ref
+//
+// documents are added with AddResource under the URI used to refer to them,
+// subschemas with their own $id or $anchor are found within them automatically
+// no documents are ever fetched from the network or the file system
+type Compiler struct {
+	// AssertFormat makes the format keyword an assertion, rather than
+	// just an annotation, for the formats this package knows
+	AssertFormat bool
+
+	docs map[string]interface{}
+	// every document and every subschema with an $id, by absolute URI
+	resources map[string]location
+	// every $anchor and $dynamicAnchor, by absolute URI including the fragment
+	anchors map[string]location
+	// every $dynamicAnchor, by name and then by the URI of its resource
+	dynamicAnchors map[string]map[string]location
+	// the resource every subschema belongs to, by its physical location
+	bases map[string]base
+	// compiled schemas by physical location, which allows recursive references
+	nodes map[string]*node
+}
+
+// location identifies a value by the document it is in and its position there
+type location struct {
+	doc string
+	ptr pointer.Pointer
+}
+
+func (l location) String() string {
+	return l.doc + "#" + l.ptr.String()
+}
+
+// base is the resource a subschema belongs to and its position within it
+type base struct {
+	uri string
+	ptr pointer.Pointer
+}
+
+// NewCompiler returns a Compiler with no documents added
+func NewCompiler() *Compiler {
+	return &Compiler{
+		docs:      map[string]interface{}{},
+		resources: map[string]location{},
+		anchors:   map[string]location{},
+		bases:     map[string]base{},
+		nodes:     map[string]*node{},
+
+		dynamicAnchors: map[string]map[string]location{},
+	}
+}
+
+// AddResource parses the schema document in r and makes it available under uri
+func (c *Compiler) AddResource(uri string, r io.Reader) error {
+	doc, err := parse.Parse(r)
+	if err != nil {
+		return fmt.Errorf("Parsing %q: %v", uri, err)
+	}
+	return c.AddResourceValue(uri, doc)
+}
+
+// AddResourceValue is like AddResource for an already parsed schema document
+func (c *Compiler) AddResourceValue(uri string, doc interface{}) error {
+	uri, err := normalizeURI(uri)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.docs[uri]; ok {
+		return fmt.Errorf("Resource %q already added", uri)
+	}
+	c.docs[uri] = doc
+	c.resources[uri] = location{doc: uri, ptr: pointer.Pointer{}}
+	return c.index(uri, pointer.Pointer{}, doc, base{uri: uri, ptr: pointer.Pointer{}})
+}
+
+// Compile compiles the schema that uri refers to, which must be a document
+// added to c, a subschema with an $id or a fragment of either
+func (c *Compiler) Compile(uri string) (*Schema, error) {
+	loc, err := c.resolve(uri)
+	if err != nil {
+		return nil, err
+	}
+	n, err := c.compile(loc)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{root: n, assertFormat: c.AssertFormat}, nil
+}
+
+// keywords whose values are not schemas and are never searched for $id or $anchor
+var dataKeywords = map[string]bool{
+	"enum":     true,
+	"const":    true,
+	"default":  true,
+	"examples": true,
+}
+
+// keywords whose values map names to schemas, the names are never keywords
+var schemaMaps = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"$defs":             true,
+	"dependentSchemas":  true,
+}
+
+// records every resource, anchor and the base of every subschema within v
+func (c *Compiler) index(doc string, ptr pointer.Pointer, v interface{}, b base) error {
+	switch actual := v.(type) {
+	case map[string]interface{}:
+		if id, ok := actual["$id"].(string); ok {
+			uri, err := resolveURI(b.uri, id)
+			if err != nil {
+				return err
+			}
+			b = base{uri: uri, ptr: pointer.Pointer{}}
+			c.resources[uri] = location{doc: doc, ptr: ptr}
+		}
+		for _, kw := range []string{"$anchor", "$dynamicAnchor"} {
+			if anchor, ok := actual[kw].(string); ok {
+				c.anchors[b.uri+"#"+anchor] = location{doc: doc, ptr: ptr}
+			}
+		}
+		if anchor, ok := actual["$dynamicAnchor"].(string); ok {
+			if c.dynamicAnchors[anchor] == nil {
+				c.dynamicAnchors[anchor] = map[string]location{}
+			}
+			c.dynamicAnchors[anchor][b.uri] = location{doc: doc, ptr: ptr}
+		}
+		c.bases[location{doc: doc, ptr: ptr}.String()] = b
+
+		for k, child := range actual {
+			if dataKeywords[k] {
+				continue
+			}
+			index := c.index
+			if schemaMaps[k] {
+				index = c.indexSchemas
+			}
+			if err := index(doc, ptr.Append(k), child, base{uri: b.uri, ptr: b.ptr.Append(k)}); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range actual {
+			if err := c.index(doc, ptr.AppendIndex(i), child, base{uri: b.uri, ptr: b.ptr.AppendIndex(i)}); err != nil {
+				return err
+			}
+		}
+	case bool:
+		c.bases[location{doc: doc, ptr: ptr}.String()] = b
+	}
+	return nil
+}
+
+// indexes the schemas of a keyword that maps names to them, e.g.: properties
+func (c *Compiler) indexSchemas(doc string, ptr pointer.Pointer, v interface{}, b base) error {
+	schemas, ok := v.(map[string]interface{})
+	if !ok {
+		return c.index(doc, ptr, v, b)
+	}
+	for name, child := range schemas {
+		if err := c.index(doc, ptr.Append(name), child, base{uri: b.uri, ptr: b.ptr.Append(name)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve finds the location uri refers to
+// the fragment of uri is either a JSON Pointer or the name of an anchor
+func (c *Compiler) resolve(uri string) (location, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return location{}, fmt.Errorf("Invalid URI %q: %v", uri, err)
+	}
+	fragment := u.Fragment
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	resource, ok := c.resources[u.String()]
+	if !ok {
+		return location{}, fmt.Errorf("Unknown schema resource %q", u.String())
+	}
+	if fragment == "" {
+		return resource, nil
+	}
+	if fragment[0] != '/' {
+		anchor, ok := c.anchors[u.String()+"#"+fragment]
+		if !ok {
+			return location{}, fmt.Errorf("Unknown anchor %q", uri)
+		}
+		return anchor, nil
+	}
+
+	ptr, err := pointer.Parse(fragment)
+	if err != nil {
+		return location{}, fmt.Errorf("Invalid fragment in %q: %v", uri, err)
+	}
+	full := make(pointer.Pointer, 0, len(resource.ptr)+len(ptr))
+	full = append(append(full, resource.ptr...), ptr...)
+	return location{doc: resource.doc, ptr: full}, nil
+}
+
+// resolves ref against the base URI, the result has no empty fragment
+func resolveURI(baseURI, ref string) (string, error) {
+	b, err := url.Parse(baseURI)
+	if err != nil {
+		return "", fmt.Errorf("Invalid base URI %q: %v", baseURI, err)
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("Invalid URI reference %q: %v", ref, err)
+	}
+	return strings.TrimSuffix(b.ResolveReference(r).String(), "#"), nil
+}
+
+func normalizeURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("Invalid URI %q: %v", uri, err)
+	}
+	if u.Fragment != "" {
+		return "", fmt.Errorf("Resource URI %q must not have a fragment", uri)
+	}
+	return strings.TrimSuffix(u.String(), "#"), nil
+}
+
+// node is a compiled schema
+type node struct {
+	// absolute location of the schema, used for absoluteKeywordLocation
+	base base
+	// set for the boolean schemas true and false
+	always *bool
+
+	ref        *node
+	dynamicRef *node
+	// the schemas with the $dynamicAnchor that dynamicRef refers to, by the URI
+	// of their resource, the one of the outermost resource in the dynamic scope
+	// is used in place of dynamicRef, nil if dynamicRef is resolved as $ref is
+	dynamicTargets map[string]*node
+
+	types    []string
+	enum     []interface{}
+	hasEnum  bool
+	constant interface{}
+	hasConst bool
+
+	multipleOf       *float64
+	maximum          *float64
+	exclusiveMaximum *float64
+	minimum          *float64
+	exclusiveMinimum *float64
+
+	maxLength *int
+	minLength *int
+	pattern   *regexp.Regexp
+	format    string
+
+	maxItems    *int
+	minItems    *int
+	uniqueItems bool
+	maxContains *int
+	minContains *int
+
+	maxProperties     *int
+	minProperties     *int
+	required          []string
+	dependentRequired map[string][]string
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	ifNode   *node
+	thenNode *node
+	elseNode *node
+
+	dependentSchemas map[string]*node
+
+	prefixItems []*node
+	items       *node
+	contains    *node
+
+	properties           map[string]*node
+	patternProperties    []patternNode
+	additionalProperties *node
+	propertyNames        *node
+
+	unevaluatedItems      *node
+	unevaluatedProperties *node
+}
+
+type patternNode struct {
+	src string
+	re  *regexp.Regexp
+	n   *node
+}
+
+func (n *node) location(keywords ...string) string {
+	ptr := n.base.ptr
+	for _, kw := range keywords {
+		ptr = ptr.Append(kw)
+	}
+	return n.base.uri + "#" + ptr.String()
+}
+
+// compile compiles the schema at loc, reusing it if it already was
+func (c *Compiler) compile(loc location) (*node, error) {
+	key := loc.String()
+	if n, ok := c.nodes[key]; ok {
+		return n, nil
+	}
+
+	v, err := pointer.Get(c.docs[loc.doc], loc.ptr)
+	if err != nil {
+		return nil, fmt.Errorf("Schema %q not found: %v", key, err)
+	}
+	b, ok := c.bases[key]
+	if !ok {
+		return nil, fmt.Errorf("%q is not a schema", key)
+	}
+
+	n := &node{base: b}
+	// registered before compiling the keywords so that recursive references find it
+	c.nodes[key] = n
+
+	switch actual := v.(type) {
+	case bool:
+		n.always = &actual
+		return n, nil
+	case map[string]interface{}:
+		sc := schemaCompiler{c: c, loc: loc, obj: actual, n: n}
+		if err := sc.compileKeywords(); err != nil {
+			delete(c.nodes, key)
+			return nil, err
+		}
+		return n, nil
+	}
+	delete(c.nodes, key)
+	return nil, fmt.Errorf("Schema at %q must be an object or a boolean, got: %T", key, v)
+}
+
+// schemaCompiler compiles the keywords of a single schema object
+type schemaCompiler struct {
+	c   *Compiler
+	loc location
+	obj map[string]interface{}
+	n   *node
+}
+
+func (sc schemaCompiler) errorf(kw string, format string, args ...interface{}) error {
+	return fmt.Errorf("%s/%s: %s", sc.loc.String(), kw, fmt.Sprintf(format, args...))
+}
+
+// compiles the subschema at the given path below this schema
+func (sc schemaCompiler) sub(path ...string) (*node, error) {
+	ptr := sc.loc.ptr
+	for _, t := range path {
+		ptr = ptr.Append(t)
+	}
+	return sc.c.compile(location{doc: sc.loc.doc, ptr: ptr})
+}
+
+func (sc schemaCompiler) compileKeywords() error {
+	compilers := []func() error{
+		sc.compileRefs,
+		sc.compileAssertions,
+		sc.compileInPlaceApplicators,
+		sc.compileArrayApplicators,
+		sc.compileObjectApplicators,
+	}
+	for _, f := range compilers {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// $dynamicRef is resolved as $ref is, unless it refers to a $dynamicAnchor
+// by name, then every schema with a $dynamicAnchor of that name is compiled
+// for the validator to pick from by the dynamic scope
+func (sc schemaCompiler) compileRefs() error {
+	for _, kw := range []string{"$ref", "$dynamicRef"} {
+		v, ok := sc.obj[kw]
+		if !ok {
+			continue
+		}
+		ref, ok := v.(string)
+		if !ok {
+			return sc.errorf(kw, "must be a string, got: %T", v)
+		}
+		uri, err := resolveURI(sc.n.base.uri, ref)
+		if err != nil {
+			return sc.errorf(kw, "%v", err)
+		}
+		loc, err := sc.c.resolve(uri)
+		if err != nil {
+			return sc.errorf(kw, "%v", err)
+		}
+		target, err := sc.c.compile(loc)
+		if err != nil {
+			return err
+		}
+		if kw == "$ref" {
+			sc.n.ref = target
+			continue
+		}
+		sc.n.dynamicRef = target
+		if err := sc.compileDynamicTargets(uri, loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compiles the schemas the $dynamicRef to uri, which resolves to loc,
+// may refer to in the dynamic scope
+func (sc schemaCompiler) compileDynamicTargets(uri string, loc location) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return sc.errorf("$dynamicRef", "%v", err)
+	}
+	name := u.Fragment
+	if name == "" || name[0] == '/' {
+		return nil
+	}
+	// the schema first referred to must have the $dynamicAnchor itself
+	if target, _ := pointer.Get(sc.c.docs[loc.doc], loc.ptr); !hasDynamicAnchor(target, name) {
+		return nil
+	}
+
+	sc.n.dynamicTargets = map[string]*node{}
+	for resource, anchorLoc := range sc.c.dynamicAnchors[name] {
+		n, err := sc.c.compile(anchorLoc)
+		if err != nil {
+			return err
+		}
+		sc.n.dynamicTargets[resource] = n
+	}
+	return nil
+}
+
+func hasDynamicAnchor(schema interface{}, name string) bool {
+	obj, ok := schema.(map[string]interface{})
+	return ok && obj["$dynamicAnchor"] == name
+}
+
+func (sc schemaCompiler) compileAssertions() error {
+	var err error
+	n := sc.n
+
+	if v, ok := sc.obj["type"]; ok {
+		if n.types, err = sc.typeList(v); err != nil {
+			return err
+		}
+	}
+	if v, ok := sc.obj["enum"]; ok {
+		arr, isArr := v.([]interface{})
+		if !isArr {
+			return sc.errorf("enum", "must be an array, got: %T", v)
+		}
+		n.enum, n.hasEnum = arr, true
+	}
+	n.constant, n.hasConst = sc.obj["const"]
+
+	numbers := map[string]**float64{
+		"multipleOf":       &n.multipleOf,
+		"maximum":          &n.maximum,
+		"exclusiveMaximum": &n.exclusiveMaximum,
+		"minimum":          &n.minimum,
+		"exclusiveMinimum": &n.exclusiveMinimum,
+	}
+	for kw, dst := range numbers {
+		if *dst, err = sc.number(kw); err != nil {
+			return err
+		}
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return sc.errorf("multipleOf", "must be greater than 0")
+	}
+
+	counts := map[string]**int{
+		"maxLength":     &n.maxLength,
+		"minLength":     &n.minLength,
+		"maxItems":      &n.maxItems,
+		"minItems":      &n.minItems,
+		"maxContains":   &n.maxContains,
+		"minContains":   &n.minContains,
+		"maxProperties": &n.maxProperties,
+		"minProperties": &n.minProperties,
+	}
+	for kw, dst := range counts {
+		if *dst, err = sc.count(kw); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := sc.obj["pattern"]; ok {
+		str, isStr := v.(string)
+		if !isStr {
+			return sc.errorf("pattern", "must be a string, got: %T", v)
+		}
+		if n.pattern, err = regexp.Compile(str); err != nil {
+			return sc.errorf("pattern", "%v", err)
+		}
+	}
+	if v, ok := sc.obj["format"]; ok {
+		str, isStr := v.(string)
+		if !isStr {
+			return sc.errorf("format", "must be a string, got: %T", v)
+		}
+		n.format = str
+	}
+	if v, ok := sc.obj["uniqueItems"]; ok {
+		b, isBool := v.(bool)
+		if !isBool {
+			return sc.errorf("uniqueItems", "must be a boolean, got: %T", v)
+		}
+		n.uniqueItems = b
+	}
+	if v, ok := sc.obj["required"]; ok {
+		if n.required, err = sc.stringList("required", v); err != nil {
+			return err
+		}
+	}
+	if v, ok := sc.obj["dependentRequired"]; ok {
+		obj, isObj := v.(map[string]interface{})
+		if !isObj {
+			return sc.errorf("dependentRequired", "must be an object, got: %T", v)
+		}
+		n.dependentRequired = make(map[string][]string, len(obj))
+		for prop, deps := range obj {
+			if n.dependentRequired[prop], err = sc.stringList("dependentRequired/"+prop, deps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var knownTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"number":  true,
+	"string":  true,
+	"integer": true,
+}
+
+func (sc schemaCompiler) typeList(v interface{}) ([]string, error) {
+	var types []string
+	switch actual := v.(type) {
+	case string:
+		types = []string{actual}
+	case []interface{}:
+		var err error
+		if types, err = sc.stringList("type", actual); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, sc.errorf("type", "must be a string or an array, got: %T", v)
+	}
+	for _, t := range types {
+		if !knownTypes[t] {
+			return nil, sc.errorf("type", "unknown type %q", t)
+		}
+	}
+	return types, nil
+}
+
+func (sc schemaCompiler) stringList(kw string, v interface{}) ([]string, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, sc.errorf(kw, "must be an array, got: %T", v)
+	}
+	out := make([]string, 0, len(arr))
+	for _, elem := range arr {
+		str, ok := elem.(string)
+		if !ok {
+			return nil, sc.errorf(kw, "must only contain strings, got: %T", elem)
+		}
+		out = append(out, str)
+	}
+	return out, nil
+}
+
+func (sc schemaCompiler) number(kw string) (*float64, error) {
+	v, ok := sc.obj[kw]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		return nil, sc.errorf(kw, "must be a number, got: %T", v)
+	}
+	return &f, nil
+}
+
+func (sc schemaCompiler) count(kw string) (*int, error) {
+	v, ok := sc.obj[kw]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := toFloat(v)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return nil, sc.errorf(kw, "must be a non-negative integer, got: %v", v)
+	}
+	i := int(f)
+	return &i, nil
+}
+
+func (sc schemaCompiler) subList(kw string) ([]*node, error) {
+	v, ok := sc.obj[kw]
+	if !ok {
+		return nil, nil
+	}
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil, sc.errorf(kw, "must be a non-empty array")
+	}
+	out := make([]*node, 0, len(arr))
+	for i := range arr {
+		n, err := sc.sub(kw, fmt.Sprint(i))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func (sc schemaCompiler) optionalSub(kw string) (*node, error) {
+	if _, ok := sc.obj[kw]; !ok {
+		return nil, nil
+	}
+	return sc.sub(kw)
+}
+
+// compiles the subschemas of every member of the object at kw
+func (sc schemaCompiler) subMap(kw string) (map[string]*node, error) {
+	v, ok := sc.obj[kw]
+	if !ok {
+		return nil, nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, sc.errorf(kw, "must be an object, got: %T", v)
+	}
+	out := make(map[string]*node, len(obj))
+	for k := range obj {
+		n, err := sc.sub(kw, k)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = n
+	}
+	return out, nil
+}
+
+func (sc schemaCompiler) compileInPlaceApplicators() error {
+	var err error
+	n := sc.n
+	if n.allOf, err = sc.subList("allOf"); err != nil {
+		return err
+	}
+	if n.anyOf, err = sc.subList("anyOf"); err != nil {
+		return err
+	}
+	if n.oneOf, err = sc.subList("oneOf"); err != nil {
+		return err
+	}
+	subs := map[string]**node{
+		"not":  &n.not,
+		"if":   &n.ifNode,
+		"then": &n.thenNode,
+		"else": &n.elseNode,
+	}
+	for kw, dst := range subs {
+		if *dst, err = sc.optionalSub(kw); err != nil {
+			return err
+		}
+	}
+	n.dependentSchemas, err = sc.subMap("dependentSchemas")
+	return err
+}
+
+func (sc schemaCompiler) compileArrayApplicators() error {
+	var err error
+	n := sc.n
+	if n.prefixItems, err = sc.subList("prefixItems"); err != nil {
+		return err
+	}
+	subs := map[string]**node{
+		"items":            &n.items,
+		"contains":         &n.contains,
+		"unevaluatedItems": &n.unevaluatedItems,
+	}
+	for kw, dst := range subs {
+		if *dst, err = sc.optionalSub(kw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc schemaCompiler) compileObjectApplicators() error {
+	var err error
+	n := sc.n
+	if n.properties, err = sc.subMap("properties"); err != nil {
+		return err
+	}
+
+	patterns, err := sc.subMap("patternProperties")
+	if err != nil {
+		return err
+	}
+	for src, sub := range patterns {
+		re, err := regexp.Compile(src)
+		if err != nil {
+			return sc.errorf("patternProperties", "%v", err)
+		}
+		n.patternProperties = append(n.patternProperties, patternNode{src: src, re: re, n: sub})
+	}
+	sort.Slice(n.patternProperties, func(i, j int) bool {
+		return n.patternProperties[i].src < n.patternProperties[j].src
+	})
+
+	subs := map[string]**node{
+		"additionalProperties":  &n.additionalProperties,
+		"propertyNames":         &n.propertyNames,
+		"unevaluatedProperties": &n.unevaluatedProperties,
+	}
+	for kw, dst := range subs {
+		if *dst, err = sc.optionalSub(kw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,551 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+func mustParse(t *testing.T, str string) interface{} {
+	v, err := parse.Parse(strings.NewReader(str))
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", str, err)
+	}
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		schema   string
+		instance string
+		valid    bool
+	}{
+		{schema: `true`, instance: `{"a": 1}`, valid: true},
+		{schema: `false`, instance: `null`, valid: false},
+		{schema: `{}`, instance: `[1, "a"]`, valid: true},
+
+		{schema: `{"type": "string"}`, instance: `"a"`, valid: true},
+		{schema: `{"type": "string"}`, instance: `1`, valid: false},
+		{schema: `{"type": "integer"}`, instance: `1.0`, valid: true},
+		{schema: `{"type": "integer"}`, instance: `1.5`, valid: false},
+		{schema: `{"type": "number"}`, instance: `1`, valid: true},
+		{schema: `{"type": ["null", "boolean"]}`, instance: `false`, valid: true},
+		{schema: `{"type": ["null", "boolean"]}`, instance: `{}`, valid: false},
+
+		{schema: `{"enum": [1, "a", {"b": null}]}`, instance: `{"b": null}`, valid: true},
+		{schema: `{"enum": [1, "a", {"b": null}]}`, instance: `1.0`, valid: true},
+		{schema: `{"enum": [1, "a", {"b": null}]}`, instance: `"b"`, valid: false},
+		{schema: `{"const": [1, 2]}`, instance: `[1, 2]`, valid: true},
+		{schema: `{"const": [1, 2]}`, instance: `[2, 1]`, valid: false},
+
+		{schema: `{"multipleOf": 0.01}`, instance: `0.07`, valid: true},
+		{schema: `{"multipleOf": 0.1}`, instance: `0.35`, valid: false},
+		{schema: `{"multipleOf": 3}`, instance: `9`, valid: true},
+		{schema: `{"multipleOf": 3}`, instance: `10`, valid: false},
+		{schema: `{"maximum": 3}`, instance: `3`, valid: true},
+		{schema: `{"maximum": 3}`, instance: `3.5`, valid: false},
+		{schema: `{"exclusiveMaximum": 3}`, instance: `3`, valid: false},
+		{schema: `{"minimum": -1}`, instance: `-1`, valid: true},
+		{schema: `{"minimum": -1}`, instance: `-2`, valid: false},
+		{schema: `{"exclusiveMinimum": -1}`, instance: `-1`, valid: false},
+		{schema: `{"minimum": 5}`, instance: `"not a number"`, valid: true},
+
+		{schema: `{"maxLength": 2}`, instance: `"éé"`, valid: true},
+		{schema: `{"maxLength": 2}`, instance: `"abc"`, valid: false},
+		{schema: `{"minLength": 2}`, instance: `"a"`, valid: false},
+		{schema: `{"pattern": "^a+$"}`, instance: `"aaa"`, valid: true},
+		{schema: `{"pattern": "^a+$"}`, instance: `"aab"`, valid: false},
+
+		{schema: `{"maxItems": 1}`, instance: `[1, 2]`, valid: false},
+		{schema: `{"minItems": 1}`, instance: `[]`, valid: false},
+		{schema: `{"uniqueItems": true}`, instance: `[1, {"a": [1]}, {"a": [2]}]`, valid: true},
+		{schema: `{"uniqueItems": true}`, instance: `[1, {"a": [1]}, {"a": [1.0]}]`, valid: false},
+		{schema: `{"uniqueItems": false}`, instance: `[1, 1]`, valid: true},
+
+		{schema: `{"maxProperties": 1}`, instance: `{"a": 1, "b": 2}`, valid: false},
+		{schema: `{"minProperties": 1}`, instance: `{}`, valid: false},
+		{schema: `{"required": ["a", "b"]}`, instance: `{"a": 1, "b": null}`, valid: true},
+		{schema: `{"required": ["a", "b"]}`, instance: `{"a": 1}`, valid: false},
+		{schema: `{"required": ["a"]}`, instance: `[]`, valid: true},
+		{schema: `{"dependentRequired": {"a": ["b"]}}`, instance: `{"c": 1}`, valid: true},
+		{schema: `{"dependentRequired": {"a": ["b"]}}`, instance: `{"a": 1}`, valid: false},
+
+		{schema: `{"allOf": [{"minimum": 1}, {"maximum": 3}]}`, instance: `2`, valid: true},
+		{schema: `{"allOf": [{"minimum": 1}, {"maximum": 3}]}`, instance: `4`, valid: false},
+		{schema: `{"anyOf": [{"type": "string"}, {"minimum": 3}]}`, instance: `4`, valid: true},
+		{schema: `{"anyOf": [{"type": "string"}, {"minimum": 3}]}`, instance: `2`, valid: false},
+		{schema: `{"oneOf": [{"type": "integer"}, {"minimum": 3}]}`, instance: `2`, valid: true},
+		{schema: `{"oneOf": [{"type": "integer"}, {"minimum": 3}]}`, instance: `4`, valid: false},
+		{schema: `{"oneOf": [{"type": "integer"}, {"minimum": 3}]}`, instance: `2.5`, valid: false},
+		{schema: `{"not": {"type": "null"}}`, instance: `null`, valid: false},
+		{schema: `{"not": {"type": "null"}}`, instance: `0`, valid: true},
+
+		{schema: `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, instance: `20`, valid: true},
+		{schema: `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, instance: `15`, valid: false},
+		{schema: `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, instance: `4`, valid: true},
+		{schema: `{"if": {"minimum": 10}, "then": {"multipleOf": 10}, "else": {"maximum": 5}}`, instance: `7`, valid: false},
+		{schema: `{"then": {"maximum": 5}}`, instance: `7`, valid: true},
+		{schema: `{"dependentSchemas": {"a": {"required": ["b"]}}}`, instance: `{"a": 1}`, valid: false},
+		{schema: `{"dependentSchemas": {"a": {"required": ["b"]}}}`, instance: `{"a": 1, "b": 2}`, valid: true},
+
+		{schema: `{"prefixItems": [{"type": "string"}, {"type": "integer"}]}`, instance: `["a", 1, null]`, valid: true},
+		{schema: `{"prefixItems": [{"type": "string"}, {"type": "integer"}]}`, instance: `[1, "a"]`, valid: false},
+		{schema: `{"prefixItems": [{"type": "string"}], "items": false}`, instance: `["a", 1]`, valid: false},
+		{schema: `{"prefixItems": [{"type": "string"}], "items": false}`, instance: `["a"]`, valid: true},
+		{schema: `{"items": {"type": "integer"}}`, instance: `[1, 2, 3]`, valid: true},
+		{schema: `{"items": {"type": "integer"}}`, instance: `[1, "2", 3]`, valid: false},
+		{schema: `{"contains": {"type": "null"}}`, instance: `[1, null]`, valid: true},
+		{schema: `{"contains": {"type": "null"}}`, instance: `[1, 2]`, valid: false},
+		{schema: `{"contains": {"type": "null"}, "minContains": 2}`, instance: `[null, 1]`, valid: false},
+		{schema: `{"contains": {"type": "null"}, "maxContains": 1}`, instance: `[null, null]`, valid: false},
+		{schema: `{"contains": {"type": "null"}, "minContains": 0}`, instance: `[]`, valid: true},
+
+		{schema: `{"properties": {"a": {"type": "string"}}}`, instance: `{"a": "x", "b": 1}`, valid: true},
+		{schema: `{"properties": {"a": {"type": "string"}}}`, instance: `{"a": 1}`, valid: false},
+		{schema: `{"patternProperties": {"^x-": {"type": "integer"}}}`, instance: `{"x-a": 1, "y": "b"}`, valid: true},
+		{schema: `{"patternProperties": {"^x-": {"type": "integer"}}}`, instance: `{"x-a": "1"}`, valid: false},
+		{
+			schema:   `{"properties": {"a": {}}, "patternProperties": {"^x-": {}}, "additionalProperties": false}`,
+			instance: `{"a": 1, "x-b": 2}`,
+			valid:    true,
+		},
+		{
+			schema:   `{"properties": {"a": {}}, "patternProperties": {"^x-": {}}, "additionalProperties": false}`,
+			instance: `{"a": 1, "c": 2}`,
+			valid:    false,
+		},
+		{schema: `{"propertyNames": {"maxLength": 2}}`, instance: `{"ab": 1}`, valid: true},
+		{schema: `{"propertyNames": {"maxLength": 2}}`, instance: `{"abc": 1}`, valid: false},
+
+		{
+			schema:   `{"allOf": [{"properties": {"a": {}}}], "unevaluatedProperties": false}`,
+			instance: `{"a": 1}`,
+			valid:    true,
+		},
+		{
+			schema:   `{"allOf": [{"properties": {"a": {}}}], "unevaluatedProperties": false}`,
+			instance: `{"a": 1, "b": 2}`,
+			valid:    false,
+		},
+		{
+			schema:   `{"anyOf": [{"properties": {"a": {"type": "string"}}}, {"properties": {"b": {}}}], "unevaluatedProperties": false}`,
+			instance: `{"a": 1, "b": 2}`,
+			valid:    false,
+		},
+		{
+			schema:   `{"prefixItems": [{}], "unevaluatedItems": {"type": "string"}}`,
+			instance: `[1, "a", "b"]`,
+			valid:    true,
+		},
+		{
+			schema:   `{"prefixItems": [{}], "unevaluatedItems": {"type": "string"}}`,
+			instance: `[1, 2]`,
+			valid:    false,
+		},
+		{
+			schema:   `{"contains": {"type": "string"}, "unevaluatedItems": false}`,
+			instance: `["a", "b"]`,
+			valid:    true,
+		},
+
+		{schema: `{"$defs": {"pos": {"minimum": 0}}, "$ref": "#/$defs/pos"}`, instance: `1`, valid: true},
+		{schema: `{"$defs": {"pos": {"minimum": 0}}, "$ref": "#/$defs/pos"}`, instance: `-1`, valid: false},
+		{schema: `{"$defs": {"pos": {"$anchor": "positive", "minimum": 0}}, "$ref": "#positive"}`, instance: `-1`, valid: false},
+		{
+			schema:   `{"$ref": "#/$defs/a~1b", "$defs": {"a/b": {"type": "string"}}}`,
+			instance: `"x"`,
+			valid:    true,
+		},
+		{
+			schema:   `{"type": "object", "properties": {"child": {"$ref": "#"}}, "additionalProperties": false}`,
+			instance: `{"child": {"child": {}}}`,
+			valid:    true,
+		},
+		{
+			schema:   `{"type": "object", "properties": {"child": {"$ref": "#"}}, "additionalProperties": false}`,
+			instance: `{"child": {"child": {"other": 1}}}`,
+			valid:    false,
+		},
+		{
+			schema: `{
+				"$id": "https://example.com/root.json",
+				"$ref": "item.json",
+				"$defs": {"item": {"$id": "item.json", "type": "array", "items": {"$ref": "#/$defs/leaf"}, "$defs": {"leaf": {"type": "integer"}}}}
+			}`,
+			instance: `[1, 2]`,
+			valid:    true,
+		},
+		{
+			schema: `{
+				"$id": "https://example.com/root.json",
+				"$ref": "item.json",
+				"$defs": {"item": {"$id": "item.json", "type": "array", "items": {"$ref": "#/$defs/leaf"}, "$defs": {"leaf": {"type": "integer"}}}}
+			}`,
+			instance: `[1, "2"]`,
+			valid:    false,
+		},
+		{schema: `{"format": "email"}`, instance: `"not an email"`, valid: true},
+		// properties and definitions may be named like keywords whose values are not schemas
+		{
+			schema:   `{"properties": {"default": {"type": "string"}, "enum": {"const": 1}}, "$defs": {"const": {"type": "null"}}}`,
+			instance: `{"default": "a", "enum": 1}`,
+			valid:    true,
+		},
+		{
+			schema:   `{"properties": {"default": {"type": "string"}, "examples": {"$ref": "#/$defs/const"}}, "$defs": {"const": {"type": "null"}}}`,
+			instance: `{"default": "a", "examples": 1}`,
+			valid:    false,
+		},
+	}
+
+	for _, test := range tests {
+		s, err := Compile(strings.NewReader(test.schema))
+		if err != nil {
+			t.Errorf("schema: %s, Compile(): %v", test.schema, err)
+			continue
+		}
+
+		err = s.Validate(mustParse(t, test.instance))
+
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("schema: %s, instance: %s, valid: %v, want valid: %v, err: %v",
+				test.schema, test.instance, valid, test.valid, err)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	schemas := []string{
+		`1`,
+		`{"type": "potato"}`,
+		`{"type": 1}`,
+		`{"minimum": "1"}`,
+		`{"maxLength": -1}`,
+		`{"maxItems": 1.5}`,
+		`{"multipleOf": 0}`,
+		`{"allOf": []}`,
+		`{"pattern": "("}`,
+		`{"required": [1]}`,
+		`{"properties": {"a": 1}}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "#missing"}`,
+		`{"$ref": "https://example.com/unknown.json"}`,
+		`{"enum": 1}`,
+	}
+
+	for _, str := range schemas {
+		if _, err := Compile(strings.NewReader(str)); err == nil {
+			t.Errorf("schema: %s, expected compile error", str)
+		}
+	}
+}
+
+func TestRefCycles(t *testing.T) {
+	tests := []struct {
+		schema   string
+		instance string
+		err      string // empty if the instance is valid
+	}{
+		{
+			schema:   `{"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`,
+			instance: `1`,
+			err:      `Schema mem:///schema.json#/$defs/a refers to itself at instance location "", which would never end`,
+		},
+		{
+			schema:   `{"allOf": [{"$ref": "#"}]}`,
+			instance: `{"a": 1}`,
+			err:      `Schema mem:///schema.json# refers to itself at instance location "", which would never end`,
+		},
+		{
+			// recursion that moves on to another part of the instance ends
+			schema:   `{"type": "array", "items": {"$ref": "#"}}`,
+			instance: `[[], [[]]]`,
+		},
+	}
+
+	for _, test := range tests {
+		s, err := Compile(strings.NewReader(test.schema))
+		if err != nil {
+			t.Errorf("schema: %s, Compile(): %v", test.schema, err)
+			continue
+		}
+		err = s.Validate(mustParse(t, test.instance))
+		if got := fmt.Sprint(err); test.err == "" && err != nil || test.err != "" && got != test.err {
+			t.Errorf("schema: %s, got: %v, want: %s", test.schema, err, test.err)
+		}
+		if test.err != "" && s.Basic(mustParse(t, test.instance)).Valid {
+			t.Errorf("schema: %s, Basic() is valid", test.schema)
+		}
+	}
+}
+
+func TestDynamicRef(t *testing.T) {
+	c := NewCompiler()
+	resources := map[string]string{
+		"https://example.com/tree": `{
+			"$dynamicAnchor": "node",
+			"type": "object",
+			"properties": {
+				"data": true,
+				"children": {"type": "array", "items": {"$dynamicRef": "#node"}}
+			}
+		}`,
+		"https://example.com/strict-tree": `{
+			"$dynamicAnchor": "node",
+			"$ref": "tree",
+			"unevaluatedProperties": false
+		}`,
+		// without a $dynamicAnchor where it points, $dynamicRef is a $ref
+		"https://example.com/list": `{
+			"$defs": {"item": {"$anchor": "item", "type": "number"}},
+			"type": "array",
+			"items": {"$dynamicRef": "#item"}
+		}`,
+	}
+	for uri, doc := range resources {
+		if err := c.AddResource(uri, strings.NewReader(doc)); err != nil {
+			t.Fatalf("AddResource(%q): %v", uri, err)
+		}
+	}
+
+	tests := []struct {
+		uri      string
+		instance string
+		valid    bool
+	}{
+		{uri: "https://example.com/tree", instance: `{"children": [{"daat": 1}]}`, valid: true},
+		{uri: "https://example.com/strict-tree", instance: `{"children": [{"data": 1}]}`, valid: true},
+		// the child is checked against strict-tree, the outermost "node"
+		{uri: "https://example.com/strict-tree", instance: `{"children": [{"daat": 1}]}`, valid: false},
+		{uri: "https://example.com/strict-tree", instance: `{"children": [{"children": [{"daat": 1}]}]}`, valid: false},
+		{uri: "https://example.com/list", instance: `[1, 2]`, valid: true},
+		{uri: "https://example.com/list", instance: `[1, "2"]`, valid: false},
+	}
+
+	for _, test := range tests {
+		s, err := c.Compile(test.uri)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", test.uri, err)
+		}
+		if err := s.Validate(mustParse(t, test.instance)); (err == nil) != test.valid {
+			t.Errorf("schema: %s, instance: %s, got: %v, want valid: %t", test.uri, test.instance, err, test.valid)
+		}
+	}
+}
+
+func TestCompilerResources(t *testing.T) {
+	c := NewCompiler()
+	resources := map[string]string{
+		"https://example.com/schemas/address.json": `{
+			"type": "object",
+			"properties": {"zip": {"$ref": "defs.json#/$defs/zip"}},
+			"required": ["zip"]
+		}`,
+		"https://example.com/schemas/defs.json": `{
+			"$defs": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"}}
+		}`,
+		"https://example.com/schemas/person.json": `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"home": {"$ref": "address.json"}
+			}
+		}`,
+	}
+	for uri, doc := range resources {
+		if err := c.AddResource(uri, strings.NewReader(doc)); err != nil {
+			t.Fatalf("AddResource(%q): %v", uri, err)
+		}
+	}
+	if err := c.AddResource("https://example.com/schemas/defs.json", strings.NewReader(`{}`)); err == nil {
+		t.Errorf("expected error adding a resource twice")
+	}
+
+	s, err := c.Compile("https://example.com/schemas/person.json")
+	if err != nil {
+		t.Fatalf("Compile(): %v", err)
+	}
+
+	if err := s.Validate(mustParse(t, `{"name": "a", "home": {"zip": "12345"}}`)); err != nil {
+		t.Errorf("expected valid instance, got: %v", err)
+	}
+
+	out := s.Basic(mustParse(t, `{"name": "a", "home": {"zip": "1234"}}`))
+	if out.Valid {
+		t.Fatalf("expected invalid instance")
+	}
+	last := out.Errors[len(out.Errors)-1]
+	want := OutputUnit{
+		KeywordLocation:         "/properties/home/$ref/properties/zip/$ref/pattern",
+		AbsoluteKeywordLocation: "https://example.com/schemas/defs.json#/$defs/zip/pattern",
+		InstanceLocation:        "/home/zip",
+		Error:                   `"1234" does not match the pattern "^[0-9]{5}$"`,
+	}
+	if !unitsEqual(last, want) {
+		t.Errorf("got: %+v, want: %+v", last, want)
+	}
+
+	sub, err := c.Compile("https://example.com/schemas/defs.json#/$defs/zip")
+	if err != nil {
+		t.Fatalf("Compile(): %v", err)
+	}
+	if err := sub.Validate("abc"); err == nil {
+		t.Errorf("expected invalid zip")
+	}
+}
+
+func TestAssertFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		value  string
+		valid  bool
+	}{
+		{format: "date-time", value: "2018-11-13T20:20:39+00:00", valid: true},
+		{format: "date-time", value: "1963-06-19t08:30:06.283185z", valid: true},
+		{format: "date-time", value: "2018-11-13 20:20:39", valid: false},
+		{format: "date", value: "1963-06-19", valid: true},
+		{format: "date", value: "1963-13-19", valid: false},
+		{format: "time", value: "08:30:06Z", valid: true},
+		{format: "time", value: "08:30:06", valid: false},
+		{format: "duration", value: "P4DT12H30M5S", valid: true},
+		{format: "duration", value: "PT", valid: false},
+		{format: "email", value: "joe.bloggs@example.com", valid: true},
+		{format: "email", value: "2962", valid: false},
+		{format: "hostname", value: "www.example.com", valid: true},
+		{format: "hostname", value: "-a.example.com", valid: false},
+		{format: "ipv4", value: "192.168.0.1", valid: true},
+		{format: "ipv4", value: "192.168.0.01", valid: false},
+		{format: "ipv6", value: "::1", valid: true},
+		{format: "ipv6", value: "12345::", valid: false},
+		{format: "uri", value: "http://example.com/a?b#c", valid: true},
+		{format: "uri", value: "/relative", valid: false},
+		{format: "uuid", value: "2eb8aa08-aa98-11ea-b4aa-73b441d16380", valid: true},
+		{format: "uuid", value: "2eb8aa08-aa98-11ea-b4aa-73b441d1638", valid: false},
+		{format: "regex", value: "^a(b)?$", valid: true},
+		{format: "regex", value: "^a(b$", valid: false},
+		{format: "json-pointer", value: "/a~1b", valid: true},
+		{format: "json-pointer", value: "a", valid: false},
+		{format: "unknown-format", value: "anything", valid: true},
+	}
+
+	for _, test := range tests {
+		c := NewCompiler()
+		c.AssertFormat = true
+		doc := map[string]interface{}{"format": test.format}
+		if err := c.AddResourceValue("mem:///format.json", doc); err != nil {
+			t.Fatalf("AddResourceValue(): %v", err)
+		}
+		s, err := c.Compile("mem:///format.json")
+		if err != nil {
+			t.Fatalf("Compile(): %v", err)
+		}
+
+		err = s.Validate(test.value)
+
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("format: %s, value: %q, valid: %v, want valid: %v", test.format, test.value, valid, test.valid)
+		}
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	schema := `{
+		"$id": "https://example.com/polygon",
+		"$defs": {
+			"point": {
+				"type": "object",
+				"properties": {"x": {"type": "number"}, "y": {"type": "number"}},
+				"additionalProperties": false,
+				"required": ["x", "y"]
+			}
+		},
+		"type": "array",
+		"items": {"$ref": "#/$defs/point"},
+		"minItems": 3
+	}`
+	instance := `[{"x": 2.5, "y": 1.3}, {"x": 1, "z": 6.7}]`
+
+	s, err := Compile(strings.NewReader(schema))
+	if err != nil {
+		t.Fatalf("Compile(): %v", err)
+	}
+
+	basic := s.Basic(mustParse(t, instance))
+	wantBasic := []OutputUnit{
+		{
+			KeywordLocation:         "/minItems",
+			AbsoluteKeywordLocation: "https://example.com/polygon#/minItems",
+			InstanceLocation:        "",
+			Error:                   "2 items is fewer than the minimum of 3",
+		},
+		{
+			KeywordLocation:         "/items",
+			AbsoluteKeywordLocation: "https://example.com/polygon#/items",
+			InstanceLocation:        "",
+			Error:                   "1 of the items do not match the schema",
+		},
+		{
+			KeywordLocation:         "/items/$ref",
+			AbsoluteKeywordLocation: "https://example.com/polygon#/items/$ref",
+			InstanceLocation:        "/1",
+			Error:                   "the referenced schema is not satisfied",
+		},
+		{
+			KeywordLocation:         "/items/$ref/required",
+			AbsoluteKeywordLocation: "https://example.com/polygon#/$defs/point/required",
+			InstanceLocation:        "/1",
+			Error:                   `missing required properties: "y"`,
+		},
+		{
+			KeywordLocation:         "/items/$ref/additionalProperties",
+			AbsoluteKeywordLocation: "https://example.com/polygon#/$defs/point/additionalProperties",
+			InstanceLocation:        "/1",
+			Error:                   `additional properties "z" are not allowed`,
+		},
+		{
+			KeywordLocation:         "/items/$ref/additionalProperties",
+			AbsoluteKeywordLocation: "https://example.com/polygon#/$defs/point/additionalProperties",
+			InstanceLocation:        "/1/z",
+			Error:                   "the false schema allows no values",
+		},
+	}
+	if basic.Valid || len(basic.Errors) != len(wantBasic) {
+		t.Fatalf("basic got: %+v, want errors: %+v", basic, wantBasic)
+	}
+	for i, u := range basic.Errors {
+		if !unitsEqual(u, wantBasic[i]) {
+			t.Errorf("basic error %d got: %+v, want: %+v", i, u, wantBasic[i])
+		}
+	}
+
+	detailed := s.Detailed(mustParse(t, instance))
+	if detailed.Valid || detailed.KeywordLocation != "" || len(detailed.Errors) != 2 {
+		t.Fatalf("detailed got: %+v", detailed)
+	}
+	items := detailed.Errors[1]
+	if items.KeywordLocation != "/items" || len(items.Errors) != 1 {
+		t.Fatalf("detailed items got: %+v", items)
+	}
+	ref := items.Errors[0]
+	if ref.KeywordLocation != "/items/$ref" || ref.InstanceLocation != "/1" || len(ref.Errors) != 2 {
+		t.Errorf("detailed $ref got: %+v", ref)
+	}
+
+	if valid := s.Detailed(mustParse(t, `[{"x": 1, "y": 1}, {"x": 2, "y": 2}, {"x": 3, "y": 3}]`)); !valid.Valid || len(valid.Errors) != 0 {
+		t.Errorf("detailed valid got: %+v", valid)
+	}
+
+	value := basic.Value()
+	if value["valid"] != false || len(value["errors"].([]interface{})) != len(wantBasic) {
+		t.Errorf("Value() got: %v", value)
+	}
+}
+
+func unitsEqual(u1, u2 OutputUnit) bool {
+	return u1.KeywordLocation == u2.KeywordLocation &&
+		u1.AbsoluteKeywordLocation == u2.AbsoluteKeywordLocation &&
+		u1.InstanceLocation == u2.InstanceLocation &&
+		u1.Error == u2.Error
+}
@@ -0,0 +1,750 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vyevs/gojson/encode"
+	"github.com/vyevs/gojson/patch"
+	"github.com/vyevs/gojson/pointer"
+)
+
+// Schema is a compiled JSON Schema
+type Schema struct {
+	root         *node
+	assertFormat bool
+}
+
+// Validate validates instance against s and returns a *ValidationError
+// listing every failed assertion if it is invalid, nil otherwise
+// instance is expected to be made up of the values the parser produces
+// a schema that refers to itself without moving on to another part of the
+// instance, e.g. {"$ref": "#"}, makes Validate fail with a plain error
+func (s *Schema) Validate(instance interface{}) error {
+	u, err := s.evaluate(instance)
+	if err != nil {
+		return err
+	}
+	if u.valid {
+		return nil
+	}
+	return &ValidationError{Output: OutputUnit{Valid: false, Errors: u.flatten(nil)}}
+}
+
+// Basic validates instance and returns the result in the spec's "basic"
+// output format, a flat list of every failed keyword
+func (s *Schema) Basic(instance interface{}) OutputUnit {
+	u, _ := s.evaluate(instance)
+	out := OutputUnit{Valid: u.valid}
+	if !u.valid {
+		out.Errors = u.flatten(nil)
+	}
+	return out
+}
+
+// Detailed validates instance and returns the result in the spec's "detailed"
+// output format, failed keywords nested under the subschemas they belong to
+func (s *Schema) Detailed(instance interface{}) OutputUnit {
+	u, _ := s.evaluate(instance)
+	if u.valid {
+		return OutputUnit{Valid: true}
+	}
+	return u.detailed()
+}
+
+// the error returned is that of a $ref cycle, which also fails the unit it is found at
+func (s *Schema) evaluate(instance interface{}) (*unit, error) {
+	v := &validator{assertFormat: s.assertFormat, active: map[activeKey]bool{}}
+	u, _ := v.eval(s.root, instance, pointer.Pointer{}, pointer.Pointer{})
+	return u, v.err
+}
+
+// ValidationError is returned by Schema.Validate for invalid instances
+type ValidationError struct {
+	// Output is the result of the validation in the "basic" format
+	Output OutputUnit
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Output.Errors))
+	for _, u := range e.Output.Errors {
+		if u.Error == "" {
+			continue
+		}
+		msgs = append(msgs, fmt.Sprintf("%q: %s", u.InstanceLocation, u.Error))
+	}
+	return "Instance is invalid: " + strings.Join(msgs, "; ")
+}
+
+// unit is the result of evaluating a schema or one of its keywords
+type unit struct {
+	valid    bool
+	keyword  pointer.Pointer
+	absolute string
+	instance pointer.Pointer
+	err      string
+	children []*unit
+}
+
+// evaluated records which parts of an instance were successfully evaluated
+// by a schema, which unevaluatedItems and unevaluatedProperties depend on
+type evaluated struct {
+	props    map[string]bool
+	items    map[int]bool
+	allItems bool
+}
+
+func (e *evaluated) addProp(p string) {
+	if e.props == nil {
+		e.props = map[string]bool{}
+	}
+	e.props[p] = true
+}
+
+func (e *evaluated) addItem(i int) {
+	if e.items == nil {
+		e.items = map[int]bool{}
+	}
+	e.items[i] = true
+}
+
+func (e *evaluated) merge(other evaluated) {
+	for p := range other.props {
+		e.addProp(p)
+	}
+	for i := range other.items {
+		e.addItem(i)
+	}
+	e.allItems = e.allItems || other.allItems
+}
+
+type validator struct {
+	assertFormat bool
+
+	// the schemas being evaluated, with the instance locations they are evaluated at,
+	// a pair that is evaluated again within itself would recurse forever
+	active map[activeKey]bool
+	err    error // the first cycle found
+
+	// the URIs of the resources of the schemas being evaluated, outermost first,
+	// which $dynamicRef is resolved against
+	scope []string
+}
+
+type activeKey struct {
+	n        *node
+	instance string
+}
+
+// evaluation of a single schema against a single instance location
+type evaluation struct {
+	v        *validator
+	n        *node
+	inst     interface{}
+	keyword  pointer.Pointer
+	instance pointer.Pointer
+	u        *unit
+	seen     evaluated
+}
+
+func (v *validator) eval(n *node, inst interface{}, keyword, instance pointer.Pointer) (*unit, evaluated) {
+	e := &evaluation{
+		v:        v,
+		n:        n,
+		inst:     inst,
+		keyword:  keyword,
+		instance: instance,
+		u:        &unit{valid: true, keyword: keyword, absolute: n.location(), instance: instance},
+	}
+
+	key := activeKey{n: n, instance: instance.String()}
+	if v.active[key] {
+		e.u.valid = false
+		e.u.err = fmt.Sprintf("schema %s refers to itself at instance location %q", n.location(), key.instance)
+		if v.err == nil {
+			v.err = fmt.Errorf("Schema %s refers to itself at instance location %q, which would never end", n.location(), key.instance)
+		}
+		return e.u, evaluated{}
+	}
+	v.active[key] = true
+	v.scope = append(v.scope, n.base.uri)
+	defer func() {
+		delete(v.active, key)
+		v.scope = v.scope[:len(v.scope)-1]
+	}()
+
+	if n.always != nil {
+		if !*n.always {
+			e.u.valid = false
+			e.u.err = "the false schema allows no values"
+		}
+		return e.u, e.seen
+	}
+
+	e.evalRefs()
+	e.evalAssertions()
+	e.evalInPlaceApplicators()
+	e.evalArray()
+	e.evalObject()
+	// these depend on the annotations of everything else, so they go last
+	e.evalUnevaluated()
+
+	if !e.u.valid {
+		// annotations of failed schemas are dropped
+		return e.u, evaluated{}
+	}
+	return e.u, e.seen
+}
+
+// fail records a failed assertion of keyword kw
+func (e *evaluation) fail(kw string, format string, args ...interface{}) {
+	e.u.valid = false
+	e.u.children = append(e.u.children, &unit{
+		keyword:  e.keyword.Append(kw),
+		absolute: e.n.location(kw),
+		instance: e.instance,
+		err:      fmt.Sprintf(format, args...),
+	})
+}
+
+// applicator evaluates the subschemas of a keyword and records their results
+// under a single unit for the keyword
+type applicator struct {
+	e *evaluation
+	u *unit
+}
+
+func (e *evaluation) applicator(kw string) applicator {
+	return applicator{e: e, u: &unit{
+		valid:    true,
+		keyword:  e.keyword.Append(kw),
+		absolute: e.n.location(kw),
+		instance: e.instance,
+	}}
+}
+
+// evaluates n against inst, which is found at instance, the subschema is at
+// the keyword location of the applicator followed by path
+func (a applicator) eval(n *node, inst interface{}, instance pointer.Pointer, path ...string) (*unit, evaluated) {
+	keyword := a.u.keyword
+	for _, t := range path {
+		keyword = keyword.Append(t)
+	}
+	u, seen := a.e.v.eval(n, inst, keyword, instance)
+	a.u.children = append(a.u.children, u)
+	return u, seen
+}
+
+// finish records the applicator's result in the schema's unit
+func (a applicator) finish(valid bool, format string, args ...interface{}) {
+	a.u.valid = valid
+	if !valid {
+		a.u.err = fmt.Sprintf(format, args...)
+		a.e.u.valid = false
+	}
+	a.e.u.children = append(a.e.u.children, a.u)
+}
+
+func (e *evaluation) evalRefs() {
+	refs := []struct {
+		kw string
+		n  *node
+	}{
+		{"$ref", e.n.ref},
+		{"$dynamicRef", e.dynamicTarget()},
+	}
+	for _, ref := range refs {
+		if ref.n == nil {
+			continue
+		}
+		a := e.applicator(ref.kw)
+		u, seen := a.eval(ref.n, e.inst, e.instance)
+		e.seen.merge(seen)
+		a.finish(u.valid, "the referenced schema is not satisfied")
+	}
+}
+
+// the schema $dynamicRef refers to: the one with the $dynamicAnchor in the
+// outermost resource of the dynamic scope that has one, if it is dynamic
+func (e *evaluation) dynamicTarget() *node {
+	if e.n.dynamicTargets != nil {
+		for _, uri := range e.v.scope {
+			if n, ok := e.n.dynamicTargets[uri]; ok {
+				return n
+			}
+		}
+	}
+	return e.n.dynamicRef
+}
+
+func (e *evaluation) evalAssertions() {
+	n := e.n
+	if len(n.types) > 0 && !matchesAnyType(e.inst, n.types) {
+		e.fail("type", "expected %s, got %s", strings.Join(n.types, " or "), typeOf(e.inst))
+	}
+	if n.hasEnum {
+		var found bool
+		for _, v := range n.enum {
+			if patch.Equal(e.inst, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			e.fail("enum", "value is not one of the enumerated values")
+		}
+	}
+	if n.hasConst && !patch.Equal(e.inst, n.constant) {
+		e.fail("const", "value must equal the constant %s", describe(n.constant))
+	}
+
+	switch inst := e.inst.(type) {
+	case int, float64:
+		f, _ := toFloat(inst)
+		e.evalNumber(f)
+	case string:
+		e.evalString(inst)
+	}
+}
+
+func (e *evaluation) evalNumber(f float64) {
+	n := e.n
+	if n.multipleOf != nil && !isMultipleOf(f, *n.multipleOf) {
+		e.fail("multipleOf", "%v is not a multiple of %v", f, *n.multipleOf)
+	}
+	if n.maximum != nil && f > *n.maximum {
+		e.fail("maximum", "%v is greater than the maximum of %v", f, *n.maximum)
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		e.fail("exclusiveMaximum", "%v is not less than %v", f, *n.exclusiveMaximum)
+	}
+	if n.minimum != nil && f < *n.minimum {
+		e.fail("minimum", "%v is less than the minimum of %v", f, *n.minimum)
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		e.fail("exclusiveMinimum", "%v is not greater than %v", f, *n.exclusiveMinimum)
+	}
+}
+
+// multipleOf is checked using the decimal representations of both numbers,
+// as the binary ones don't divide evenly, e.g. 0.3 by 0.1
+func isMultipleOf(f, m float64) bool {
+	if f == math.Trunc(f) && m == math.Trunc(m) && math.Abs(f) < 1<<53 && math.Abs(m) < 1<<53 {
+		return math.Mod(f, m) == 0
+	}
+	fr, ok1 := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	mr, ok2 := new(big.Rat).SetString(strconv.FormatFloat(m, 'g', -1, 64))
+	if !ok1 || !ok2 {
+		return false
+	}
+	return new(big.Rat).Quo(fr, mr).IsInt()
+}
+
+func (e *evaluation) evalString(str string) {
+	n := e.n
+	length := utf8.RuneCountInString(str)
+	if n.maxLength != nil && length > *n.maxLength {
+		e.fail("maxLength", "length %d is greater than the maximum of %d", length, *n.maxLength)
+	}
+	if n.minLength != nil && length < *n.minLength {
+		e.fail("minLength", "length %d is less than the minimum of %d", length, *n.minLength)
+	}
+	if n.pattern != nil && !n.pattern.MatchString(str) {
+		e.fail("pattern", "%q does not match the pattern %q", str, n.pattern.String())
+	}
+	if n.format != "" && e.v.assertFormat {
+		if check, ok := formats[n.format]; ok && !check(str) {
+			e.fail("format", "%q is not a valid %s", str, n.format)
+		}
+	}
+}
+
+func (e *evaluation) evalInPlaceApplicators() {
+	n := e.n
+
+	if len(n.allOf) > 0 {
+		a := e.applicator("allOf")
+		var failed int
+		for i, sub := range n.allOf {
+			u, seen := a.eval(sub, e.inst, e.instance, strconv.Itoa(i))
+			if !u.valid {
+				failed++
+			}
+			e.seen.merge(seen)
+		}
+		a.finish(failed == 0, "%d of the subschemas are not satisfied", failed)
+	}
+
+	if len(n.anyOf) > 0 {
+		a := e.applicator("anyOf")
+		var passed int
+		for i, sub := range n.anyOf {
+			// every subschema is evaluated, since all of them contribute annotations
+			u, seen := a.eval(sub, e.inst, e.instance, strconv.Itoa(i))
+			if u.valid {
+				passed++
+				e.seen.merge(seen)
+			}
+		}
+		a.finish(passed > 0, "none of the subschemas are satisfied")
+	}
+
+	if len(n.oneOf) > 0 {
+		a := e.applicator("oneOf")
+		var passed []string
+		var passedSeen evaluated
+		for i, sub := range n.oneOf {
+			u, seen := a.eval(sub, e.inst, e.instance, strconv.Itoa(i))
+			if u.valid {
+				passed = append(passed, strconv.Itoa(i))
+				passedSeen = seen
+			}
+		}
+		if len(passed) == 1 {
+			e.seen.merge(passedSeen)
+			a.finish(true, "")
+		} else if len(passed) == 0 {
+			a.finish(false, "none of the subschemas are satisfied")
+		} else {
+			a.finish(false, "exactly one subschema must be satisfied, but subschemas %s are", strings.Join(passed, ", "))
+		}
+	}
+
+	if n.not != nil {
+		a := e.applicator("not")
+		u, _ := a.eval(n.not, e.inst, e.instance)
+		// the result of the subschema is inverted, so its errors are not errors of the instance
+		a.u.children = nil
+		a.finish(!u.valid, "value must not be valid against the subschema")
+	}
+
+	if n.ifNode != nil {
+		e.evalConditional()
+	}
+
+	if len(n.dependentSchemas) > 0 {
+		if obj, ok := e.inst.(map[string]interface{}); ok {
+			a := e.applicator("dependentSchemas")
+			var failed []string
+			for _, prop := range sortedNodeKeys(n.dependentSchemas) {
+				if _, ok := obj[prop]; !ok {
+					continue
+				}
+				u, seen := a.eval(n.dependentSchemas[prop], e.inst, e.instance, prop)
+				if u.valid {
+					e.seen.merge(seen)
+				} else {
+					failed = append(failed, strconv.Quote(prop))
+				}
+			}
+			a.finish(len(failed) == 0, "the schemas depending on %s are not satisfied", strings.Join(failed, ", "))
+		}
+	}
+}
+
+// if determines whether then or else applies, it never fails by itself
+func (e *evaluation) evalConditional() {
+	n := e.n
+	ifUnit, seen := e.v.eval(n.ifNode, e.inst, e.keyword.Append("if"), e.instance)
+	kw, branch := "else", n.elseNode
+	if ifUnit.valid {
+		e.seen.merge(seen)
+		kw, branch = "then", n.thenNode
+	}
+	if branch == nil {
+		return
+	}
+
+	a := e.applicator(kw)
+	u, seen := a.eval(branch, e.inst, e.instance)
+	if u.valid {
+		e.seen.merge(seen)
+	}
+	a.finish(u.valid, "the %q branch of the conditional is not satisfied", kw)
+}
+
+func (e *evaluation) evalArray() {
+	arr, ok := e.inst.([]interface{})
+	if !ok {
+		return
+	}
+	n := e.n
+
+	if n.maxItems != nil && len(arr) > *n.maxItems {
+		e.fail("maxItems", "%d items is more than the maximum of %d", len(arr), *n.maxItems)
+	}
+	if n.minItems != nil && len(arr) < *n.minItems {
+		e.fail("minItems", "%d items is fewer than the minimum of %d", len(arr), *n.minItems)
+	}
+	if n.uniqueItems {
+		if i, j, ok := findDuplicate(arr); ok {
+			e.fail("uniqueItems", "items %d and %d are equal", i, j)
+		}
+	}
+
+	if len(n.prefixItems) > 0 {
+		a := e.applicator("prefixItems")
+		var failed int
+		for i, sub := range n.prefixItems {
+			if i >= len(arr) {
+				break
+			}
+			u, _ := a.eval(sub, arr[i], e.instance.AppendIndex(i), strconv.Itoa(i))
+			if !u.valid {
+				failed++
+			}
+			e.seen.addItem(i)
+		}
+		a.finish(failed == 0, "%d of the items do not match their schemas", failed)
+	}
+
+	if n.items != nil && len(arr) > len(n.prefixItems) {
+		a := e.applicator("items")
+		var failed int
+		for i := len(n.prefixItems); i < len(arr); i++ {
+			u, _ := a.eval(n.items, arr[i], e.instance.AppendIndex(i))
+			if !u.valid {
+				failed++
+			}
+		}
+		e.seen.allItems = true
+		a.finish(failed == 0, "%d of the items do not match the schema", failed)
+	}
+
+	if n.contains != nil {
+		a := e.applicator("contains")
+		var matched int
+		for i, item := range arr {
+			u, _ := a.eval(n.contains, item, e.instance.AppendIndex(i))
+			if u.valid {
+				matched++
+				e.seen.addItem(i)
+			}
+		}
+		// only the items that matched are of interest to the output
+		a.u.children = nil
+
+		min := 1
+		if n.minContains != nil {
+			min = *n.minContains
+		}
+		switch {
+		case matched < min:
+			a.finish(false, "%d items match the contains schema, at least %d must", matched, min)
+		case n.maxContains != nil && matched > *n.maxContains:
+			a.finish(false, "%d items match the contains schema, at most %d may", matched, *n.maxContains)
+		default:
+			a.finish(true, "")
+		}
+	}
+}
+
+func (e *evaluation) evalObject() {
+	obj, ok := e.inst.(map[string]interface{})
+	if !ok {
+		return
+	}
+	n := e.n
+	keys := encode.SortedKeys(obj)
+
+	if n.maxProperties != nil && len(obj) > *n.maxProperties {
+		e.fail("maxProperties", "%d properties is more than the maximum of %d", len(obj), *n.maxProperties)
+	}
+	if n.minProperties != nil && len(obj) < *n.minProperties {
+		e.fail("minProperties", "%d properties is fewer than the minimum of %d", len(obj), *n.minProperties)
+	}
+	if missing := missingProperties(obj, n.required); len(missing) > 0 {
+		e.fail("required", "missing required properties: %s", strings.Join(missing, ", "))
+	}
+	for _, prop := range keys {
+		if _, ok := n.dependentRequired[prop]; !ok {
+			continue
+		}
+		if missing := missingProperties(obj, n.dependentRequired[prop]); len(missing) > 0 {
+			e.fail("dependentRequired", "properties %s are required when %q is present", strings.Join(missing, ", "), prop)
+		}
+	}
+
+	// properties matched by properties or patternProperties, which
+	// additionalProperties does not apply to
+	matched := map[string]bool{}
+
+	if len(n.properties) > 0 {
+		a := e.applicator("properties")
+		var failed []string
+		for _, prop := range keys {
+			sub, ok := n.properties[prop]
+			if !ok {
+				continue
+			}
+			matched[prop] = true
+			u, _ := a.eval(sub, obj[prop], e.instance.Append(prop), prop)
+			if !u.valid {
+				failed = append(failed, strconv.Quote(prop))
+			}
+			e.seen.addProp(prop)
+		}
+		a.finish(len(failed) == 0, "properties %s do not match their schemas", strings.Join(failed, ", "))
+	}
+
+	if len(n.patternProperties) > 0 {
+		a := e.applicator("patternProperties")
+		var failed []string
+		for _, prop := range keys {
+			for _, p := range n.patternProperties {
+				if !p.re.MatchString(prop) {
+					continue
+				}
+				matched[prop] = true
+				u, _ := a.eval(p.n, obj[prop], e.instance.Append(prop), p.src)
+				if !u.valid {
+					failed = append(failed, strconv.Quote(prop))
+				}
+				e.seen.addProp(prop)
+			}
+		}
+		a.finish(len(failed) == 0, "properties %s do not match their schemas", strings.Join(failed, ", "))
+	}
+
+	if n.additionalProperties != nil {
+		a := e.applicator("additionalProperties")
+		var failed []string
+		for _, prop := range keys {
+			if matched[prop] {
+				continue
+			}
+			u, _ := a.eval(n.additionalProperties, obj[prop], e.instance.Append(prop))
+			if !u.valid {
+				failed = append(failed, strconv.Quote(prop))
+			}
+			e.seen.addProp(prop)
+		}
+		a.finish(len(failed) == 0, "additional properties %s are not allowed", strings.Join(failed, ", "))
+	}
+
+	if n.propertyNames != nil {
+		a := e.applicator("propertyNames")
+		var failed []string
+		for _, prop := range keys {
+			u, _ := a.eval(n.propertyNames, prop, e.instance)
+			if !u.valid {
+				failed = append(failed, strconv.Quote(prop))
+			}
+		}
+		a.finish(len(failed) == 0, "property names %s are not valid", strings.Join(failed, ", "))
+	}
+}
+
+func (e *evaluation) evalUnevaluated() {
+	n := e.n
+
+	if arr, ok := e.inst.([]interface{}); ok && n.unevaluatedItems != nil && !e.seen.allItems {
+		a := e.applicator("unevaluatedItems")
+		var failed int
+		for i, item := range arr {
+			if e.seen.items[i] {
+				continue
+			}
+			u, _ := a.eval(n.unevaluatedItems, item, e.instance.AppendIndex(i))
+			if !u.valid {
+				failed++
+			}
+		}
+		e.seen.allItems = true
+		a.finish(failed == 0, "%d of the unevaluated items are not allowed", failed)
+	}
+
+	if obj, ok := e.inst.(map[string]interface{}); ok && n.unevaluatedProperties != nil {
+		a := e.applicator("unevaluatedProperties")
+		var failed []string
+		for _, prop := range encode.SortedKeys(obj) {
+			if e.seen.props[prop] {
+				continue
+			}
+			u, _ := a.eval(n.unevaluatedProperties, obj[prop], e.instance.Append(prop))
+			if !u.valid {
+				failed = append(failed, strconv.Quote(prop))
+			}
+			e.seen.addProp(prop)
+		}
+		a.finish(len(failed) == 0, "unevaluated properties %s are not allowed", strings.Join(failed, ", "))
+	}
+}
+
+func missingProperties(obj map[string]interface{}, required []string) []string {
+	var missing []string
+	for _, prop := range required {
+		if _, ok := obj[prop]; !ok {
+			missing = append(missing, strconv.Quote(prop))
+		}
+	}
+	return missing
+}
+
+func findDuplicate(arr []interface{}) (int, int, bool) {
+	for i := range arr {
+		for j := i + 1; j < len(arr); j++ {
+			if patch.Equal(arr[i], arr[j]) {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func matchesAnyType(v interface{}, types []string) bool {
+	actual := typeOf(v)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the JSON Schema type of v
+// numbers without a fractional part are integers, whether parsed as int or float64
+func typeOf(v interface{}) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case int:
+		return "integer"
+	case float64:
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func describe(v interface{}) string {
+	encoded, err := encode.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(encoded)
+}
+
+func sortedNodeKeys(m map[string]*node) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,241 @@
+package tape
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Kind is the type of a JSON value
+type Kind uint8
+
+// the kinds of JSON values, numbers with a fraction or an exponent are Floats
+const (
+	Null Kind = iota
+	Bool
+	Integer
+	Float
+	String
+	Array
+	Object
+)
+
+var kindNames = [...]string{"null", "boolean", "integer", "float", "string", "array", "object"}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+var kindOfType = [256]Kind{
+	'n': Null,
+	't': Bool,
+	'f': Bool,
+	'l': Integer,
+	'd': Float,
+	'"': String,
+	'[': Array,
+	'{': Object,
+}
+
+// Cursor points at a value within a Tape
+type Cursor struct {
+	t *Tape
+	i int // index of the value's first word
+}
+
+// Root returns a Cursor at the value of the whole document
+func (t *Tape) Root() Cursor {
+	return Cursor{t: t, i: 1}
+}
+
+// Kind returns the type of the value
+func (c Cursor) Kind() Kind {
+	return kindOfType[c.t.typeAt(c.i)]
+}
+
+// end returns the index just past the value and everything in it
+func (c Cursor) end() int {
+	switch c.t.typeAt(c.i) {
+	case '{', '[':
+		return int(c.t.payloadAt(c.i) & indexMask)
+	}
+	return c.i + 1
+}
+
+// Len returns the number of elements of an array or members of an object,
+// and 0 for other values
+func (c Cursor) Len() int {
+	switch c.t.typeAt(c.i) {
+	case '{', '[':
+	default:
+		return 0
+	}
+	n := int(c.t.payloadAt(c.i) >> countShift)
+	if n < maxCount {
+		return n
+	}
+	// too many to be recorded, count them
+	n = 0
+	for it := c.Iter(); it.Next(); {
+		n++
+	}
+	return n
+}
+
+// Index returns the i-th element of an array, reporting false if there is none
+func (c Cursor) Index(i int) (Cursor, bool) {
+	if c.t.typeAt(c.i) != '[' || i < 0 {
+		return Cursor{}, false
+	}
+	for it := c.Iter(); it.Next(); i-- {
+		if i == 0 {
+			return it.Value(), true
+		}
+	}
+	return Cursor{}, false
+}
+
+// Get returns the value of the member of an object with the given key,
+// reporting false if there is none
+func (c Cursor) Get(key string) (Cursor, bool) {
+	if c.t.typeAt(c.i) != '{' {
+		return Cursor{}, false
+	}
+	for it := c.Iter(); it.Next(); {
+		if string(c.t.stringAt(it.i-1)) == key {
+			return it.Value(), true
+		}
+	}
+	return Cursor{}, false
+}
+
+// the bytes of the string at word i
+func (t *Tape) stringAt(i int) []byte {
+	off := t.payloadAt(i)
+	n := binary.LittleEndian.Uint32(t.buf[off:])
+	return t.buf[off+4 : off+4+uint64(n)]
+}
+
+func (t *Tape) numberAt(i int) uint64 {
+	return binary.LittleEndian.Uint64(t.buf[t.payloadAt(i):])
+}
+
+// String returns the value of a string
+func (c Cursor) String() (string, error) {
+	if k := c.Kind(); k != String {
+		return "", fmt.Errorf("Expected string, found: %s", k)
+	}
+	return string(c.t.stringAt(c.i)), nil
+}
+
+// Int returns the value of an integer
+func (c Cursor) Int() (int64, error) {
+	if k := c.Kind(); k != Integer {
+		return 0, fmt.Errorf("Expected integer, found: %s", k)
+	}
+	return int64(c.t.numberAt(c.i)), nil
+}
+
+// Float returns the value of any number, integers are converted
+func (c Cursor) Float() (float64, error) {
+	switch k := c.Kind(); k {
+	case Integer:
+		return float64(int64(c.t.numberAt(c.i))), nil
+	case Float:
+		return math.Float64frombits(c.t.numberAt(c.i)), nil
+	default:
+		return 0, fmt.Errorf("Expected number, found: %s", k)
+	}
+}
+
+// Bool returns the value of a boolean
+func (c Cursor) Bool() (bool, error) {
+	if k := c.Kind(); k != Bool {
+		return false, fmt.Errorf("Expected boolean, found: %s", k)
+	}
+	return c.t.typeAt(c.i) == 't', nil
+}
+
+// IsNull reports whether the value is null
+func (c Cursor) IsNull() bool {
+	return c.t.typeAt(c.i) == 'n'
+}
+
+// Iter iterates over the elements of an array or the members of an object
+//
+//	for it := c.Iter(); it.Next(); {
+//		use(it.Key(), it.Value())
+//	}
+type Iter struct {
+	t      *Tape
+	object bool
+	i      int // the current value, or the first word of the container before Next
+	next   int // index of the next element or key
+	end    int // index of the end word of the container
+}
+
+// Iter returns an Iter over the value, which has nothing to iterate
+// over unless the value is an array or object
+func (c Cursor) Iter() Iter {
+	switch c.t.typeAt(c.i) {
+	case '{', '[':
+		return Iter{t: c.t, object: c.t.typeAt(c.i) == '{', i: c.i, next: c.i + 1, end: c.end() - 1}
+	}
+	return Iter{t: c.t, i: c.i, next: c.i, end: c.i}
+}
+
+// Next advances to the next element or member, reporting false when there are no more
+func (it *Iter) Next() bool {
+	if it.next >= it.end {
+		return false
+	}
+	it.i = it.next
+	if it.object {
+		it.i++ // past the key
+	}
+	it.next = Cursor{t: it.t, i: it.i}.end()
+	return true
+}
+
+// Key returns the key of the current member of an object
+func (it *Iter) Key() string {
+	if !it.object {
+		return ""
+	}
+	return string(it.t.stringAt(it.i - 1))
+}
+
+// Value returns the current element or member value
+func (it *Iter) Value() Cursor {
+	return Cursor{t: it.t, i: it.i}
+}
+
+// Interface returns the value in the form parse.Parse returns,
+// with integers as int
+func (c Cursor) Interface() interface{} {
+	switch c.t.typeAt(c.i) {
+	case 'n':
+		return nil
+	case 't':
+		return true
+	case 'f':
+		return false
+	case 'l':
+		return int(int64(c.t.numberAt(c.i)))
+	case 'd':
+		return math.Float64frombits(c.t.numberAt(c.i))
+	case '"':
+		return string(c.t.stringAt(c.i))
+	case '[':
+		out := make([]interface{}, 0, c.Len())
+		for it := c.Iter(); it.Next(); {
+			out = append(out, it.Value().Interface())
+		}
+		return out
+	}
+	out := make(map[string]interface{}, c.Len())
+	for it := c.Iter(); it.Next(); {
+		out[it.Key()] = it.Value().Interface()
+	}
+	return out
+}
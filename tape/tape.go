@@ -0,0 +1,172 @@
+// Package tape parses JSON documents into a compact flat representation
+//
+// in the spirit of simdjson, a Tape holds a whole document in two slices:
+// the tape, with one 64 bit word for every value and for the end of every
+// object and array, and a buffer with the contents of strings and numbers
+// this takes far less memory than map[string]interface{} trees, and a handful
+// of allocations rather than one for every value
+package tape
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/vyevs/gojson/lex"
+	"github.com/vyevs/gojson/tok"
+)
+
+// the type of a word is held in its top 8 bits, its payload in the rest
+//
+//	'r'       root, first and last word, the payload of the first is the index of the last
+//	'{', '['  start of an object or array, payload is the index just past its end word,
+//	          and the number of members or elements in bits 32-55, saturated at maxCount
+//	'}', ']'  end of an object or array, payload is the index of its start word
+//	'"'       string, payload is the offset in the buffer of its 4 byte length and bytes
+//	'l', 'd'  integer or float, payload is the offset in the buffer of its 8 bytes
+//	't', 'f', 'n'  true, false, null
+//
+// object members are a string word for the key followed by the words of the value
+const (
+	typeShift   = 56
+	payloadMask = 1<<typeShift - 1
+	countShift  = 32
+	indexMask   = 1<<countShift - 1
+	maxCount    = 1<<(typeShift-countShift) - 1
+)
+
+// Tape is a JSON document in flat form
+type Tape struct {
+	words []uint64
+	buf   []byte
+}
+
+// Parse parses the JSON document in data into a Tape
+//
+// Parse accepts the documents parse.Parse accepts, except that objects with
+// duplicate keys are not rejected, and integers must fit in an int64
+// data is not retained
+func Parse(data []byte) (*Tape, error) {
+	b := builder{
+		data: data,
+		t:    &Tape{words: make([]uint64, 0, len(data)/8+2), buf: make([]byte, 0, len(data)/2)},
+	}
+	b.t.words = append(b.t.words, 'r'<<typeShift)
+	if err := lex.Scan(data, &b); err != nil {
+		return nil, err
+	}
+	b.t.words[0] |= uint64(len(b.t.words))
+	b.t.words = append(b.t.words, 'r'<<typeShift)
+	return b.t, nil
+}
+
+// Size returns the number of bytes the tape and buffer take up
+func (t *Tape) Size() int {
+	return 8*len(t.words) + len(t.buf)
+}
+
+func (t *Tape) typeAt(i int) byte {
+	return byte(t.words[i] >> typeShift)
+}
+
+func (t *Tape) payloadAt(i int) uint64 {
+	return t.words[i] & payloadMask
+}
+
+// builder appends the values lex.Scan finds in a document to the tape
+type builder struct {
+	data []byte
+	t    *Tape
+	open []int // indexes of the start words of the objects and arrays not yet ended
+}
+
+func (b *builder) appendWord(typ byte, payload uint64) {
+	b.t.words = append(b.t.words, uint64(typ)<<typeShift|payload)
+}
+
+// appends the 8 bytes of a number to the buffer
+func (b *builder) appendNumber(typ byte, bits uint64) {
+	b.appendWord(typ, uint64(len(b.t.buf)))
+	var scratch [8]byte
+	binary.LittleEndian.PutUint64(scratch[:], bits)
+	b.t.buf = append(b.t.buf, scratch[:]...)
+}
+
+func (b *builder) Value(t tok.TokenType, start, end int, escaped bool) error {
+	switch t {
+	case tok.String:
+		return b.parseString(start, end, escaped)
+	case tok.Integer, tok.FloatingPoint:
+		return b.parseNumber(t, start, end)
+	case tok.Boolean:
+		b.appendWord(b.data[start], 0)
+	default:
+		b.appendWord('n', 0)
+	}
+	return nil
+}
+
+func (b *builder) Begin(t tok.TokenType, start int) error {
+	b.open = append(b.open, len(b.t.words))
+	if t == tok.OpeningCurlyBrace {
+		b.appendWord('{', 0)
+	} else {
+		b.appendWord('[', 0)
+	}
+	return nil
+}
+
+func (b *builder) End(t tok.TokenType, end, count int) error {
+	start := b.open[len(b.open)-1]
+	b.open = b.open[:len(b.open)-1]
+	if count > maxCount {
+		count = maxCount
+	}
+	if t == tok.ClosingCurlyBrace {
+		b.appendWord('}', uint64(start))
+	} else {
+		b.appendWord(']', uint64(start))
+	}
+	b.t.words[start] |= uint64(count)<<countShift | uint64(len(b.t.words))
+	return nil
+}
+
+// decodes the string between start and end into the buffer
+func (b *builder) parseString(start, end int, escaped bool) error {
+	// the length is filled in once the string is decoded
+	off := len(b.t.buf)
+	b.appendWord('"', uint64(off))
+	b.t.buf = append(b.t.buf, 0, 0, 0, 0)
+
+	str := b.data[start+1 : end-1]
+	if escaped {
+		var ok bool
+		if b.t.buf, ok = lex.AppendUnescaped(b.t.buf, str); !ok {
+			return fmt.Errorf("Invalid escape sequence in string beginning at offset %d", start)
+		}
+	} else {
+		b.t.buf = append(b.t.buf, str...)
+	}
+	binary.LittleEndian.PutUint32(b.t.buf[off:], uint32(len(b.t.buf)-off-4))
+	return nil
+}
+
+// parses the number between start and end into the buffer
+func (b *builder) parseNumber(t tok.TokenType, start, end int) error {
+	literal := string(b.data[start:end])
+	if t == tok.FloatingPoint {
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return fmt.Errorf("Number %s at offset %d out of range", literal, start)
+		}
+		b.appendNumber('d', math.Float64bits(f))
+		return nil
+	}
+	i, err := strconv.ParseInt(literal, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Integer %s at offset %d out of range", literal, start)
+	}
+	b.appendNumber('l', uint64(i))
+	return nil
+}
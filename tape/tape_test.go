@@ -0,0 +1,189 @@
+package tape
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/vyevs/gojson/parse"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		doc     string
+		want    interface{}
+		wantErr bool
+	}{
+		{doc: `null`, want: nil},
+		{doc: ` true `, want: true},
+		{doc: `-12`, want: -12},
+		{doc: `1.5e2`, want: 150.0},
+		{doc: `"a\"é"`, want: `a"é`},
+		{doc: `[]`, want: []interface{}{}},
+		{doc: `{}`, want: map[string]interface{}{}},
+		{doc: "[\r\n\t1\r\n]\r\n", want: []interface{}{1}},
+		{
+			doc:  `{"a": [1, {"b": null}, [[]]], "c": "d"}`,
+			want: map[string]interface{}{"a": []interface{}{1, map[string]interface{}{"b": nil}, []interface{}{[]interface{}{}}}, "c": "d"},
+		},
+		{doc: ``, wantErr: true},
+		{doc: `[1,]`, wantErr: true},
+		{doc: `{"a" 1}`, wantErr: true},
+		{doc: `{1: 1}`, wantErr: true},
+		{doc: `"\q"`, wantErr: true},
+		{doc: `"abc`, wantErr: true},
+		{doc: `01`, wantErr: true},
+		{doc: `1e`, wantErr: true},
+		{doc: `99999999999999999999`, wantErr: true},
+		{doc: `nul`, wantErr: true},
+		{doc: `[] x`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		tp, err := Parse([]byte(test.doc))
+
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("doc: %q, err: %v, wantErr: %v", test.doc, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := tp.Root().Interface(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("doc: %q, got: %#v, want: %#v", test.doc, got, test.want)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	tp, err := Parse([]byte(`{"name": "x", "mass": 21, "lat": -1.5, "fell": false, "note": null,
+		"coords": [6.5, 50], "geo": {"type": "Point"}}`))
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+	root := tp.Root()
+
+	if root.Kind() != Object || root.Len() != 7 {
+		t.Errorf("root kind: %s, len: %d, want: object, 7", root.Kind(), root.Len())
+	}
+	var keys []string
+	for it := root.Iter(); it.Next(); {
+		keys = append(keys, it.Key())
+	}
+	if want := []string{"name", "mass", "lat", "fell", "note", "coords", "geo"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys: %q, want: %q", keys, want)
+	}
+
+	name, _ := root.Get("name")
+	if s, err := name.String(); err != nil || s != "x" {
+		t.Errorf("name: %q, err: %v", s, err)
+	}
+	mass, _ := root.Get("mass")
+	if i, err := mass.Int(); err != nil || i != 21 {
+		t.Errorf("mass: %d, err: %v", i, err)
+	}
+	if f, err := mass.Float(); err != nil || f != 21 {
+		t.Errorf("mass as float: %v, err: %v", f, err)
+	}
+	lat, _ := root.Get("lat")
+	if f, err := lat.Float(); err != nil || f != -1.5 {
+		t.Errorf("lat: %v, err: %v", f, err)
+	}
+	fell, _ := root.Get("fell")
+	if b, err := fell.Bool(); err != nil || b {
+		t.Errorf("fell: %v, err: %v", b, err)
+	}
+	if note, _ := root.Get("note"); !note.IsNull() {
+		t.Errorf("note kind: %s, want: null", note.Kind())
+	}
+	if _, ok := root.Get("missing"); ok {
+		t.Errorf("found missing key")
+	}
+
+	coords, _ := root.Get("coords")
+	second, ok := coords.Index(1)
+	if i, err := second.Int(); !ok || err != nil || i != 50 {
+		t.Errorf("coords[1]: %d, found: %v, err: %v", i, ok, err)
+	}
+	if _, ok := coords.Index(2); ok {
+		t.Errorf("found coords[2]")
+	}
+	geo, _ := root.Get("geo")
+	typ, _ := geo.Get("type")
+	if s, _ := typ.String(); s != "Point" {
+		t.Errorf("geo type: %q, want: Point", s)
+	}
+
+	if _, err := root.String(); err == nil {
+		t.Errorf("expected error reading object as string")
+	}
+	if _, err := name.Int(); err == nil {
+		t.Errorf("expected error reading string as integer")
+	}
+	if _, ok := name.Get("x"); ok {
+		t.Errorf("expected no members in a string")
+	}
+}
+
+// more elements than the count in a start word can hold
+func TestLongArray(t *testing.T) {
+	n := maxCount + 10
+	data := append(append([]byte("["), bytes.Repeat([]byte("0,"), n-1)...), "0]"...)
+
+	tp, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(): %v", err)
+	}
+	if got := tp.Root().Len(); got != n {
+		t.Errorf("len: %d, want: %d", got, n)
+	}
+}
+
+func TestInterfaceMatchesParse(t *testing.T) {
+	files := []string{"colors1.json", "colors2.json", "colors3.json", "gdp.json", "meteorites.json"}
+	for _, f := range files {
+		data, err := ioutil.ReadFile("../parse/testdata/" + f)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", f, err)
+		}
+		tp, err := Parse(data)
+		if err != nil {
+			t.Errorf("%s: Parse(): %v", f, err)
+			continue
+		}
+		want, err := parse.Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: parse.Parse(): %v", f, err)
+		}
+		if got := tp.Root().Interface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: tape and parse.Parse differ", f)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	data, err := ioutil.ReadFile("../parse/testdata/meteorites.json")
+	if err != nil {
+		b.Fatalf("ReadFile(): %v", err)
+	}
+
+	b.Run("tape", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Parse(data); err != nil {
+				b.Fatalf("Parse(): %v", err)
+			}
+		}
+	})
+	b.Run("parse.Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := parse.Parse(bytes.NewReader(data)); err != nil {
+				b.Fatalf("Parse(): %v", err)
+			}
+		}
+	})
+	tp, _ := Parse(data)
+	b.Logf("tape size: %d bytes for a %d byte document", tp.Size(), len(data))
+}
@@ -30,6 +30,12 @@ const (
 	EOF
 
 	Invalid
+
+	// Comment is only read by lexers that keep the comments of JSONC documents
+	Comment
+
+	// Identifier is an unquoted key, only read by lexers of JSON5 documents
+	Identifier
 )
 
 // Token represents a sequence of characters in a json doc
@@ -87,6 +93,8 @@ var tokenTypeToString = map[TokenType]string{
 	Null:          "Null",
 	EOF:           "EOF",
 	Invalid:       "Invalid",
+	Comment:       "Comment",
+	Identifier:    "Identifier",
 }
 
 func (tokType TokenType) String() string {